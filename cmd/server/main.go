@@ -1,15 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
+	_ "time/tzdata" // user timezones must resolve even without system zoneinfo
 
 	"github.com/czeful/diplom_back/internal/config"
 	"github.com/czeful/diplom_back/internal/database"
 	"github.com/czeful/diplom_back/internal/handlers"
+	"github.com/czeful/diplom_back/internal/models"
 	"github.com/czeful/diplom_back/internal/repository"
 	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/internal/storage"
 	"github.com/czeful/diplom_back/pkg/middleware"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
@@ -25,8 +30,9 @@ func main() {
 	// Apply CORS middleware
 	handler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000"}, // Разрешаем React
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "Last-Event-ID", "If-Match", "If-None-Match", "Range", "If-Range", handlers.LinkPasswordHeader},
+		ExposedHeaders:   []string{"ETag", "Content-Range", "Content-Disposition", "Accept-Ranges"},
 		AllowCredentials: true,
 	}).Handler(router)
 
@@ -36,36 +42,341 @@ func main() {
 		log.Fatalf("Database connection error: %v", err)
 	}
 
+	// Store files in GridFS unless local blob storage is configured
+	var blobStore storage.BlobStore
+	if cfg.BlobStorage == "local" {
+		blobStore, err = storage.NewLocalStore(cfg.BlobDir)
+	} else {
+		blobStore, err = storage.NewGridFSStore(db, "blobs")
+	}
+	if err != nil {
+		log.Fatalf("Blob storage error: %v", err)
+	}
+
 	// Initialize repositories, services, and handlers for goals
 	goalRepo := repository.NewGoalRepository(db)
 	goalService := services.NewGoalService(goalRepo)
 	goalHandler := handlers.NewGoalHandler(goalService)
+	if err := goalRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to create goal indexes: %v", err)
+	}
 
 	// Initialize repositories, services, and handlers for users
 	userRepo := repository.NewUserRepository(db)
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(userRepo, blobStore)
 	userHandler := handlers.NewUserHandler(userService, cfg)
 
+	// Interpret all-day due dates in each user's own timezone
+	goalService.SetLocationLookup(userService.Location)
+
+	// Initialize repositories, services, and handlers for user settings
+	settingsDefaults := models.DefaultSettings()
+	settingsDefaults.AutoArchiveDays = cfg.AutoArchiveDays
+	settingsRepo := repository.NewSettingsRepository(db)
+	settingsService := services.NewSettingsService(settingsRepo, settingsDefaults)
+	settingsHandler := handlers.NewSettingsHandler(settingsService, goalService)
+	goalService.SetSettingsLookup(settingsService.GetSettings)
+
+	// Initialize repositories, services, and handlers for webhooks
+	webhookRepo := repository.NewWebhookRepository(db)
+	webhookService := services.NewWebhookService(webhookRepo)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+
+	// Deliver goal lifecycle events to user webhooks
+	goalService.AddListener(webhookService.HandleGoalEvent)
+	go webhookService.RunDeliveries(context.Background(), cfg.WebhookWorkers)
+
+	// Push goal events to connected clients in real time
+	goalBroker := services.NewMemoryGoalBroker(goalService.Viewers)
+	goalService.AddListener(goalBroker.Publish)
+	streamHandler := handlers.NewStreamHandler(goalBroker)
+
+	// Record every goal mutation in the activity history
+	historyRepo := repository.NewHistoryRepository(db)
+	historyService := services.NewHistoryService(historyRepo, goalService)
+	historyHandler := handlers.NewHistoryHandler(historyService, goalService)
+	goalService.AddListener(historyService.RecordGoalEvent)
+	if err := historyRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to create history indexes: %v", err)
+	}
+
+	// Initialize repositories, services, and handlers for goal templates
+	templateRepo := repository.NewTemplateRepository(db)
+	templateService := services.NewTemplateService(templateRepo, goalService)
+	templateHandler := handlers.NewTemplateHandler(templateService, goalService)
+	if err := templateService.SeedSystemTemplates(context.Background()); err != nil {
+		log.Printf("Failed to seed system templates: %v", err)
+	}
+
+	// Initialize repositories, services, and handlers for categories and tags
+	categoryRepo := repository.NewCategoryRepository(db)
+	categoryService := services.NewCategoryService(categoryRepo, goalService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	goalService.SetCategoryLookup(categoryService.CategoryNames)
+	tagRepo := repository.NewTagRepository(db)
+	tagService := services.NewTagService(tagRepo, goalService)
+	tagHandler := handlers.NewTagHandler(tagService)
+
+	// Initialize repositories, services, and handlers for goal notes
+	noteRepo := repository.NewNoteRepository(db)
+	noteService := services.NewNoteService(noteRepo, goalService)
+	noteHandler := handlers.NewNoteHandler(noteService, goalService)
+	goalService.AddListener(noteService.HandleGoalEvent)
+	goalService.AddCloneHook(noteService.CopyNotes)
+
+	// Initialize repositories, services, and handlers for goal attachments
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	attachmentService := services.NewAttachmentService(attachmentRepo, blobStore, services.AttachmentLimits{
+		MaxSize: cfg.MaxAttachmentSize,
+		Quota:   cfg.StorageQuota,
+	})
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, goalService)
+	goalService.AddListener(attachmentService.HandleGoalEvent)
+	goalService.AddCloneHook(attachmentService.CopyAttachments)
+
+	// Compute goal statistics, recomputing them once a user's goals change
+	statsRepo := repository.NewStatsRepository(db)
+	statsService := services.NewStatsService(statsRepo, goalService)
+	statsHandler := handlers.NewStatsHandler(statsService, goalService)
+	goalService.AddListener(statsService.HandleGoalEvent)
+	if err := statsRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to create statistics indexes: %v", err)
+	}
+
+	// Initialize repositories, services, and handlers for notifications
+	notificationRepo := repository.NewNotificationRepository(db)
+	notificationService := services.NewNotificationService(notificationRepo, goalService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+
+	// Award XP and achievements as goals progress, following the configured rules
+	achievementRules, err := services.LoadAchievementRules(cfg.AchievementsFile)
+	if err != nil {
+		log.Fatalf("Achievement rules error: %v", err)
+	}
+	achievementRepo := repository.NewAchievementRepository(db)
+	achievementService := services.NewAchievementService(achievementRepo, achievementRules, goalService, statsService, notificationService)
+	achievementHandler := handlers.NewAchievementHandler(achievementService)
+	goalService.AddListener(achievementService.HandleGoalEvent)
+	go achievementService.RunAwards(context.Background(), cfg.AchievementWorkers)
+	if err := achievementRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to create achievement indexes: %v", err)
+	}
+
+	// Initialize repositories, services, and handlers for goal sharing
+	shareRepo := repository.NewShareRepository(db)
+	shareService := services.NewShareService(shareRepo, goalService, userService, notificationService)
+	shareHandler := handlers.NewShareHandler(shareService, goalService)
+	goalService.AddAccessLookup(shareService.AccessRole)
+	goalService.AddAudienceLookup(shareService.Audience)
+	goalService.AddListener(shareService.HandleGoalEvent)
+	if err := shareRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to create share indexes: %v", err)
+	}
+
+	// Initialize repositories, services, and handlers for organizations
+	orgRepo := repository.NewOrgRepository(db)
+	orgService := services.NewOrgService(orgRepo, goalService, userService, notificationService)
+	orgHandler := handlers.NewOrgHandler(orgService, goalService, cfg)
+	goalService.SetOrgLookup(orgService.Role)
+	goalService.AddAudienceLookup(orgService.Audience)
+	if err := orgRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to create organization indexes: %v", err)
+	}
+
+	// Initialize repositories, services, and handlers for accountability partners
+	partnerRepo := repository.NewPartnerRepository(db)
+	partnerService := services.NewPartnerService(partnerRepo, goalService, userService, notificationService)
+	partnerHandler := handlers.NewPartnerHandler(partnerService, goalService)
+	goalService.AddAccessLookup(partnerService.AccessRole)
+	goalService.AddAudienceLookup(partnerService.Audience)
+	goalService.AddListener(partnerService.HandleGoalEvent)
+	if err := partnerRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to create partner indexes: %v", err)
+	}
+
+	// Initialize repositories, services, and handlers for public goal links
+	publicLinkRepo := repository.NewPublicLinkRepository(db)
+	publicLinkService := services.NewPublicLinkService(publicLinkRepo, goalService, userService)
+	publicLinkHandler := handlers.NewPublicLinkHandler(publicLinkService, goalService)
+	goalService.AddListener(publicLinkService.HandleGoalEvent)
+	if err := publicLinkRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to create public link indexes: %v", err)
+	}
+
+	// Permanently remove goals that have been in the trash longer than the retention period
+	go goalService.RunTrashPurger(context.Background(), cfg.TrashRetention, time.Hour)
+
+	// Archive goals that have been completed for longer than their owner's chosen period
+	go goalService.RunAutoArchiver(context.Background(), time.Hour)
+
+	// Send partners their weekly summaries of each other's progress
+	go partnerService.RunWeeklySummaries(context.Background(), time.Hour)
+
 	// Apply authentication middleware to goal routes
 	protectedRoutes := router.PathPrefix("/goals").Subrouter()
 	protectedRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	protectedRoutes.HandleFunc("/stream", streamHandler.GoalStreamHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/matrix", goalHandler.GetGoalMatrixHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/trash", goalHandler.GetTrashHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/shared", shareHandler.GetSharedGoalsHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/bulk", goalHandler.BulkGoalsHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/from-template/{templateId}", templateHandler.CreateGoalFromTemplateHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/archive", goalHandler.BulkArchiveGoalsHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/unarchive", goalHandler.BulkUnarchiveGoalsHandler).Methods("POST")
 	protectedRoutes.HandleFunc("", goalHandler.CreateGoalHandler).Methods("POST")
 	protectedRoutes.HandleFunc("/{id}", goalHandler.GetGoalHandler).Methods("GET")
 	protectedRoutes.HandleFunc("/{id}", goalHandler.UpdateGoalHandler).Methods("PUT")
+	protectedRoutes.HandleFunc("/{id}", goalHandler.PatchGoalHandler).Methods("PATCH")
 	protectedRoutes.HandleFunc("/{id}", goalHandler.DeleteGoalHandler).Methods("DELETE")
 	protectedRoutes.HandleFunc("/{id}/progress", goalHandler.UpdateGoalProgressHandler).Methods("PATCH")
 	protectedRoutes.HandleFunc("/{id}/progress", goalHandler.GetGoalProgressHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/{id}/forecast", goalHandler.GetGoalForecastHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/{id}/assignees", goalHandler.AssignStepHandler).Methods("PATCH")
+	protectedRoutes.HandleFunc("/{id}/shares", shareHandler.GetSharesHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/{id}/shares", shareHandler.CreateShareHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/{id}/shares/{shareId}", shareHandler.UpdateShareHandler).Methods("PATCH")
+	protectedRoutes.HandleFunc("/{id}/shares/{shareId}", shareHandler.DeleteShareHandler).Methods("DELETE")
+	protectedRoutes.HandleFunc("/{id}/partners", partnerHandler.SetPartnerVisibilityHandler).Methods("PUT")
+	protectedRoutes.HandleFunc("/{id}/links", publicLinkHandler.GetLinksHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/{id}/links", publicLinkHandler.CreateLinkHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/{id}/links/{linkId}", publicLinkHandler.RevokeLinkHandler).Methods("DELETE")
+	protectedRoutes.HandleFunc("/{id}/archive", goalHandler.ArchiveGoalHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/{id}/unarchive", goalHandler.UnarchiveGoalHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/{id}/restore", goalHandler.RestoreGoalHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/{id}/purge", goalHandler.PurgeGoalHandler).Methods("DELETE")
+	protectedRoutes.HandleFunc("/{id}/clone", goalHandler.CloneGoalHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/{id}/template", templateHandler.SaveGoalAsTemplateHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/{id}/history", historyHandler.GetGoalHistoryHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/{id}/history/{revision}/restore", historyHandler.RestoreGoalRevisionHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/{id}/notes", noteHandler.GetNotesHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/{id}/notes", noteHandler.CreateNoteHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/{id}/notes/{noteId}", noteHandler.GetNoteHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/{id}/notes/{noteId}", noteHandler.UpdateNoteHandler).Methods("PUT")
+	protectedRoutes.HandleFunc("/{id}/notes/{noteId}", noteHandler.DeleteNoteHandler).Methods("DELETE")
+	protectedRoutes.HandleFunc("/{id}/attachments", attachmentHandler.GetAttachmentsHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/{id}/attachments", attachmentHandler.UploadAttachmentHandler).Methods("POST")
+	protectedRoutes.HandleFunc("/{id}/attachments/{attachmentId}", attachmentHandler.DeleteAttachmentHandler).Methods("DELETE")
+	protectedRoutes.HandleFunc("/{id}/attachments/{attachmentId}/content", attachmentHandler.DownloadAttachmentHandler).Methods("GET")
+	protectedRoutes.HandleFunc("/{id}/attachments/{attachmentId}/thumbnail", attachmentHandler.GetThumbnailHandler).Methods("GET")
 	protectedRoutes.HandleFunc("", goalHandler.GetGoalsHandler).Methods("GET")
 
 	// Register User routes
 	router.HandleFunc("/users/register", userHandler.RegisterUserHandler).Methods("POST")
 	router.HandleFunc("/users/login", userHandler.LoginUserHandler).Methods("POST")
 
+	// Avatar images are public so that they can be used as image sources
+	router.HandleFunc("/avatars/{avatarId}/{size}", userHandler.GetAvatarHandler).Methods("GET")
+
+	// Public goal links need no account; they are rate-limited against token and password guessing
+	publicRoutes := router.PathPrefix("/public").Subrouter()
+	publicRoutes.Use(middleware.RateLimitMiddleware(cfg.PublicRateLimit, time.Minute))
+	publicRoutes.HandleFunc("/goals/{token}", publicLinkHandler.GetPublicGoalHandler).Methods("GET")
+
 	// Protected user routes (only authenticated users can access)
 	protectedUserRoutes := router.PathPrefix("/users").Subrouter()
 	protectedUserRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
 	protectedUserRoutes.HandleFunc("/{id}", userHandler.GetUserHandler).Methods("GET")
 	protectedUserRoutes.HandleFunc("/{id}", userHandler.UpdateUserHandler).Methods("PUT")
+	protectedUserRoutes.HandleFunc("/{id}", userHandler.PatchUserHandler).Methods("PATCH")
+	protectedUserRoutes.HandleFunc("/{id}/settings", settingsHandler.GetSettingsHandler).Methods("GET")
+	protectedUserRoutes.HandleFunc("/{id}/settings", settingsHandler.PatchSettingsHandler).Methods("PATCH")
+	protectedUserRoutes.HandleFunc("/{id}/profile", userHandler.GetPublicProfileHandler).Methods("GET")
+	protectedUserRoutes.HandleFunc("/{id}/avatar", userHandler.UploadAvatarHandler).Methods("PUT")
+	protectedUserRoutes.HandleFunc("/{id}/avatar", userHandler.DeleteAvatarHandler).Methods("DELETE")
+	protectedUserRoutes.HandleFunc("/{id}/achievements", achievementHandler.GetAchievementsHandler).Methods("GET")
+
+	// Protected template routes
+	templateRoutes := router.PathPrefix("/templates").Subrouter()
+	templateRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	templateRoutes.HandleFunc("", templateHandler.GetTemplatesHandler).Methods("GET")
+	templateRoutes.HandleFunc("", templateHandler.CreateTemplateHandler).Methods("POST")
+	templateRoutes.HandleFunc("/{id}", templateHandler.GetTemplateHandler).Methods("GET")
+	templateRoutes.HandleFunc("/{id}", templateHandler.UpdateTemplateHandler).Methods("PUT")
+	templateRoutes.HandleFunc("/{id}", templateHandler.DeleteTemplateHandler).Methods("DELETE")
+
+	// Protected category routes
+	categoryRoutes := router.PathPrefix("/categories").Subrouter()
+	categoryRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	categoryRoutes.HandleFunc("", categoryHandler.GetCategoriesHandler).Methods("GET")
+	categoryRoutes.HandleFunc("", categoryHandler.CreateCategoryHandler).Methods("POST")
+	categoryRoutes.HandleFunc("/{id}", categoryHandler.UpdateCategoryHandler).Methods("PUT")
+	categoryRoutes.HandleFunc("/{id}", categoryHandler.DeleteCategoryHandler).Methods("DELETE")
+
+	// Protected tag routes
+	tagRoutes := router.PathPrefix("/tags").Subrouter()
+	tagRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	tagRoutes.HandleFunc("", tagHandler.GetTagsHandler).Methods("GET")
+	tagRoutes.HandleFunc("/merge", tagHandler.MergeTagsHandler).Methods("POST")
+	tagRoutes.HandleFunc("/{name}", tagHandler.UpdateTagHandler).Methods("PUT")
+	tagRoutes.HandleFunc("/{name}", tagHandler.DeleteTagHandler).Methods("DELETE")
+	tagRoutes.HandleFunc("/{name}/rename", tagHandler.RenameTagHandler).Methods("POST")
+
+	// Protected attachment routes
+	attachmentRoutes := router.PathPrefix("/attachments").Subrouter()
+	attachmentRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	attachmentRoutes.HandleFunc("/usage", attachmentHandler.GetStorageUsageHandler).Methods("GET")
+
+	// Protected statistics routes
+	statsRoutes := router.PathPrefix("/stats").Subrouter()
+	statsRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	statsRoutes.HandleFunc("", statsHandler.GetStatsHandler).Methods("GET")
+	statsRoutes.HandleFunc("/activity", statsHandler.GetActivityHandler).Methods("GET")
+
+	// Protected webhook routes
+	webhookRoutes := router.PathPrefix("/webhooks").Subrouter()
+	webhookRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	webhookRoutes.HandleFunc("", webhookHandler.CreateWebhookHandler).Methods("POST")
+	webhookRoutes.HandleFunc("", webhookHandler.GetWebhooksHandler).Methods("GET")
+	webhookRoutes.HandleFunc("/{id}", webhookHandler.GetWebhookHandler).Methods("GET")
+	webhookRoutes.HandleFunc("/{id}", webhookHandler.UpdateWebhookHandler).Methods("PUT")
+	webhookRoutes.HandleFunc("/{id}", webhookHandler.DeleteWebhookHandler).Methods("DELETE")
+	webhookRoutes.HandleFunc("/{id}/deliveries", webhookHandler.GetDeliveriesHandler).Methods("GET")
+	webhookRoutes.HandleFunc("/{id}/test", webhookHandler.SendTestEventHandler).Methods("POST")
+
+	// Protected notification routes
+	notificationRoutes := router.PathPrefix("/notifications").Subrouter()
+	notificationRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	notificationRoutes.HandleFunc("", notificationHandler.GetNotificationsHandler).Methods("GET")
+	notificationRoutes.HandleFunc("/read", notificationHandler.MarkNotificationsReadHandler).Methods("POST")
+
+	// Protected invitation routes
+	invitationRoutes := router.PathPrefix("/invitations").Subrouter()
+	invitationRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	invitationRoutes.HandleFunc("", shareHandler.GetInvitationsHandler).Methods("GET")
+	invitationRoutes.HandleFunc("/{id}/accept", shareHandler.AcceptInvitationHandler).Methods("POST")
+	invitationRoutes.HandleFunc("/{id}/decline", shareHandler.DeclineInvitationHandler).Methods("POST")
+
+	// Protected organization routes
+	orgRoutes := router.PathPrefix("/orgs").Subrouter()
+	orgRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	orgRoutes.HandleFunc("", orgHandler.GetOrgsHandler).Methods("GET")
+	orgRoutes.HandleFunc("", orgHandler.CreateOrgHandler).Methods("POST")
+	orgRoutes.HandleFunc("/active", orgHandler.SetActiveOrgHandler).Methods("PUT")
+	orgRoutes.HandleFunc("/invitations", orgHandler.GetOrgInvitationsHandler).Methods("GET")
+	orgRoutes.HandleFunc("/invitations/{id}/accept", orgHandler.AcceptOrgInvitationHandler).Methods("POST")
+	orgRoutes.HandleFunc("/invitations/{id}/decline", orgHandler.DeclineOrgInvitationHandler).Methods("POST")
+	orgRoutes.HandleFunc("/{id}", orgHandler.GetOrgHandler).Methods("GET")
+	orgRoutes.HandleFunc("/{id}", orgHandler.RenameOrgHandler).Methods("PATCH")
+	orgRoutes.HandleFunc("/{id}", orgHandler.DeleteOrgHandler).Methods("DELETE")
+	orgRoutes.HandleFunc("/{id}/members", orgHandler.GetMembersHandler).Methods("GET")
+	orgRoutes.HandleFunc("/{id}/members", orgHandler.InviteMemberHandler).Methods("POST")
+	orgRoutes.HandleFunc("/{id}/members/{memberId}", orgHandler.UpdateMemberHandler).Methods("PATCH")
+	orgRoutes.HandleFunc("/{id}/members/{memberId}", orgHandler.RemoveMemberHandler).Methods("DELETE")
+	orgRoutes.HandleFunc("/{id}/goals", orgHandler.GetOrgGoalsHandler).Methods("GET")
+	orgRoutes.HandleFunc("/{id}/contributions", orgHandler.GetContributionsHandler).Methods("GET")
+
+	// Protected accountability partner routes
+	partnerRoutes := router.PathPrefix("/partners").Subrouter()
+	partnerRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	partnerRoutes.HandleFunc("", partnerHandler.GetPartnersHandler).Methods("GET")
+	partnerRoutes.HandleFunc("", partnerHandler.CreatePartnerRequestHandler).Methods("POST")
+	partnerRoutes.HandleFunc("/requests", partnerHandler.GetPartnerRequestsHandler).Methods("GET")
+	partnerRoutes.HandleFunc("/requests/{id}/accept", partnerHandler.AcceptPartnerRequestHandler).Methods("POST")
+	partnerRoutes.HandleFunc("/requests/{id}/decline", partnerHandler.DeclinePartnerRequestHandler).Methods("POST")
+	partnerRoutes.HandleFunc("/{userId}", partnerHandler.DeletePartnerHandler).Methods("DELETE")
+	partnerRoutes.HandleFunc("/{userId}/goals", partnerHandler.GetPartnerGoalsHandler).Methods("GET")
+	partnerRoutes.HandleFunc("/{userId}/summary", partnerHandler.GetPartnerSummaryHandler).Methods("GET")
+	partnerRoutes.HandleFunc("/{userId}/nudge", partnerHandler.NudgePartnerHandler).Methods("POST")
 
 	// Apply middleware for logging
 	router.Use(middleware.LoggingMiddleware)
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -15,6 +16,36 @@ type Config struct {
 	Port        string
 	JWTSecret   string
 	TokenExpiry time.Duration
+
+	// TrashRetention is how long soft-deleted goals are kept before being purged
+	TrashRetention time.Duration
+	// AutoArchiveDays is how many days completed goals stay in the main list for users who
+	// haven't chosen otherwise in their settings; zero disables auto-archiving
+	AutoArchiveDays int
+
+	// BlobStorage selects where files are stored: "gridfs" (the default) or "local"
+	BlobStorage string
+	// BlobDir is the directory used by local blob storage
+	BlobDir string
+	// MaxAttachmentSize is the largest accepted attachment, in bytes
+	MaxAttachmentSize int64
+	// StorageQuota is how many bytes of attachments each user can store
+	StorageQuota int64
+
+	// AchievementsFile is the JSON file the XP, levels and achievement rules are read from
+	AchievementsFile string
+
+	// PublicRateLimit is how many requests each client IP address can make to public links
+	// per minute. Clients are told apart by the address of the connection, so behind a
+	// reverse proxy all of them share the proxy's limit
+	PublicRateLimit int
+
+	// WebhookWorkers is how many webhook deliveries are sent concurrently
+	WebhookWorkers int
+
+	// AchievementWorkers is how many goal events are checked for XP and achievements
+	// concurrently
+	AchievementWorkers int
 }
 
 // LoadConfig reads from the .env file
@@ -38,5 +69,68 @@ func LoadConfig() *Config {
 		Port:        os.Getenv("PORT"),
 		JWTSecret:   os.Getenv("JWT_SECRET"),
 		TokenExpiry: expiry,
+
+		TrashRetention:  getDuration("TRASH_RETENTION", 30*24*time.Hour),
+		AutoArchiveDays: getInt("AUTO_ARCHIVE_DAYS", 0),
+
+		BlobStorage:       getString("BLOB_STORAGE", "gridfs"),
+		BlobDir:           getString("BLOB_DIR", "data/blobs"),
+		MaxAttachmentSize: int64(getInt("MAX_ATTACHMENT_MB", 10)) << 20,
+		StorageQuota:      int64(getInt("STORAGE_QUOTA_MB", 100)) << 20,
+
+		AchievementsFile: getString("ACHIEVEMENTS_FILE", "config/achievements.json"),
+
+		PublicRateLimit: getPositiveInt("PUBLIC_RATE_LIMIT", 60),
+
+		WebhookWorkers: getPositiveInt("WEBHOOK_WORKERS", 4),
+
+		AchievementWorkers: getPositiveInt("ACHIEVEMENT_WORKERS", 2),
+	}
+}
+
+// getString reads a string variable, falling back to def when it is missing.
+func getString(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getInt reads an integer variable, falling back to def when it is missing or invalid.
+func getInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid %s format, defaulting to %d: %v", key, def, err)
+		return def
+	}
+	return parsed
+}
+
+// getPositiveInt reads an integer variable, falling back to def when it is missing, invalid
+// or below 1.
+func getPositiveInt(key string, def int) int {
+	parsed := getInt(key, def)
+	if parsed < 1 {
+		log.Printf("%s must be at least 1, defaulting to %d", key, def)
+		return def
+	}
+	return parsed
+}
+
+// getDuration reads a duration variable, falling back to def when it is missing or invalid.
+func getDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s format, defaulting to %s: %v", key, def, err)
+		return def
 	}
+	return parsed
 }
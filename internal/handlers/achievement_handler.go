@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AchievementHandler handles HTTP requests related to achievements and XP.
+type AchievementHandler struct {
+	Service *services.AchievementService
+}
+
+// NewAchievementHandler creates a new instance of AchievementHandler.
+func NewAchievementHandler(service *services.AchievementService) *AchievementHandler {
+	return &AchievementHandler{Service: service}
+}
+
+// GetAchievementsHandler returns a user's XP, level and unlocked achievements. Like the
+// public profile, any logged-in user can see them.
+func (h *AchievementHandler) GetAchievementsHandler(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetUserFromContext(r.Context()) == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.Service.GetSummary(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve achievements", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/gorilla/mux"
+)
+
+// AttachmentHandler handles HTTP requests related to goal attachments.
+type AttachmentHandler struct {
+	Service     *services.AttachmentService
+	GoalService *services.GoalService
+}
+
+// NewAttachmentHandler creates a new instance of AttachmentHandler.
+func NewAttachmentHandler(service *services.AttachmentService, goalService *services.GoalService) *AttachmentHandler {
+	return &AttachmentHandler{
+		Service:     service,
+		GoalService: goalService,
+	}
+}
+
+// UploadAttachmentHandler attaches the file sent in the "file" field of a multipart form to a goal.
+func (h *AttachmentHandler) UploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionEdit)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself
+	r.Body = http.MaxBytesReader(w, r.Body, h.Service.Limits().MaxSize+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected a multipart/form-data upload", http.StatusBadRequest)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "No file provided", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			writeUploadError(w, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.Service.Upload(r.Context(), goal, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			writeUploadError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(attachment)
+		return
+	}
+}
+
+// GetAttachmentsHandler lists the attachments of a goal, newest first.
+func (h *AttachmentHandler) GetAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionView)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.Service.GetAttachments(r.Context(), goal.ID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve attachments", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(attachments)
+}
+
+// DownloadAttachmentHandler streams the content of an attachment, honouring Range requests.
+func (h *AttachmentHandler) DownloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, false)
+}
+
+// GetThumbnailHandler streams the thumbnail of an image attachment.
+func (h *AttachmentHandler) GetThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, true)
+}
+
+// DeleteAttachmentHandler removes an attachment and its content.
+func (h *AttachmentHandler) DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionEdit)
+	if !ok {
+		return
+	}
+	attachment, ok := h.goalAttachment(w, r, goal)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteAttachment(r.Context(), attachment); err != nil {
+		http.Error(w, "Failed to delete attachment", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetStorageUsageHandler reports how much of their storage quota the logged-in user has used.
+func (h *AttachmentHandler) GetStorageUsageHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := h.Service.GetStorageUsage(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve storage usage", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+func (h *AttachmentHandler) serve(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionView)
+	if !ok {
+		return
+	}
+	attachment, ok := h.goalAttachment(w, r, goal)
+	if !ok {
+		return
+	}
+
+	blob, err := h.Service.Open(r.Context(), attachment, thumbnail)
+	if err != nil {
+		http.Error(w, "Attachment content not found", http.StatusNotFound)
+		return
+	}
+	defer blob.Close()
+
+	// Thumbnails of JPEG photos are JPEG, other thumbnails are PNG
+	contentType := attachment.ContentType
+	if thumbnail && contentType != "image/jpeg" {
+		contentType = "image/png"
+	}
+	disposition := "attachment"
+	if strings.HasPrefix(contentType, "image/") {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	// ServeContent handles Range, If-Range and If-Modified-Since
+	http.ServeContent(w, r, attachment.FileName, attachment.CreatedAt, blob)
+}
+
+// goalAttachment loads the attachment from the route and ensures it belongs to the goal.
+func (h *AttachmentHandler) goalAttachment(w http.ResponseWriter, r *http.Request, goal *models.Goal) (*models.Attachment, bool) {
+	attachment, err := h.Service.GetAttachment(r.Context(), mux.Vars(r)["attachmentId"])
+	if err != nil || attachment == nil || attachment.GoalID != goal.ID {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return nil, false
+	}
+	return attachment, true
+}
+
+// writeUploadError maps upload failures to status codes.
+func writeUploadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, services.ErrAttachmentTooLarge), errors.As(err, &maxBytesErr):
+		http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, services.ErrUnsupportedType):
+		http.Error(w, "Unsupported file type", http.StatusUnsupportedMediaType)
+	case errors.Is(err, services.ErrQuotaExceeded):
+		http.Error(w, "Storage quota exceeded", http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, "Failed to upload attachment", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// forbiddenMessages explain why an action on a goal was refused.
+var forbiddenMessages = map[services.GoalAction]string{
+	services.ActionView:   "Forbidden: You don't have access to this goal",
+	services.ActionEdit:   "Forbidden: You can't edit this goal",
+	services.ActionManage: "Forbidden: Only the owners of this goal can do this",
+}
+
+// authorize ensures the logged-in user may perform action on goal, as decided by the
+// goal service's access policy. It writes the error response itself and reports whether
+// the caller may continue.
+func authorize(w http.ResponseWriter, r *http.Request, goalService *services.GoalService, goal *models.Goal, action services.GoalAction) bool {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return false
+	}
+
+	err = goalService.Authorize(r.Context(), goal, userID, action)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, forbiddenMessages[action], http.StatusForbidden)
+		return false
+	}
+	if err != nil {
+		http.Error(w, "Failed to check access", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// actorID returns the logged-in user, to whom the service attributes a change. Handlers
+// call it once authorize or currentUserID has checked the user.
+func actorID(r *http.Request) primitive.ObjectID {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		return primitive.NilObjectID
+	}
+	userID, _ := primitive.ObjectIDFromHex(claims.UserID)
+	return userID
+}
+
+// activeOrg returns the organization the logged-in user acts within, or nil when they act
+// on their own. It writes the error response itself and reports whether the caller may continue.
+func activeOrg(w http.ResponseWriter, r *http.Request, goalService *services.GoalService, userID primitive.ObjectID) (*primitive.ObjectID, bool) {
+	orgID, err := goalService.ActiveOrg(r.Context(), userID, middleware.GetActiveOrgFromContext(r.Context()))
+	if errors.Is(err, services.ErrNotOrgMember) {
+		http.Error(w, "Forbidden: You are not a member of the active organization", http.StatusForbidden)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to check organization membership", http.StatusInternalServerError)
+		return nil, false
+	}
+	return orgID, true
+}
+
+// authorizedGoal loads the goal from the route and ensures the logged-in user may perform
+// action on it. It writes the error response itself and reports whether the caller may continue.
+func authorizedGoal(w http.ResponseWriter, r *http.Request, goalService *services.GoalService, action services.GoalAction) (*models.Goal, bool) {
+	if middleware.GetUserFromContext(r.Context()) == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	goal, err := goalService.GetGoal(r.Context(), mux.Vars(r)["id"])
+	if err != nil || goal == nil {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if !authorize(w, r, goalService, goal, action) {
+		return nil, false
+	}
+	return goal, true
+}
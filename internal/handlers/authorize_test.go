@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/services"
+	jwtutil "github.com/czeful/diplom_back/pkg/jwt"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestAuthorizeRemovedOrgMember tests that the creator of a team goal is refused with
+// 403 once they are removed from the organization.
+func TestAuthorizeRemovedOrgMember(t *testing.T) {
+	creator, orgID := primitive.NewObjectID(), primitive.NewObjectID()
+	goal := &models.Goal{ID: primitive.NewObjectID(), UserID: creator, OrgID: &orgID}
+
+	member := true
+	goalService := &services.GoalService{}
+	goalService.SetOrgLookup(func(context.Context, primitive.ObjectID, primitive.ObjectID) (string, error) {
+		if member {
+			return models.OrgRoleAdmin, nil
+		}
+		return "", nil
+	})
+
+	check := func() int {
+		req := httptest.NewRequest("DELETE", "/goals/"+goal.ID.Hex(), nil)
+		claims := &jwtutil.Claims{UserID: creator.Hex()}
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, claims))
+		rec := httptest.NewRecorder()
+		if authorize(rec, req, goalService, goal, services.ActionManage) {
+			return http.StatusOK
+		}
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, check())
+	member = false
+	assert.Equal(t, http.StatusForbidden, check())
+}
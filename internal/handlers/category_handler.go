@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CategoryHandler handles HTTP requests related to goal categories.
+type CategoryHandler struct {
+	Service *services.CategoryService
+}
+
+// NewCategoryHandler creates a new instance of CategoryHandler.
+func NewCategoryHandler(service *services.CategoryService) *CategoryHandler {
+	return &CategoryHandler{Service: service}
+}
+
+// GetCategoriesHandler lists the default categories and the logged-in user's own.
+func (h *CategoryHandler) GetCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	categories, err := h.Service.GetCategories(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve categories", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+// CreateCategoryHandler creates a category for the logged-in user.
+func (h *CategoryHandler) CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var category models.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := services.ValidateCategory(&category); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	category.ID = primitive.NilObjectID
+	category.UserID = userID
+
+	createdCategory, err := h.Service.CreateCategory(r.Context(), &category)
+	if err != nil {
+		if errors.Is(err, services.ErrCategoryExists) {
+			http.Error(w, "Category already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create category", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdCategory)
+}
+
+// UpdateCategoryHandler renames or recolors one of the logged-in user's categories.
+// Goals in the category follow the new name.
+func (h *CategoryHandler) UpdateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	existingCategory, ok := h.ownedCategory(w, r)
+	if !ok {
+		return
+	}
+
+	var updatedCategory models.Category
+	if err := json.NewDecoder(r.Body).Decode(&updatedCategory); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := services.ValidateCategory(&updatedCategory); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updatedCategoryData, err := h.Service.UpdateCategory(r.Context(), existingCategory, &updatedCategory)
+	if err != nil {
+		if errors.Is(err, services.ErrCategoryExists) {
+			http.Error(w, "Category already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to update category", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedCategoryData)
+}
+
+// DeleteCategoryHandler removes one of the logged-in user's categories if no goal uses it.
+func (h *CategoryHandler) DeleteCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	category, ok := h.ownedCategory(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteCategory(r.Context(), category); err != nil {
+		if errors.Is(err, services.ErrCategoryInUse) {
+			http.Error(w, "Category is in use: move its goals to another category first", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to delete category", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ownedCategory loads the category from the route and ensures it belongs to the logged-in user.
+// Default categories have no ID and can't be changed. It writes the error response itself
+// and reports whether the caller may continue.
+func (h *CategoryHandler) ownedCategory(w http.ResponseWriter, r *http.Request) (*models.Category, bool) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	category, err := h.Service.GetCategory(r.Context(), mux.Vars(r)["id"])
+	if err != nil || category == nil {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if category.UserID.Hex() != claims.UserID {
+		http.Error(w, "Forbidden: You can only manage your own categories", http.StatusForbidden)
+		return nil, false
+	}
+
+	return category, true
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+)
+
+// goalETag returns the entity tag of the current version of a goal.
+func goalETag(goal *models.Goal) string {
+	return fmt.Sprintf("\"%d\"", goal.Version)
+}
+
+// goalViewETag returns the entity tag of a goal as GetGoalHandler renders it. Besides the
+// version, the body depends on whether the goal is shown as expired, which changes with
+// the clock, and on the zone its timestamps are rendered in.
+func goalViewETag(goal *models.Goal, expired bool, zone *time.Location) string {
+	tag := strconv.FormatInt(goal.Version, 10)
+	if expired {
+		tag += "-expired"
+	}
+	if zone != nil {
+		tag += "-" + zone.String()
+	}
+	return "\"" + tag + "\""
+}
+
+// setGoalETag adds the ETag header for the goal to the response.
+func setGoalETag(w http.ResponseWriter, goal *models.Goal) {
+	w.Header().Set("ETag", goalETag(goal))
+}
+
+// expectedVersion returns the goal version the client's If-Match header refers to,
+// or repository.AnyVersion when the header is missing or "*". ok is false when the
+// header doesn't match the current goal, in which case a 412 has been written.
+func expectedVersion(w http.ResponseWriter, r *http.Request, goal *models.Goal) (int64, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return repository.AnyVersion, true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		// Tags of rendered views carry the version before the first "-"
+		tag = strings.Trim(strings.TrimPrefix(strings.TrimSpace(tag), "W/"), "\"")
+		tag, _, _ = strings.Cut(tag, "-")
+		version, err := strconv.ParseInt(tag, 10, 64)
+		if err == nil && version == goal.Version {
+			return version, true
+		}
+	}
+
+	w.Header().Set("ETag", goalETag(goal))
+	writePreconditionFailed(w)
+	return 0, false
+}
+
+// notModified reports whether the client's If-None-Match header matches etag.
+func notModified(r *http.Request, etag string) bool {
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeConflict reports a failed write, answering 412 when it lost a version race.
+// It returns false when err is not a version conflict so that the caller can handle it.
+func writeConflict(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, repository.ErrVersionConflict) {
+		return false
+	}
+	writePreconditionFailed(w)
+	return true
+}
+
+func writePreconditionFailed(w http.ResponseWriter) {
+	http.Error(w, "Precondition Failed: the goal was modified by another request", http.StatusPreconditionFailed)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGoalViewETag tests that the tag changes with the computed status and the zone,
+// and that If-Match still accepts it as the goal version.
+func TestGoalViewETag(t *testing.T) {
+	goal := &models.Goal{Version: 7}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	plain := goalViewETag(goal, false, nil)
+	expired := goalViewETag(goal, true, nil)
+	zoned := goalViewETag(goal, true, berlin)
+	assert.Equal(t, goalETag(goal), plain)
+	assert.Equal(t, `"7-expired"`, expired)
+	assert.Equal(t, `"7-expired-Europe/Berlin"`, zoned)
+
+	req := httptest.NewRequest("GET", "/goals/id", nil)
+	req.Header.Set("If-None-Match", plain)
+	assert.True(t, notModified(req, plain))
+	assert.False(t, notModified(req, expired))
+
+	req = httptest.NewRequest("PUT", "/goals/id", nil)
+	req.Header.Set("If-Match", zoned)
+	version, ok := expectedVersion(httptest.NewRecorder(), req, goal)
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), version)
+
+	req.Header.Set("If-Match", `"6-expired"`)
+	rec := httptest.NewRecorder()
+	_, ok = expectedVersion(rec, req, goal)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+}
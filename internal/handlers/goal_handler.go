@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
 	"github.com/czeful/diplom_back/internal/services"
 	"github.com/czeful/diplom_back/pkg/middleware"
 	"github.com/gorilla/mux"
@@ -50,20 +52,40 @@ func (h *GoalHandler) CreateGoalHandler(w http.ResponseWriter, r *http.Request)
 	goal.CreatedAt = time.Now()
 	goal.UpdatedAt = time.Now()
 
-	//  Validate & Parse Due Date (Optional)
-	if !goal.DueDate.IsZero() && goal.DueDate.Before(time.Now()) {
-		http.Error(w, "Due date cannot be in the past", http.StatusBadRequest)
+	// Goals created while acting within an organization belong to it
+	orgID, ok := activeOrg(w, r, h.Service, userID)
+	if !ok {
 		return
 	}
+	goal.OrgID = orgID
 
-	//  Validate & Set Category (Optional)
-	if goal.Category != "" {
-		if _, exists := models.AllowedCategories[goal.Category]; !exists {
-			http.Error(w, "Invalid category", http.StatusBadRequest)
+	// Sub-goals go below a goal of the same owner or organization
+	if goal.ParentID != nil {
+		parent, err := h.Service.GetGoal(r.Context(), goal.ParentID.Hex())
+		if err != nil || parent == nil {
+			http.Error(w, "Parent goal not found", http.StatusBadRequest)
+			return
+		}
+		if err := services.ValidateParent(&goal, parent); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 	}
 
+	//  Validate & Parse Due Date (Optional); a plain date is due until the end of that day for the user
+	if goal.IsOverdue(time.Now(), h.Service.Location(r.Context(), userID)) {
+		http.Error(w, "Due date cannot be in the past", http.StatusBadRequest)
+		return
+	}
+
+	//  Validate & Set Category, Tags and Priority (Optional); the category defaults to the user's setting
+	if goal.Category == "" {
+		goal.Category = h.Service.DefaultCategory(r.Context(), userID)
+	}
+	if !h.validateLabels(w, r, &goal, http.StatusBadRequest) {
+		return
+	}
+
 	// Initialize progress field
 	goal.Progress = make(map[string]bool)
 	for _, step := range goal.Steps {
@@ -77,6 +99,7 @@ func (h *GoalHandler) CreateGoalHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	setGoalETag(w, createdGoal)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(createdGoal)
 }
@@ -100,17 +123,35 @@ func (h *GoalHandler) GetGoalHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	//  Ensure the logged-in user is the owner of the goal
-	if goal.UserID.Hex() != claims.UserID {
-		http.Error(w, "Forbidden: You can only view your own goals", http.StatusForbidden)
+	// Ensure the logged-in user may view the goal
+	if !authorize(w, r, h.Service, goal, services.ActionView) {
 		return
 	}
 
-	//  Check if the goal is overdue
-	if !goal.DueDate.IsZero() && goal.DueDate.Before(time.Now()) {
+	//  Check if the goal is overdue in the owner's timezone
+	loc := h.Service.Location(r.Context(), goal.UserID)
+	expired := goal.IsOverdue(time.Now(), loc)
+	if expired {
 		goal.Status = "expired"
 	}
 
+	// tz=user renders the goal in the requester's timezone, not the owner's
+	zone, ok := responseZone(w, r, h.Service, actorID(r))
+	if !ok {
+		return
+	}
+
+	// The client's cached copy is still current
+	etag := goalViewETag(goal, expired, zone)
+	w.Header().Set("ETag", etag)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if zone != nil {
+		*goal = goal.In(zone)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(goal)
 }
@@ -141,9 +182,13 @@ func (h *GoalHandler) UpdateGoalHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Ensure the logged-in user is the owner of the goal
-	if existingGoal.UserID.Hex() != claims.UserID {
-		http.Error(w, "Forbidden: You can only update your own goals", http.StatusForbidden)
+	// Ensure the logged-in user may edit the goal
+	if !authorize(w, r, h.Service, existingGoal, services.ActionEdit) {
+		return
+	}
+
+	// Honour If-Match so that edits based on a stale copy are rejected
+	if _, ok := expectedVersion(w, r, existingGoal); !ok {
 		return
 	}
 
@@ -156,57 +201,112 @@ func (h *GoalHandler) UpdateGoalHandler(w http.ResponseWriter, r *http.Request)
 	defer r.Body.Close()
 
 	//  Validate & Parse Due Date (Optional)
-	if !updatedGoal.DueDate.IsZero() && updatedGoal.DueDate.Before(time.Now()) {
+	if updatedGoal.IsOverdue(time.Now(), h.Service.Location(r.Context(), existingGoal.UserID)) {
 		http.Error(w, "Due date cannot be in the past", http.StatusBadRequest)
 		return
 	}
 
-	// Sync Progress Field (Only Keep Steps That Exist in Updated Goal)
-	newProgress := make(map[string]bool)
+	//  Assign updated values
+	updatedGoal.ID = objID
+	updatedGoal.UserID = existingGoal.UserID
 
-	//  Validate & Set Category (Optional)
-	if updatedGoal.Category != "" {
-		if _, exists := models.AllowedCategories[updatedGoal.Category]; !exists {
-			http.Error(w, "Invalid category", http.StatusBadRequest)
+	//  Validate & Set Category, Tags and Priority (Optional)
+	if !h.validateLabels(w, r, &updatedGoal, http.StatusBadRequest) {
+		return
+	}
+	updatedGoal.Progress = syncProgress(updatedGoal.Steps, existingGoal.Progress) // Ensure old steps are removed
+	updatedGoal.UpdatedAt = time.Now()
+	updatedGoal.Version = existingGoal.Version
+
+	// Save the updated goal; it fails if the goal changed since it was read above
+	updatedGoalData, err := h.Service.UpdateGoal(r.Context(), goalID, &updatedGoal, actorID(r))
+	if err != nil {
+		if writeConflict(w, err) {
 			return
 		}
+		http.Error(w, "Failed to update goal", http.StatusInternalServerError)
+		return
 	}
 
-	// Create a set of valid steps (to remove old progress)
-	validSteps := make(map[string]bool)
-	for _, step := range updatedGoal.Steps {
-		validSteps[step] = true
+	setGoalETag(w, updatedGoalData)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedGoalData)
+}
+
+// PatchGoalHandler applies a JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902) to a goal.
+// The patched goal is validated as a whole, but only the fields that changed are written.
+func (h *GoalHandler) PatchGoalHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	goalID := vars["id"]
+
+	// Get the logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
 	}
 
-	// Keep only the progress of valid steps
-	for step, done := range existingGoal.Progress {
-		if validSteps[step] {
-			newProgress[step] = done // Keep existing progress
-		}
+	// Fetch the existing goal
+	existingGoal, err := h.Service.GetGoal(r.Context(), goalID)
+	if err != nil || existingGoal == nil {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
 	}
 
-	// Add new steps with default `false`
-	for _, step := range updatedGoal.Steps {
-		if _, exists := newProgress[step]; !exists {
-			newProgress[step] = false
-		}
+	// Ensure the logged-in user may edit the goal
+	if !authorize(w, r, h.Service, existingGoal, services.ActionEdit) {
+		return
 	}
 
-	//  Assign updated values
-	updatedGoal.ID = objID
-	updatedGoal.UserID = existingGoal.UserID
-	updatedGoal.Progress = newProgress // Ensure old steps are removed
-	updatedGoal.UpdatedAt = time.Now()
+	if _, ok := expectedVersion(w, r, existingGoal); !ok {
+		return
+	}
+
+	// Apply the patch to the current JSON representation of the goal
+	patchedJSON, err := patchDocument(r, existingGoal)
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	var patchedGoal models.Goal
+	if err := json.Unmarshal(patchedJSON, &patchedGoal); err != nil {
+		http.Error(w, "Invalid patch: the result is not a valid goal", http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Validate the patched result
+	if patchedGoal.Name == "" {
+		http.Error(w, "Goal name is required", http.StatusUnprocessableEntity)
+		return
+	}
+	dueDateChanged := !patchedGoal.DueDate.Equal(existingGoal.DueDate) || patchedGoal.DueAllDay != existingGoal.DueAllDay
+	if dueDateChanged && patchedGoal.IsOverdue(time.Now(), h.Service.Location(r.Context(), existingGoal.UserID)) {
+		http.Error(w, "Due date cannot be in the past", http.StatusUnprocessableEntity)
+		return
+	}
 
-	// Save the updated goal
-	updatedGoalData, err := h.Service.UpdateGoal(r.Context(), goalID, &updatedGoal)
+	// Identity fields can't be patched, and progress follows the steps
+	patchedGoal.ID = existingGoal.ID
+	patchedGoal.UserID = existingGoal.UserID
+	if !h.validateLabels(w, r, &patchedGoal, http.StatusUnprocessableEntity) {
+		return
+	}
+	patchedGoal.Progress = syncProgress(patchedGoal.Steps, patchedGoal.Progress)
+
+	updatedGoal, err := h.Service.PatchGoal(r.Context(), goalID, &patchedGoal, existingGoal.Version, actorID(r))
 	if err != nil {
+		if writeConflict(w, err) {
+			return
+		}
 		http.Error(w, "Failed to update goal", http.StatusInternalServerError)
 		return
 	}
 
+	setGoalETag(w, updatedGoal)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedGoalData)
+	json.NewEncoder(w).Encode(updatedGoal)
 }
 
 func (h *GoalHandler) UpdateGoalProgressHandler(w http.ResponseWriter, r *http.Request) {
@@ -227,9 +327,14 @@ func (h *GoalHandler) UpdateGoalProgressHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Ensure the logged-in user owns the goal
-	if goal.UserID.Hex() != claims.UserID {
-		http.Error(w, "Forbidden: You can only update your own goals", http.StatusForbidden)
+	// Ensure the logged-in user may edit the goal
+	if !authorize(w, r, h.Service, goal, services.ActionEdit) {
+		return
+	}
+
+	// Without If-Match the toggle applies to whatever version is current
+	version, ok := expectedVersion(w, r, goal)
+	if !ok {
 		return
 	}
 
@@ -244,40 +349,74 @@ func (h *GoalHandler) UpdateGoalProgressHandler(w http.ResponseWriter, r *http.R
 	}
 	defer r.Body.Close()
 
-	// Ensure the step exists in the goal
-	if _, exists := goal.Progress[progressUpdate.Step]; !exists {
-		http.Error(w, "Step not found in goal", http.StatusBadRequest)
+	// Toggle the step atomically; the service also recomputes the goal status
+	updatedGoal, err := h.Service.UpdateGoalProgress(r.Context(), goalID, progressUpdate.Step, progressUpdate.Done, version, actorID(r))
+	if err != nil {
+		if errors.Is(err, services.ErrStepNotFound) {
+			http.Error(w, "Step not found in goal", http.StatusBadRequest)
+			return
+		}
+		if writeConflict(w, err) {
+			return
+		}
+		http.Error(w, "Failed to update progress", http.StatusInternalServerError)
 		return
 	}
 
-	// Update step progress
-	goal.Progress[progressUpdate.Step] = progressUpdate.Done
+	setGoalETag(w, updatedGoal)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedGoal)
+}
 
-	// Check if all steps are completed
-	allCompleted := true
-	for _, done := range goal.Progress {
-		if !done {
-			allCompleted = false
-			break
-		}
+// AssignStepHandler assigns a step of a goal to a user who may edit the goal, or
+// unassigns it when user_id is empty.
+func (h *GoalHandler) AssignStepHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.Service, services.ActionEdit)
+	if !ok {
+		return
+	}
+	if _, ok := expectedVersion(w, r, goal); !ok {
+		return
 	}
 
-	// Update goal status
-	if allCompleted {
-		goal.Status = "completed"
-	} else {
-		goal.Status = "in_progress"
+	var assignment struct {
+		Step   string `json:"step"`
+		UserID string `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&assignment); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
 	}
+	defer r.Body.Close()
 
-	goal.UpdatedAt = time.Now()
+	var assignee *primitive.ObjectID
+	if assignment.UserID != "" {
+		userID, err := primitive.ObjectIDFromHex(assignment.UserID)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+		assignee = &userID
+	}
 
-	// Save changes
-	updatedGoal, err := h.Service.UpdateGoal(r.Context(), goalID, goal)
+	updatedGoal, err := h.Service.AssignStep(r.Context(), goal, assignment.Step, assignee, actorID(r))
 	if err != nil {
-		http.Error(w, "Failed to update progress", http.StatusInternalServerError)
+		if errors.Is(err, services.ErrStepNotFound) {
+			http.Error(w, "Step not found in goal", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, services.ErrInvalidAssignee) {
+			http.Error(w, "Steps can only be assigned to users who can edit the goal", http.StatusUnprocessableEntity)
+			return
+		}
+		if writeConflict(w, err) {
+			return
+		}
+		http.Error(w, "Failed to assign step", http.StatusInternalServerError)
 		return
 	}
 
+	setGoalETag(w, updatedGoal)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(updatedGoal)
 }
@@ -301,14 +440,18 @@ func (h *GoalHandler) DeleteGoalHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Check if the logged-in user is the owner
-	if goal.UserID.Hex() != claims.UserID {
-		http.Error(w, "Forbidden: You can only delete your own goals", http.StatusForbidden)
+	// Ensure the logged-in user may manage the goal
+	if !authorize(w, r, h.Service, goal, services.ActionManage) {
+		return
+	}
+
+	// Don't delete a goal the client hasn't seen the latest version of
+	if _, ok := expectedVersion(w, r, goal); !ok {
 		return
 	}
 
 	// Perform delete
-	err = h.Service.DeleteGoal(r.Context(), goalID)
+	err = h.Service.DeleteGoal(r.Context(), goalID, actorID(r))
 	if err != nil {
 		http.Error(w, "Failed to delete goal", http.StatusInternalServerError)
 		return
@@ -357,9 +500,8 @@ func (h *GoalHandler) GetGoalProgressHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Ensure the logged-in user is the owner of the goal
-	if goal.UserID.Hex() != claims.UserID {
-		http.Error(w, "Forbidden: You can only view your own goal progress", http.StatusForbidden)
+	// Ensure the logged-in user may view the goal
+	if !authorize(w, r, h.Service, goal, services.ActionView) {
 		return
 	}
 
@@ -372,6 +514,35 @@ func (h *GoalHandler) GetGoalProgressHandler(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(response)
 }
 
+// GetGoalForecastHandler projects when a goal will be completed and returns burndown
+// and burnup series for charting.
+func (h *GoalHandler) GetGoalForecastHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	goalID := vars["id"]
+
+	// Get the logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Fetch the goal from DB
+	goal, err := h.Service.GetGoal(r.Context(), goalID)
+	if err != nil || goal == nil {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+
+	// Ensure the logged-in user may view the goal
+	if !authorize(w, r, h.Service, goal, services.ActionView) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Service.GetForecast(r.Context(), goal))
+}
+
 func (h *GoalHandler) GetGoalsHandler(w http.ResponseWriter, r *http.Request) {
 	// Get logged-in user
 	claims := middleware.GetUserFromContext(r.Context())
@@ -387,16 +558,469 @@ func (h *GoalHandler) GetGoalsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get category filter from query params (optional)
-	category := r.URL.Query().Get("category")
+	// List the goals of the active organization, or the user's personal goals
+	orgID, ok := activeOrg(w, r, h.Service, userID)
+	if !ok {
+		return
+	}
+
+	// Get category and archive filters from query params (optional)
+	filter := repository.GoalFilter{
+		UserID:   userID,
+		OrgID:    orgID,
+		Category: r.URL.Query().Get("category"),
+		Archived: repository.ArchivedExclude,
+	}
+	if archived := r.URL.Query().Get("archived"); archived != "" {
+		if archived != repository.ArchivedExclude && archived != repository.ArchivedOnly && archived != repository.ArchivedAll {
+			http.Error(w, "Invalid archived filter: use true, false or all", http.StatusBadRequest)
+			return
+		}
+		filter.Archived = archived
+	}
+	// Every tag given with ?tag= must be present on a goal
+	filter.Tags = r.URL.Query()["tag"]
+
+	// Keep only goals on track, at risk and so on with ?forecast=
+	if forecast := r.URL.Query().Get("forecast"); forecast != "" {
+		if !models.ValidForecastStatuses[forecast] {
+			http.Error(w, "Invalid forecast filter: use on_track, at_risk, off_track, completed or no_deadline", http.StatusBadRequest)
+			return
+		}
+		filter.Forecast = forecast
+	}
+
+	// Sort by priority with ?sort=priority, otherwise as the user's settings say
+	if sortBy := r.URL.Query().Get("sort"); sortBy != "" {
+		if sortBy != repository.SortPriority {
+			http.Error(w, "Invalid sort: use priority", http.StatusBadRequest)
+			return
+		}
+		filter.Sort = sortBy
+	} else {
+		filter.Sort = h.Service.Settings(r.Context(), userID).DefaultSort
+	}
+
+	// Render timestamps in a given zone with ?tz=
+	zone, ok := responseZone(w, r, h.Service, userID)
+	if !ok {
+		return
+	}
 
-	// Fetch goals from DB with optional category filter
-	goals, err := h.Service.GetGoals(r.Context(), userID, category)
+	// Fetch goals from DB with optional filters
+	goals, err := h.Service.GetGoals(r.Context(), filter)
 	if err != nil {
 		http.Error(w, "Failed to retrieve goals", http.StatusInternalServerError)
 		return
 	}
+	goalsIn(goals, zone)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(goals)
+}
+
+// GetTrashHandler lists the logged-in user's deleted goals that can still be restored.
+func (h *GoalHandler) GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	// Get logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Convert UserID to ObjectID
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	goals, err := h.Service.GetTrash(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve trash", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(goals)
 }
+
+// RestoreGoalHandler takes a goal out of the trash.
+func (h *GoalHandler) RestoreGoalHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	goalID := vars["id"]
+
+	// Get the logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Fetch the goal from the trash
+	goal, err := h.Service.GetDeletedGoal(r.Context(), goalID)
+	if err != nil || goal == nil {
+		http.Error(w, "Goal not found in trash", http.StatusNotFound)
+		return
+	}
+
+	// Ensure the logged-in user may manage the goal
+	if !authorize(w, r, h.Service, goal, services.ActionManage) {
+		return
+	}
+
+	restoredGoal, err := h.Service.RestoreGoal(r.Context(), goalID, actorID(r))
+	if err != nil {
+		http.Error(w, "Failed to restore goal", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(restoredGoal)
+}
+
+// PurgeGoalHandler permanently removes a goal from the trash.
+func (h *GoalHandler) PurgeGoalHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	goalID := vars["id"]
+
+	// Get the logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Only goals that are already in the trash can be purged
+	goal, err := h.Service.GetDeletedGoal(r.Context(), goalID)
+	if err != nil || goal == nil {
+		http.Error(w, "Goal not found in trash", http.StatusNotFound)
+		return
+	}
+
+	// Ensure the logged-in user may manage the goal
+	if !authorize(w, r, h.Service, goal, services.ActionManage) {
+		return
+	}
+
+	if err := h.Service.PurgeGoal(r.Context(), goalID, actorID(r)); err != nil {
+		http.Error(w, "Failed to purge goal", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ArchiveGoalHandler moves a goal into the archive.
+func (h *GoalHandler) ArchiveGoalHandler(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+// UnarchiveGoalHandler moves an archived goal back to the main list.
+func (h *GoalHandler) UnarchiveGoalHandler(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+// BulkArchiveGoalsHandler archives every goal listed in the request body.
+func (h *GoalHandler) BulkArchiveGoalsHandler(w http.ResponseWriter, r *http.Request) {
+	h.setArchivedBulk(w, r, true)
+}
+
+// BulkUnarchiveGoalsHandler unarchives every goal listed in the request body.
+func (h *GoalHandler) BulkUnarchiveGoalsHandler(w http.ResponseWriter, r *http.Request) {
+	h.setArchivedBulk(w, r, false)
+}
+
+func (h *GoalHandler) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	vars := mux.Vars(r)
+	goalID := vars["id"]
+
+	// Get the logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Fetch the goal from DB
+	goal, err := h.Service.GetGoal(r.Context(), goalID)
+	if err != nil || goal == nil {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+
+	// Ensure the logged-in user may manage the goal
+	if !authorize(w, r, h.Service, goal, services.ActionManage) {
+		return
+	}
+
+	if _, ok := expectedVersion(w, r, goal); !ok {
+		return
+	}
+
+	var updatedGoal *models.Goal
+	if archived {
+		updatedGoal, err = h.Service.ArchiveGoal(r.Context(), goalID, actorID(r))
+	} else {
+		updatedGoal, err = h.Service.UnarchiveGoal(r.Context(), goalID, actorID(r))
+	}
+	if err != nil {
+		http.Error(w, "Failed to update goal", http.StatusInternalServerError)
+		return
+	}
+
+	setGoalETag(w, updatedGoal)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedGoal)
+}
+
+func (h *GoalHandler) setArchivedBulk(w http.ResponseWriter, r *http.Request, archived bool) {
+	// Get logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Convert UserID to ObjectID
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	var request struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.IDs) == 0 {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	// Goals that don't exist or belong to someone else are silently skipped
+	changed, err := h.Service.SetArchivedBulk(r.Context(), userID, request.IDs, archived)
+	if err != nil {
+		http.Error(w, "Failed to update goals", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"updated": changed,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// syncProgress returns the progress for steps, keeping the state of steps that
+// already existed and starting new ones as not done.
+func syncProgress(steps []string, existing map[string]bool) map[string]bool {
+	progress := make(map[string]bool)
+	for _, step := range steps {
+		progress[step] = existing[step]
+	}
+	return progress
+}
+
+// BulkGoalsHandler applies a list of operations to the logged-in user's goals and
+// returns the outcome of every item. Atomic requests answer 409 when rolled back.
+func (h *GoalHandler) BulkGoalsHandler(w http.ResponseWriter, r *http.Request) {
+	// Get logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Convert UserID to ObjectID
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	var request services.BulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Operations) == 0 {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	items := 0
+	for _, operation := range request.Operations {
+		items += len(operation.IDs)
+	}
+	if items > services.MaxBulkItems {
+		http.Error(w, "Too many items in bulk request", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	result, err := h.Service.BulkUpdate(r.Context(), userID, request)
+	switch {
+	case errors.Is(err, services.ErrBulkRolledBack):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(result)
+		return
+	case errors.Is(err, repository.ErrTransactionsUnsupported):
+		http.Error(w, "All-or-nothing mode requires a database with transaction support", http.StatusNotImplemented)
+		return
+	case err != nil:
+		http.Error(w, "Failed to apply bulk operations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// CloneGoalHandler creates a copy of one of the logged-in user's goals. Progress is reset
+// and tags are copied unless the request says otherwise; notes, attachments and sub-goals
+// are only copied on request.
+func (h *GoalHandler) CloneGoalHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	goalID := vars["id"]
+
+	// Get the logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Fetch the goal from DB
+	goal, err := h.Service.GetGoal(r.Context(), goalID)
+	if err != nil || goal == nil {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+
+	// Ensure the logged-in user may manage the goal
+	if !authorize(w, r, h.Service, goal, services.ActionManage) {
+		return
+	}
+
+	var request struct {
+		Name            string `json:"name"`
+		ResetProgress   *bool  `json:"reset_progress"`
+		ShiftDays       int    `json:"shift_days"`
+		CopyTags        *bool  `json:"copy_tags"`
+		CopyNotes       bool   `json:"copy_notes"`
+		CopyAttachments bool   `json:"copy_attachments"`
+		IncludeSubGoals bool   `json:"include_sub_goals"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+	}
+
+	opts := services.CloneOptions{
+		Name:            request.Name,
+		ResetProgress:   request.ResetProgress == nil || *request.ResetProgress,
+		ShiftDays:       request.ShiftDays,
+		CopyTags:        request.CopyTags == nil || *request.CopyTags,
+		CopyNotes:       request.CopyNotes,
+		CopyAttachments: request.CopyAttachments,
+		IncludeSubGoals: request.IncludeSubGoals,
+	}
+
+	// Same rule as CreateGoalHandler: the copy can't already be overdue
+	preview := services.BuildClone(goal, opts)
+	if preview.IsOverdue(time.Now(), h.Service.Location(r.Context(), goal.UserID)) {
+		http.Error(w, "Due date cannot be in the past: use shift_days to move it", http.StatusBadRequest)
+		return
+	}
+
+	clonedGoal, err := h.Service.CloneGoal(r.Context(), goal, opts)
+	if err != nil {
+		http.Error(w, "Failed to clone goal", http.StatusInternalServerError)
+		return
+	}
+
+	setGoalETag(w, clonedGoal)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(clonedGoal)
+}
+
+// validateLabels normalizes the tags of a goal, checks its priority and checks its category
+// against the categories of the goal's owner. On failure it writes an error with the given status.
+func (h *GoalHandler) validateLabels(w http.ResponseWriter, r *http.Request, goal *models.Goal, status int) bool {
+	if err := services.ValidatePriority(goal.Priority); err != nil {
+		http.Error(w, err.Error(), status)
+		return false
+	}
+
+	tags, err := services.NormalizeTags(goal.Tags)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return false
+	}
+	goal.Tags = tags
+
+	if goal.Category == "" {
+		return true
+	}
+	valid, err := h.Service.ValidCategory(r.Context(), goal.UserID, goal.Category)
+	if err != nil {
+		http.Error(w, "Failed to check category", http.StatusInternalServerError)
+		return false
+	}
+	if !valid {
+		http.Error(w, "Invalid category", status)
+		return false
+	}
+	return true
+}
+
+// GetGoalMatrixHandler buckets the logged-in user's active goals into the Eisenhower quadrants.
+// Unless auto_urgency=false, goals due within urgent_within_days days (3 by default) count as urgent.
+func (h *GoalHandler) GetGoalMatrixHandler(w http.ResponseWriter, r *http.Request) {
+	// Get logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Convert UserID to ObjectID
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	opts := services.MatrixOptions{
+		AutoUrgency:  true,
+		UrgentWithin: services.DefaultUrgentWithin,
+		Now:          time.Now(),
+		Location:     h.Service.Location(r.Context(), userID),
+	}
+	if autoUrgency := r.URL.Query().Get("auto_urgency"); autoUrgency != "" {
+		opts.AutoUrgency, err = strconv.ParseBool(autoUrgency)
+		if err != nil {
+			http.Error(w, "Invalid auto_urgency: use true or false", http.StatusBadRequest)
+			return
+		}
+	}
+	if days := r.URL.Query().Get("urgent_within_days"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid urgent_within_days", http.StatusBadRequest)
+			return
+		}
+		opts.UrgentWithin = time.Duration(n) * 24 * time.Hour
+	}
+
+	matrix, err := h.Service.GetMatrix(r.Context(), userID, opts)
+	if err != nil {
+		http.Error(w, "Failed to build matrix", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(matrix)
+}
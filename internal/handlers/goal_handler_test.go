@@ -12,8 +12,11 @@ import (
 	"github.com/czeful/diplom_back/internal/models"
 	"github.com/czeful/diplom_back/internal/repository"
 	"github.com/czeful/diplom_back/internal/services"
+	jwtutil "github.com/czeful/diplom_back/pkg/jwt"
+	"github.com/czeful/diplom_back/pkg/middleware"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -121,3 +124,57 @@ func TestGetGoalHandler(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, createdGoal.Name, fetchedGoal.Name)
 }
+
+func TestUpdateGoalHandlerClearsFields(t *testing.T) {
+	_, db, ctx, cleanup := setupTestRouter()
+	defer cleanup()
+
+	// Store a goal with every optional field set
+	goalRepo := repository.NewGoalRepository(db)
+	userID := primitive.NewObjectID()
+	goal := &models.Goal{
+		UserID:    userID,
+		Name:      "Goal to clear",
+		Category:  "Health",
+		Tags:      []string{"run"},
+		Priority:  models.PriorityHighest,
+		Important: true,
+		Urgent:    true,
+		Steps:     []string{"Step 1"},
+		Progress:  map[string]bool{"Step 1": false},
+		Status:    "pending",
+	}
+	createdGoal, err := goalRepo.CreateGoal(ctx, goal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PUT the goal back without them
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":     "Goal to clear",
+		"steps":    []string{"Step 1"},
+		"progress": map[string]bool{"Step 1": false},
+		"status":   "pending",
+		"version":  createdGoal.Version,
+	})
+	req, err := http.NewRequest("PUT", "/goals/"+createdGoal.ID.Hex(), bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &jwtutil.Claims{UserID: userID.Hex()}
+	req = req.WithContext(context.WithValue(ctx, middleware.UserContextKey, claims))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/goals/{id}", NewGoalHandler(services.NewGoalService(goalRepo)).UpdateGoalHandler).Methods("PUT")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	stored, err := goalRepo.GetGoalByID(ctx, createdGoal.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, stored.Category)
+	assert.Empty(t, stored.Tags)
+	assert.Equal(t, models.PriorityNone, stored.Priority)
+	assert.False(t, stored.Important)
+	assert.False(t, stored.Urgent)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/czeful/diplom_back/internal/repository"
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"github.com/gorilla/mux"
+)
+
+// HistoryHandler handles HTTP requests related to goal activity history.
+type HistoryHandler struct {
+	Service     *services.HistoryService
+	GoalService *services.GoalService
+}
+
+// NewHistoryHandler creates a new instance of HistoryHandler.
+func NewHistoryHandler(service *services.HistoryService, goalService *services.GoalService) *HistoryHandler {
+	return &HistoryHandler{
+		Service:     service,
+		GoalService: goalService,
+	}
+}
+
+// GetGoalHistoryHandler returns a page of a goal's activity history, newest first.
+func (h *HistoryHandler) GetGoalHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	goalID := vars["id"]
+
+	// Get the logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Fetch the goal from DB
+	goal, err := h.GoalService.GetGoal(r.Context(), goalID)
+	if err != nil || goal == nil {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+
+	// Ensure the logged-in user may view the goal
+	if !authorize(w, r, h.GoalService, goal, services.ActionView) {
+		return
+	}
+
+	page := parsePositiveInt(r.URL.Query().Get("page"), 1)
+	limit := parsePositiveInt(r.URL.Query().Get("limit"), 20)
+	if limit > 100 {
+		limit = 100
+	}
+
+	entries, total, err := h.Service.GetHistory(r.Context(), goal.ID, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve goal history", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"items": entries,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RestoreGoalRevisionHandler restores a goal to the state stored in a previous revision.
+func (h *HistoryHandler) RestoreGoalRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	goalID := vars["id"]
+
+	// Get the logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	revision, err := strconv.Atoi(vars["revision"])
+	if err != nil || revision < 1 {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the goal from DB
+	goal, err := h.GoalService.GetGoal(r.Context(), goalID)
+	if err != nil || goal == nil {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+
+	// Ensure the logged-in user may edit the goal
+	if !authorize(w, r, h.GoalService, goal, services.ActionEdit) {
+		return
+	}
+
+	// Honour If-Match so that a restore based on a stale copy is rejected
+	if _, ok := expectedVersion(w, r, goal); !ok {
+		return
+	}
+
+	restoredGoal, err := h.Service.RestoreRevision(r.Context(), goal, revision, actorID(r))
+	if err != nil {
+		switch {
+		case writeConflict(w, err):
+		case errors.Is(err, repository.ErrRevisionNotFound):
+			http.Error(w, "Revision not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrNoSnapshot):
+			http.Error(w, "Revision has no snapshot to restore", http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, "Failed to restore revision", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	setGoalETag(w, restoredGoal)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(restoredGoal)
+}
+
+// parsePositiveInt parses a positive integer query value, falling back to def.
+func parsePositiveInt(value string, def int64) int64 {
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed < 1 {
+		return def
+	}
+	return parsed
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/gorilla/mux"
+)
+
+// NoteHandler handles HTTP requests related to goal notes and journal entries.
+type NoteHandler struct {
+	Service     *services.NoteService
+	GoalService *services.GoalService
+}
+
+// NewNoteHandler creates a new instance of NoteHandler.
+func NewNoteHandler(service *services.NoteService, goalService *services.GoalService) *NoteHandler {
+	return &NoteHandler{
+		Service:     service,
+		GoalService: goalService,
+	}
+}
+
+// GetNotesHandler returns a page of a goal's notes, newest first. ?step= limits the
+// result to the notes on one step.
+func (h *NoteHandler) GetNotesHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionView)
+	if !ok {
+		return
+	}
+
+	page := parsePositiveInt(r.URL.Query().Get("page"), 1)
+	limit := parsePositiveInt(r.URL.Query().Get("limit"), 20)
+	if limit > 100 {
+		limit = 100
+	}
+
+	notes, total, err := h.Service.GetNotes(r.Context(), goal.ID, r.URL.Query().Get("step"), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve notes", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"items": notes,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateNoteHandler adds a note to a goal, or to one of its steps when step is set.
+func (h *NoteHandler) CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionEdit)
+	if !ok {
+		return
+	}
+
+	var note models.GoalNote
+	if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := services.ValidateNote(goal, &note); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdNote, err := h.Service.CreateNote(r.Context(), goal, &note, actorID(r))
+	if err != nil {
+		http.Error(w, "Failed to create note", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdNote)
+}
+
+// GetNoteHandler fetches a single note of a goal.
+func (h *NoteHandler) GetNoteHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionView)
+	if !ok {
+		return
+	}
+	note, ok := h.goalNote(w, r, goal)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(note)
+}
+
+// UpdateNoteHandler replaces the body and mood of a note.
+func (h *NoteHandler) UpdateNoteHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionEdit)
+	if !ok {
+		return
+	}
+	existingNote, ok := h.goalNote(w, r, goal)
+	if !ok {
+		return
+	}
+
+	var updatedNote models.GoalNote
+	if err := json.NewDecoder(r.Body).Decode(&updatedNote); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	updatedNote.Step = existingNote.Step
+	if err := services.ValidateNote(goal, &updatedNote); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updatedNoteData, err := h.Service.UpdateNote(r.Context(), goal, existingNote, &updatedNote, actorID(r))
+	if err != nil {
+		http.Error(w, "Failed to update note", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedNoteData)
+}
+
+// DeleteNoteHandler removes a note from a goal.
+func (h *NoteHandler) DeleteNoteHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionEdit)
+	if !ok {
+		return
+	}
+	note, ok := h.goalNote(w, r, goal)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteNote(r.Context(), goal, note, actorID(r)); err != nil {
+		http.Error(w, "Failed to delete note", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// goalNote loads the note from the route and ensures it belongs to the goal.
+func (h *NoteHandler) goalNote(w http.ResponseWriter, r *http.Request, goal *models.Goal) (*models.GoalNote, bool) {
+	note, err := h.Service.GetNote(r.Context(), mux.Vars(r)["noteId"])
+	if err != nil || note == nil || note.GoalID != goal.ID {
+		http.Error(w, "Note not found", http.StatusNotFound)
+		return nil, false
+	}
+	return note, true
+}
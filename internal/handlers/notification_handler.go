@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationHandler handles HTTP requests related to notifications.
+type NotificationHandler struct {
+	Service *services.NotificationService
+}
+
+// NewNotificationHandler creates a new instance of NotificationHandler.
+func NewNotificationHandler(service *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{Service: service}
+}
+
+// GetNotificationsHandler lists the logged-in user's latest notifications, newest first.
+// ?unread=true leaves out those already read.
+func (h *NotificationHandler) GetNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	notifications, err := h.Service.GetNotifications(r.Context(), userID, unreadOnly, limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve notifications", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notifications)
+}
+
+// MarkNotificationsReadHandler marks the notifications listed in the ids of the request
+// body as read, or all of the logged-in user's notifications when no ids are given.
+func (h *NotificationHandler) MarkNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	var request struct {
+		IDs []primitive.ObjectID `json:"ids"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+	}
+
+	marked, err := h.Service.MarkRead(r.Context(), userID, request.IDs)
+	if err != nil {
+		http.Error(w, "Failed to mark notifications read", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"marked": marked})
+}
@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/czeful/diplom_back/internal/config"
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"github.com/czeful/diplom_back/internal/services"
+	jwtutil "github.com/czeful/diplom_back/pkg/jwt"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrgHandler handles HTTP requests related to organizations, their members and their goals.
+type OrgHandler struct {
+	Service     *services.OrgService
+	GoalService *services.GoalService
+	Config      *config.Config
+}
+
+// NewOrgHandler creates a new instance of OrgHandler.
+func NewOrgHandler(service *services.OrgService, goalService *services.GoalService, cfg *config.Config) *OrgHandler {
+	return &OrgHandler{
+		Service:     service,
+		GoalService: goalService,
+		Config:      cfg,
+	}
+}
+
+// GetOrgsHandler lists the organizations the logged-in user belongs to.
+func (h *OrgHandler) GetOrgsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	orgs, err := h.Service.GetUserOrgs(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve organizations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orgs)
+}
+
+// CreateOrgHandler creates an organization with the logged-in user as its admin.
+func (h *OrgHandler) CreateOrgHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	name, err := services.ValidateOrgName(request.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	org, err := h.Service.CreateOrg(r.Context(), userID, name)
+	if err != nil {
+		http.Error(w, "Failed to create organization", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(org)
+}
+
+// SetActiveOrgHandler issues a new token acting within the organization given as org_id,
+// or for personal use when org_id is empty. Goals are then created in, and listed from,
+// that organization.
+func (h *OrgHandler) SetActiveOrgHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	claims := middleware.GetUserFromContext(r.Context())
+
+	var request struct {
+		OrgID string `json:"org_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if request.OrgID != "" {
+		orgID, err := primitive.ObjectIDFromHex(request.OrgID)
+		if err != nil {
+			http.Error(w, "Invalid organization ID", http.StatusBadRequest)
+			return
+		}
+		role, err := h.Service.Role(r.Context(), orgID, userID)
+		if err != nil {
+			http.Error(w, "Failed to check organization membership", http.StatusInternalServerError)
+			return
+		}
+		if role == "" {
+			http.Error(w, "Forbidden: You are not a member of this organization", http.StatusForbidden)
+			return
+		}
+	}
+
+	token, err := jwtutil.GenerateOrgToken(claims.UserID, claims.Email, request.OrgID, h.Config.JWTSecret, h.Config.TokenExpiry)
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"token":  token,
+		"org_id": request.OrgID,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetOrgHandler fetches an organization the logged-in user belongs to.
+func (h *OrgHandler) GetOrgHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := h.memberOrg(w, r, false)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
+
+// RenameOrgHandler changes the name of an organization.
+func (h *OrgHandler) RenameOrgHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := h.memberOrg(w, r, true)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	name, err := services.ValidateOrgName(request.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	org, err = h.Service.RenameOrg(r.Context(), org, name)
+	if err != nil {
+		http.Error(w, "Failed to update organization", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
+
+// DeleteOrgHandler removes an organization. Its goals go back to the users who created them.
+func (h *OrgHandler) DeleteOrgHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := h.memberOrg(w, r, true)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteOrg(r.Context(), org); err != nil {
+		http.Error(w, "Failed to delete organization", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMembersHandler lists the members and pending invitations of an organization.
+func (h *OrgHandler) GetMembersHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := h.memberOrg(w, r, false)
+	if !ok {
+		return
+	}
+
+	members, err := h.Service.GetMembers(r.Context(), org)
+	if err != nil {
+		http.Error(w, "Failed to retrieve members", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+// InviteMemberHandler invites the user of an email address to an organization with a role.
+func (h *OrgHandler) InviteMemberHandler(w http.ResponseWriter, r *http.Request) {
+	org, userID, ok := h.memberOrg(w, r, true)
+	if !ok {
+		return
+	}
+
+	var invitation struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&invitation); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	email, err := services.NormalizeEmail(invitation.Email)
+	if err != nil {
+		http.Error(w, "A valid email is required", http.StatusBadRequest)
+		return
+	}
+	if invitation.Role == "" {
+		invitation.Role = models.OrgRoleMember
+	}
+	if err := services.ValidateOrgRole(invitation.Role); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.Service.Invite(r.Context(), org, userID, email, invitation.Role)
+	if err != nil {
+		if errors.Is(err, services.ErrAlreadyMember) {
+			http.Error(w, "User is already a member of the organization", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to invite member", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(member)
+}
+
+// UpdateMemberHandler changes the role of a member.
+func (h *OrgHandler) UpdateMemberHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := h.memberOrg(w, r, true)
+	if !ok {
+		return
+	}
+	member, err := h.Service.GetMember(r.Context(), org, mux.Vars(r)["memberId"])
+	if err != nil {
+		http.Error(w, "Member not found", http.StatusNotFound)
+		return
+	}
+
+	var update struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := services.ValidateOrgRole(update.Role); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	member, err = h.Service.UpdateMemberRole(r.Context(), member, update.Role)
+	if err != nil {
+		if errors.Is(err, services.ErrLastAdmin) {
+			http.Error(w, "Organization needs at least one admin", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to update member", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(member)
+}
+
+// RemoveMemberHandler removes a member or withdraws an invitation. Admins may remove
+// anyone, and members may remove themselves to leave the organization.
+func (h *OrgHandler) RemoveMemberHandler(w http.ResponseWriter, r *http.Request) {
+	org, userID, ok := h.memberOrg(w, r, false)
+	if !ok {
+		return
+	}
+	member, err := h.Service.GetMember(r.Context(), org, mux.Vars(r)["memberId"])
+	if err != nil {
+		http.Error(w, "Member not found", http.StatusNotFound)
+		return
+	}
+
+	// Leaving an organization needs no more than belonging to it
+	if member.UserID != userID {
+		role, err := h.Service.Role(r.Context(), org.ID, userID)
+		if err != nil {
+			http.Error(w, "Failed to check organization membership", http.StatusInternalServerError)
+			return
+		}
+		if role != models.OrgRoleAdmin {
+			http.Error(w, "Forbidden: Only admins can do this", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := h.Service.RemoveMember(r.Context(), org, member, userID); err != nil {
+		if errors.Is(err, services.ErrLastAdmin) {
+			http.Error(w, "Organization needs at least one admin", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to remove member", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetOrgGoalsHandler lists the goals of an organization, optionally narrowed down with
+// ?category= and ?tag=.
+func (h *OrgHandler) GetOrgGoalsHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := h.memberOrg(w, r, false)
+	if !ok {
+		return
+	}
+
+	goals, err := h.GoalService.GetGoals(r.Context(), repository.GoalFilter{
+		OrgID:    &org.ID,
+		Category: r.URL.Query().Get("category"),
+		Tags:     r.URL.Query()["tag"],
+		Archived: repository.ArchivedExclude,
+	})
+	if err != nil {
+		http.Error(w, "Failed to retrieve goals", http.StatusInternalServerError)
+		return
+	}
+	if goals == nil {
+		goals = []models.Goal{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(goals)
+}
+
+// GetContributionsHandler sums up what each member did on the goals of an organization.
+func (h *OrgHandler) GetContributionsHandler(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := h.memberOrg(w, r, false)
+	if !ok {
+		return
+	}
+
+	contributions, err := h.Service.GetContributions(r.Context(), org)
+	if err != nil {
+		http.Error(w, "Failed to retrieve contributions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contributions)
+}
+
+// GetOrgInvitationsHandler lists the pending organization invitations of the logged-in user.
+func (h *OrgHandler) GetOrgInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.Service.GetInvitations(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve invitations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invitations)
+}
+
+// AcceptOrgInvitationHandler accepts an invitation, making the user a member.
+func (h *OrgHandler) AcceptOrgInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, true)
+}
+
+// DeclineOrgInvitationHandler declines an invitation.
+func (h *OrgHandler) DeclineOrgInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, false)
+}
+
+func (h *OrgHandler) respond(w http.ResponseWriter, r *http.Request, accept bool) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	member, err := h.Service.Respond(r.Context(), mux.Vars(r)["id"], userID, accept)
+	if err != nil {
+		if errors.Is(err, services.ErrMemberNotFound) {
+			http.Error(w, "Invitation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to respond to invitation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(member)
+}
+
+// memberOrg loads the organization from the route and ensures the logged-in user belongs
+// to it, as an admin when adminOnly is set. It writes the error response itself and
+// reports whether the caller may continue.
+func (h *OrgHandler) memberOrg(w http.ResponseWriter, r *http.Request, adminOnly bool) (*models.Organization, primitive.ObjectID, bool) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return nil, primitive.NilObjectID, false
+	}
+
+	org, err := h.Service.GetOrg(r.Context(), mux.Vars(r)["id"])
+	if err != nil || org == nil {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return nil, primitive.NilObjectID, false
+	}
+
+	role, err := h.Service.Role(r.Context(), org.ID, userID)
+	if err != nil {
+		http.Error(w, "Failed to check organization membership", http.StatusInternalServerError)
+		return nil, primitive.NilObjectID, false
+	}
+	if role == "" {
+		http.Error(w, "Forbidden: You are not a member of this organization", http.StatusForbidden)
+		return nil, primitive.NilObjectID, false
+	}
+	if adminOnly && role != models.OrgRoleAdmin {
+		http.Error(w, "Forbidden: Only admins can do this", http.StatusForbidden)
+		return nil, primitive.NilObjectID, false
+	}
+	return org, userID, true
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/gorilla/mux"
+)
+
+// PartnerHandler handles HTTP requests related to accountability partners.
+type PartnerHandler struct {
+	Service     *services.PartnerService
+	GoalService *services.GoalService
+}
+
+// NewPartnerHandler creates a new instance of PartnerHandler.
+func NewPartnerHandler(service *services.PartnerService, goalService *services.GoalService) *PartnerHandler {
+	return &PartnerHandler{
+		Service:     service,
+		GoalService: goalService,
+	}
+}
+
+// GetPartnersHandler lists the partners of the logged-in user.
+func (h *PartnerHandler) GetPartnersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	partners, err := h.Service.GetPartners(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve partners", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(partners)
+}
+
+// CreatePartnerRequestHandler asks the user of an email address to become a partner.
+func (h *PartnerHandler) CreatePartnerRequestHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	email, err := services.NormalizeEmail(request.Email)
+	if err != nil {
+		http.Error(w, "A valid email is required", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.Service.Request(r.Context(), userID, email)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPartnerNotFound):
+			http.Error(w, "User not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrPartnerWithSelf):
+			http.Error(w, "You can't be your own partner", http.StatusBadRequest)
+		case errors.Is(err, services.ErrAlreadyPartners):
+			http.Error(w, "A partner request already exists", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to send partner request", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// GetPartnerRequestsHandler lists the partner requests sent to the logged-in user.
+func (h *PartnerHandler) GetPartnerRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	requests, err := h.Service.GetRequests(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve partner requests", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(requests)
+}
+
+// AcceptPartnerRequestHandler accepts a partner request.
+func (h *PartnerHandler) AcceptPartnerRequestHandler(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, true)
+}
+
+// DeclinePartnerRequestHandler declines a partner request.
+func (h *PartnerHandler) DeclinePartnerRequestHandler(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, false)
+}
+
+// DeletePartnerHandler ends a partnership or withdraws a pending request.
+func (h *PartnerHandler) DeletePartnerHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.RemovePartner(r.Context(), userID, mux.Vars(r)["userId"]); err != nil {
+		h.partnerError(w, err, "Failed to remove partner")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPartnerGoalsHandler lists the goals a partner shows to their partners.
+func (h *PartnerHandler) GetPartnerGoalsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	goals, err := h.Service.GetPartnerGoals(r.Context(), userID, mux.Vars(r)["userId"])
+	if err != nil {
+		h.partnerError(w, err, "Failed to retrieve partner goals")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(goals)
+}
+
+// GetPartnerSummaryHandler sums up what a partner did over the last week.
+func (h *PartnerHandler) GetPartnerSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := h.Service.GetSummary(r.Context(), userID, mux.Vars(r)["userId"])
+	if err != nil {
+		h.partnerError(w, err, "Failed to summarize partner progress")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// NudgePartnerHandler sends a partner a reminder, optionally about one of their goals.
+func (h *PartnerHandler) NudgePartnerHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var nudge struct {
+		GoalID  string `json:"goal_id"`
+		Message string `json:"message"`
+	}
+	// The body is optional
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&nudge); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+	}
+	if err := services.ValidateNudgeMessage(nudge.Message); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := h.Service.Nudge(r.Context(), userID, mux.Vars(r)["userId"], nudge.GoalID, nudge.Message)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPartnerGoalNotFound):
+			http.Error(w, "Goal not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrNudgeTooSoon):
+			http.Error(w, "Partner was nudged recently", http.StatusTooManyRequests)
+		default:
+			h.partnerError(w, err, "Failed to nudge partner")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetPartnerVisibilityHandler hides a goal from the owner's partners, or shows it again.
+func (h *PartnerHandler) SetPartnerVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionManage)
+	if !ok {
+		return
+	}
+
+	var visibility struct {
+		Hidden *bool `json:"hidden"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&visibility); err != nil || visibility.Hidden == nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.Service.SetHidden(r.Context(), goal, *visibility.Hidden); err != nil {
+		http.Error(w, "Failed to update goal visibility", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"hidden": *visibility.Hidden})
+}
+
+func (h *PartnerHandler) respond(w http.ResponseWriter, r *http.Request, accept bool) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	link, err := h.Service.Respond(r.Context(), mux.Vars(r)["id"], userID, accept)
+	if err != nil {
+		if errors.Is(err, services.ErrPartnerNotFound) {
+			http.Error(w, "Partner request not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to respond to partner request", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
+
+// partnerError reports ErrPartnerNotFound as a 404 and any other error with message.
+func (h *PartnerHandler) partnerError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, services.ErrPartnerNotFound) {
+		http.Error(w, "Partner not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, message, http.StatusInternalServerError)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/czeful/diplom_back/pkg/jsonpatch"
+)
+
+// maxPatchSize limits the size of PATCH request bodies.
+const maxPatchSize = 1 << 20
+
+// errUnsupportedPatch is returned for PATCH requests with an unknown Content-Type.
+var errUnsupportedPatch = errors.New("unsupported patch format")
+
+// patchDocument applies the JSON Merge Patch or JSON Patch in the request body to the
+// JSON representation of original and returns the patched JSON. Plain application/json
+// is treated as a merge patch.
+func patchDocument(r *http.Request, original interface{}) ([]byte, error) {
+	document, err := json.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := io.ReadAll(io.LimitReader(r.Body, maxPatchSize))
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case jsonpatch.MergePatchType, "application/json":
+		return jsonpatch.MergePatch(document, patch)
+	case jsonpatch.JSONPatchType:
+		return jsonpatch.Apply(document, patch)
+	default:
+		return nil, errUnsupportedPatch
+	}
+}
+
+// writePatchError answers a failed patchDocument call.
+func writePatchError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errUnsupportedPatch):
+		w.Header().Set("Accept-Patch", jsonpatch.MergePatchType+", "+jsonpatch.JSONPatchType)
+		http.Error(w, "Unsupported Media Type: use "+jsonpatch.MergePatchType+" or "+jsonpatch.JSONPatchType, http.StatusUnsupportedMediaType)
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		http.Error(w, "Patch test operation failed", http.StatusConflict)
+	default:
+		http.Error(w, "Invalid patch: "+err.Error(), http.StatusUnprocessableEntity)
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"github.com/gorilla/mux"
+)
+
+// GetPublicProfileHandler returns the public profile of any user to a logged-in user.
+func (h *UserHandler) GetPublicProfileHandler(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetUserFromContext(r.Context()) == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.Service.GetUser(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user.PublicProfile())
+}
+
+// UploadAvatarHandler makes the image sent in the "file" field of a multipart form the
+// logged-in user's avatar.
+func (h *UserHandler) UploadAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	requestedUserID := mux.Vars(r)["id"]
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if requestedUserID != claims.UserID {
+		http.Error(w, "Forbidden: You can only update your own profile", http.StatusForbidden)
+		return
+	}
+
+	user, err := h.Service.GetUser(r.Context(), requestedUserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Leave room for the multipart framing around the image itself
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxAvatarSize+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected a multipart/form-data upload", http.StatusBadRequest)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "No file provided", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			writeAvatarError(w, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		updatedUser, err := h.Service.UploadAvatar(r.Context(), user, part)
+		part.Close()
+		if err != nil {
+			writeAvatarError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updatedUser.Profile())
+		return
+	}
+}
+
+// DeleteAvatarHandler removes the logged-in user's avatar.
+func (h *UserHandler) DeleteAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	requestedUserID := mux.Vars(r)["id"]
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if requestedUserID != claims.UserID {
+		http.Error(w, "Forbidden: You can only update your own profile", http.StatusForbidden)
+		return
+	}
+
+	user, err := h.Service.GetUser(r.Context(), requestedUserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	updatedUser, err := h.Service.DeleteAvatar(r.Context(), user)
+	if err != nil {
+		http.Error(w, "Failed to delete avatar", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedUser.Profile())
+}
+
+// GetAvatarHandler serves an avatar image. It needs no authentication so that avatars
+// can be used directly as image sources; avatar IDs don't reveal whose avatar it is.
+func (h *UserHandler) GetAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	blob, err := h.Service.OpenAvatar(r.Context(), vars["avatarId"], vars["size"])
+	if err != nil {
+		http.Error(w, "Avatar not found", http.StatusNotFound)
+		return
+	}
+	defer blob.Close()
+
+	// Avatar images never change, a new upload gets a new ID
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	// ServeContent sniffs the content type from the image itself
+	http.ServeContent(w, r, "", time.Time{}, blob)
+}
+
+// writeAvatarError maps avatar upload failures to status codes.
+func writeAvatarError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, services.ErrAvatarTooLarge), errors.As(err, &maxBytesErr):
+		http.Error(w, "Image is too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, services.ErrInvalidAvatar):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	default:
+		http.Error(w, "Failed to upload avatar", http.StatusInternalServerError)
+	}
+}
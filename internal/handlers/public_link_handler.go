@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/gorilla/mux"
+)
+
+// LinkPasswordHeader carries the password of a protected public link, which keeps it out
+// of URLs and access logs.
+const LinkPasswordHeader = "X-Link-Password"
+
+// PublicLinkHandler handles HTTP requests related to public goal links, including the
+// unauthenticated view of a goal through a link.
+type PublicLinkHandler struct {
+	Service     *services.PublicLinkService
+	GoalService *services.GoalService
+}
+
+// NewPublicLinkHandler creates a new instance of PublicLinkHandler.
+func NewPublicLinkHandler(service *services.PublicLinkService, goalService *services.GoalService) *PublicLinkHandler {
+	return &PublicLinkHandler{
+		Service:     service,
+		GoalService: goalService,
+	}
+}
+
+// GetLinksHandler lists the public links of a goal with their view counts.
+func (h *PublicLinkHandler) GetLinksHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionManage)
+	if !ok {
+		return
+	}
+
+	links, err := h.Service.GetLinks(r.Context(), goal)
+	if err != nil {
+		http.Error(w, "Failed to retrieve links", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+// CreateLinkHandler creates a public link to a goal, optionally expiring or protected
+// by a password.
+func (h *PublicLinkHandler) CreateLinkHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionManage)
+	if !ok {
+		return
+	}
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var options struct {
+		ExpiresAt *time.Time `json:"expires_at"`
+		Password  string     `json:"password"`
+	}
+	// The body is optional
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+	}
+	if err := services.ValidateLinkOptions(options.ExpiresAt, options.Password, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.Service.CreateLink(r.Context(), goal, userID, options.ExpiresAt, options.Password)
+	if err != nil {
+		http.Error(w, "Failed to create link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// RevokeLinkHandler revokes a public link of a goal.
+func (h *PublicLinkHandler) RevokeLinkHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionManage)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.RevokeLink(r.Context(), goal, mux.Vars(r)["linkId"]); err != nil {
+		if errors.Is(err, services.ErrLinkNotFound) {
+			http.Error(w, "Link not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to revoke link", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPublicGoalHandler serves the read-only view of the goal a token links to. It needs
+// no authentication; protected links take their password in the X-Link-Password header.
+func (h *PublicLinkHandler) GetPublicGoalHandler(w http.ResponseWriter, r *http.Request) {
+	goal, err := h.Service.View(r.Context(), mux.Vars(r)["token"], r.Header.Get(LinkPasswordHeader))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrLinkNotFound):
+			http.Error(w, "Link not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrLinkExpired):
+			http.Error(w, "Link has expired", http.StatusGone)
+		case errors.Is(err, services.ErrLinkPassword):
+			http.Error(w, "Password required", http.StatusUnauthorized)
+		default:
+			http.Error(w, "Failed to retrieve goal", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(goal)
+}
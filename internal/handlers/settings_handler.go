@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SettingsHandler handles HTTP requests related to user settings.
+type SettingsHandler struct {
+	Service     *services.SettingsService
+	GoalService *services.GoalService
+}
+
+// NewSettingsHandler creates a new instance of SettingsHandler.
+func NewSettingsHandler(service *services.SettingsService, goalService *services.GoalService) *SettingsHandler {
+	return &SettingsHandler{
+		Service:     service,
+		GoalService: goalService,
+	}
+}
+
+// GetSettingsHandler returns the logged-in user's settings, including defaults.
+func (h *SettingsHandler) GetSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ownSettings(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := h.Service.GetSettings(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// PatchSettingsHandler applies a JSON Merge Patch or JSON Patch to the logged-in user's
+// settings. The patched settings are validated as a whole.
+func (h *SettingsHandler) PatchSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ownSettings(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := h.Service.GetSettings(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve settings", http.StatusInternalServerError)
+		return
+	}
+
+	patchedJSON, err := patchDocument(r, settings)
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	var patched models.UserSettings
+	if err := json.Unmarshal(patchedJSON, &patched); err != nil {
+		http.Error(w, "Invalid patch: the result is not valid settings", http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Validate the patched result
+	if err := services.ValidateSettings(&patched); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if patched.DefaultCategory != "" {
+		valid, err := h.GoalService.ValidCategory(r.Context(), userID, patched.DefaultCategory)
+		if err != nil {
+			http.Error(w, "Failed to check category", http.StatusInternalServerError)
+			return
+		}
+		if !valid {
+			http.Error(w, "Invalid default_category", http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	updatedSettings, err := h.Service.UpdateSettings(r.Context(), userID, &patched)
+	if err != nil {
+		http.Error(w, "Failed to update settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedSettings)
+}
+
+// ownSettings ensures the settings in the route belong to the logged-in user and returns
+// the user's ID. It writes the error response itself and reports whether the caller may continue.
+func ownSettings(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return primitive.NilObjectID, false
+	}
+	if mux.Vars(r)["id"] != claims.UserID {
+		http.Error(w, "Forbidden: You can only access your own settings", http.StatusForbidden)
+		return primitive.NilObjectID, false
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
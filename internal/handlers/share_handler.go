@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/gorilla/mux"
+)
+
+// ShareHandler handles HTTP requests related to sharing goals and to invitations.
+type ShareHandler struct {
+	Service     *services.ShareService
+	GoalService *services.GoalService
+}
+
+// NewShareHandler creates a new instance of ShareHandler.
+func NewShareHandler(service *services.ShareService, goalService *services.GoalService) *ShareHandler {
+	return &ShareHandler{
+		Service:     service,
+		GoalService: goalService,
+	}
+}
+
+// GetSharedGoalsHandler lists the goals other users shared with the logged-in user.
+func (h *ShareHandler) GetSharedGoalsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	shared, err := h.Service.GetSharedWithMe(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve shared goals", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shared)
+}
+
+// GetSharesHandler lists the shares of a goal.
+func (h *ShareHandler) GetSharesHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionManage)
+	if !ok {
+		return
+	}
+
+	shares, err := h.Service.GetShares(r.Context(), goal)
+	if err != nil {
+		http.Error(w, "Failed to retrieve shares", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shares)
+}
+
+// CreateShareHandler invites the user of an email address to a goal with a role.
+func (h *ShareHandler) CreateShareHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionManage)
+	if !ok {
+		return
+	}
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var invitation struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&invitation); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	email, err := services.NormalizeEmail(invitation.Email)
+	if err != nil {
+		http.Error(w, "A valid email is required", http.StatusBadRequest)
+		return
+	}
+	if err := services.ValidateShareRole(invitation.Role); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	share, err := h.Service.InviteUser(r.Context(), goal, userID, email, invitation.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAlreadyShared):
+			http.Error(w, "Goal is already shared with this user", http.StatusConflict)
+		case errors.Is(err, services.ErrShareWithOwner):
+			http.Error(w, "Goal can't be shared with its owner", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to share goal", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(share)
+}
+
+// UpdateShareHandler changes the role a share grants.
+func (h *ShareHandler) UpdateShareHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionManage)
+	if !ok {
+		return
+	}
+	share, err := h.Service.GetShare(r.Context(), goal, mux.Vars(r)["shareId"])
+	if err != nil {
+		http.Error(w, "Share not found", http.StatusNotFound)
+		return
+	}
+
+	var update struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := services.ValidateShareRole(update.Role); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	share, err = h.Service.UpdateRole(r.Context(), goal, share, update.Role, actorID(r))
+	if err != nil {
+		http.Error(w, "Failed to update share", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(share)
+}
+
+// DeleteShareHandler revokes a share. The owners of the goal may revoke any share, and
+// the invitee may remove their own to leave the goal.
+func (h *ShareHandler) DeleteShareHandler(w http.ResponseWriter, r *http.Request) {
+	goal, ok := authorizedGoal(w, r, h.GoalService, services.ActionView)
+	if !ok {
+		return
+	}
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	share, err := h.Service.GetShare(r.Context(), goal, mux.Vars(r)["shareId"])
+	if err != nil {
+		http.Error(w, "Share not found", http.StatusNotFound)
+		return
+	}
+
+	// Leaving a goal needs no more than access to it
+	if share.UserID != userID && !authorize(w, r, h.GoalService, goal, services.ActionManage) {
+		return
+	}
+
+	if err := h.Service.RemoveShare(r.Context(), goal, share, userID); err != nil {
+		http.Error(w, "Failed to delete share", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetInvitationsHandler lists the pending invitations of the logged-in user.
+func (h *ShareHandler) GetInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.Service.GetInvitations(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve invitations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invitations)
+}
+
+// AcceptInvitationHandler accepts an invitation, granting its role on the goal.
+func (h *ShareHandler) AcceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, true)
+}
+
+// DeclineInvitationHandler declines an invitation.
+func (h *ShareHandler) DeclineInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, false)
+}
+
+func (h *ShareHandler) respond(w http.ResponseWriter, r *http.Request, accept bool) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	share, err := h.Service.Respond(r.Context(), mux.Vars(r)["id"], userID, accept)
+	if err != nil {
+		if errors.Is(err, services.ErrShareNotFound) {
+			http.Error(w, "Invitation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to respond to invitation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(share)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// defaultStatsDays is the range statistics cover when none is given
+	defaultStatsDays = 84
+	// defaultActivityDays is the number of days the activity calendar covers when none is given
+	defaultActivityDays = 365
+)
+
+// StatsHandler handles HTTP requests related to goal statistics.
+type StatsHandler struct {
+	Service     *services.StatsService
+	GoalService *services.GoalService
+}
+
+// NewStatsHandler creates a new instance of StatsHandler.
+func NewStatsHandler(service *services.StatsService, goalService *services.GoalService) *StatsHandler {
+	return &StatsHandler{
+		Service:     service,
+		GoalService: goalService,
+	}
+}
+
+// GetStatsHandler returns statistics over the logged-in user's goals. The range is given
+// with from and to, as dates in the user's timezone (both inclusive) or RFC 3339 timestamps;
+// it defaults to the last 12 weeks. Responses carry an ETag so that clients can revalidate.
+func (h *StatsHandler) GetStatsHandler(w http.ResponseWriter, r *http.Request) {
+	// Get logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Convert UserID to ObjectID
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	loc := h.GoalService.Location(r.Context(), userID)
+	from, to, err := statsRange(r, loc, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.Service.GetStats(r.Context(), userID, from, to)
+	if err != nil {
+		http.Error(w, "Failed to compute statistics", http.StatusInternalServerError)
+		return
+	}
+
+	body, err := json.Marshal(stats)
+	if err != nil {
+		http.Error(w, "Failed to encode statistics", http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := "\"" + hex.EncodeToString(sum[:8]) + "\""
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(services.StatsCacheTTL.Seconds())))
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
+
+// GetActivityHandler returns the logged-in user's activity per day over the last days
+// days (a year by default), along with their active day streaks.
+func (h *StatsHandler) GetActivityHandler(w http.ResponseWriter, r *http.Request) {
+	// Get logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Convert UserID to ObjectID
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	days := defaultActivityDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 1 || days > services.MaxStatsDays {
+			http.Error(w, fmt.Sprintf("Invalid days: use 1 to %d", services.MaxStatsDays), http.StatusBadRequest)
+			return
+		}
+	}
+
+	calendar, err := h.Service.GetActivity(r.Context(), userID, days)
+	if err != nil {
+		http.Error(w, "Failed to compute activity", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(calendar)
+}
+
+// statsRange parses the from and to query parameters. Dates are taken in loc, and a to
+// date includes that whole day.
+func statsRange(r *http.Request, loc *time.Location, now time.Time) (time.Time, time.Time, error) {
+	year, month, day := now.In(loc).Date()
+	to := time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, dateOnly, err := parseRangeDate(value, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: use YYYY-MM-DD or RFC 3339")
+		}
+		to = parsed
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+
+	from := to.AddDate(0, 0, -defaultStatsDays)
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, _, err := parseRangeDate(value, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: use YYYY-MM-DD or RFC 3339")
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid range: from must be before to")
+	}
+	if to.Sub(from) > services.MaxStatsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid range: at most %d days", services.MaxStatsDays)
+	}
+	return from, to, nil
+}
+
+// parseRangeDate parses a date, as local midnight in loc, or an RFC 3339 timestamp.
+func parseRangeDate(value string, loc *time.Location) (time.Time, bool, error) {
+	if date, err := time.ParseInLocation(models.DateLayout, value, loc); err == nil {
+		return date, true, nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, value)
+	return timestamp, false, err
+}
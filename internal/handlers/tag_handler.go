@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TagHandler handles HTTP requests related to tags.
+type TagHandler struct {
+	Service *services.TagService
+}
+
+// NewTagHandler creates a new instance of TagHandler.
+func NewTagHandler(service *services.TagService) *TagHandler {
+	return &TagHandler{Service: service}
+}
+
+// GetTagsHandler lists the logged-in user's tags with their colors and usage counts.
+func (h *TagHandler) GetTagsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	tags, err := h.Service.GetTags(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve tags", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// UpdateTagHandler sets the color of a tag.
+func (h *TagHandler) UpdateTagHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Color string `json:"color"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	name := mux.Vars(r)["name"]
+	if err := services.ValidateTagName(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := services.ValidateColor(request.Color); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.Service.SetTagColor(r.Context(), userID, name, request.Color)
+	if err != nil {
+		http.Error(w, "Failed to update tag", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// RenameTagHandler renames a tag on all of the logged-in user's goals. Renaming to an
+// existing tag merges the two.
+func (h *TagHandler) RenameTagHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	target := strings.TrimSpace(request.Name)
+	if err := services.ValidateTagName(target); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	changed, err := h.Service.RenameTag(r.Context(), userID, mux.Vars(r)["name"], target)
+	if err != nil {
+		http.Error(w, "Failed to rename tag", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"goals_updated": changed})
+}
+
+// MergeTagsHandler replaces several tags with a single one on all of the logged-in user's goals.
+func (h *TagHandler) MergeTagsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Sources []string `json:"sources"`
+		Target  string   `json:"target"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	target := strings.TrimSpace(request.Target)
+	if err := services.ValidateTagName(target); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(request.Sources) == 0 {
+		http.Error(w, "No source tags provided", http.StatusBadRequest)
+		return
+	}
+
+	changed, err := h.Service.MergeTags(r.Context(), userID, request.Sources, target)
+	if err != nil {
+		http.Error(w, "Failed to merge tags", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"goals_updated": changed})
+}
+
+// DeleteTagHandler removes a tag from all of the logged-in user's goals.
+func (h *TagHandler) DeleteTagHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	changed, err := h.Service.DeleteTag(r.Context(), userID, mux.Vars(r)["name"])
+	if err != nil {
+		http.Error(w, "Failed to delete tag", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"goals_updated": changed})
+}
+
+// currentUserID returns the ID of the logged-in user. It writes the error response
+// itself and reports whether the caller may continue.
+func currentUserID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return primitive.NilObjectID, false
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
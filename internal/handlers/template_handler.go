@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TemplateHandler handles HTTP requests related to goal templates.
+type TemplateHandler struct {
+	Service     *services.TemplateService
+	GoalService *services.GoalService
+}
+
+// NewTemplateHandler creates a new instance of TemplateHandler.
+func NewTemplateHandler(service *services.TemplateService, goalService *services.GoalService) *TemplateHandler {
+	return &TemplateHandler{
+		Service:     service,
+		GoalService: goalService,
+	}
+}
+
+// GetTemplatesHandler lists the built-in templates and the logged-in user's private ones.
+func (h *TemplateHandler) GetTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	templates, err := h.Service.GetTemplates(r.Context(), userID, r.URL.Query().Get("category"))
+	if err != nil {
+		http.Error(w, "Failed to retrieve templates", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GetTemplateHandler fetches a single template.
+func (h *TemplateHandler) GetTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	template, ok := h.visibleTemplate(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// CreateTemplateHandler creates a private template for the logged-in user.
+func (h *TemplateHandler) CreateTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var template models.GoalTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	if err := services.ValidateTemplate(&template); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.validCategory(w, r, userID, template.Category) {
+		return
+	}
+
+	template.ID = primitive.NilObjectID
+	template.UserID = userID
+
+	createdTemplate, err := h.Service.CreateTemplate(r.Context(), &template)
+	if err != nil {
+		http.Error(w, "Failed to create template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdTemplate)
+}
+
+// UpdateTemplateHandler replaces a private template.
+func (h *TemplateHandler) UpdateTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	existingTemplate, ok := h.ownedTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	var updatedTemplate models.GoalTemplate
+	if err := json.NewDecoder(r.Body).Decode(&updatedTemplate); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := services.ValidateTemplate(&updatedTemplate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.validCategory(w, r, existingTemplate.UserID, updatedTemplate.Category) {
+		return
+	}
+
+	updatedTemplate.ID = existingTemplate.ID
+	updatedTemplate.UserID = existingTemplate.UserID
+	updatedTemplate.System = false
+	updatedTemplate.Slug = ""
+	updatedTemplate.CreatedAt = existingTemplate.CreatedAt
+
+	updatedTemplateData, err := h.Service.UpdateTemplate(r.Context(), existingTemplate.ID.Hex(), &updatedTemplate)
+	if err != nil {
+		http.Error(w, "Failed to update template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedTemplateData)
+}
+
+// DeleteTemplateHandler removes a private template.
+func (h *TemplateHandler) DeleteTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	template, ok := h.ownedTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteTemplate(r.Context(), template.ID.Hex()); err != nil {
+		http.Error(w, "Failed to delete template", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SaveGoalAsTemplateHandler creates a private template from one of the user's goals.
+func (h *TemplateHandler) SaveGoalAsTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	goalID := vars["id"]
+
+	// Get the logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Fetch the goal from DB
+	goal, err := h.GoalService.GetGoal(r.Context(), goalID)
+	if err != nil || goal == nil {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+
+	// Ensure the logged-in user may view the goal
+	if !authorize(w, r, h.GoalService, goal, services.ActionView) {
+		return
+	}
+
+	// The template name is optional and defaults to the goal name
+	var request struct {
+		Name string `json:"name"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+	}
+
+	template, err := h.Service.SaveGoalAsTemplate(r.Context(), goal, request.Name)
+	if err != nil {
+		http.Error(w, "Failed to save template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(template)
+}
+
+// CreateGoalFromTemplateHandler instantiates a goal from a template, with due dates
+// computed from the requested start date (today by default).
+func (h *TemplateHandler) CreateGoalFromTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	template, ok := h.visibleTemplate(w, r, mux.Vars(r)["templateId"])
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Name      string `json:"name"`
+		StartDate string `json:"start_date"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+	}
+
+	start := time.Now()
+	if request.StartDate != "" {
+		parsed, err := parseStartDate(request.StartDate)
+		if err != nil {
+			http.Error(w, "Invalid start date: use YYYY-MM-DD or RFC 3339", http.StatusBadRequest)
+			return
+		}
+		start = parsed
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	// Same rule as CreateGoalHandler: the resulting goal can't already be overdue
+	preview := services.BuildGoalFromTemplate(template, start)
+	if preview.IsOverdue(time.Now(), h.GoalService.Location(r.Context(), userID)) {
+		http.Error(w, "Due date cannot be in the past: choose a later start date", http.StatusBadRequest)
+		return
+	}
+
+	// Goals created while acting within an organization belong to it
+	orgID, ok := activeOrg(w, r, h.GoalService, userID)
+	if !ok {
+		return
+	}
+
+	goal, err := h.Service.CreateGoalFromTemplate(r.Context(), template, userID, orgID, start, request.Name)
+	if err != nil {
+		http.Error(w, "Failed to create goal", http.StatusInternalServerError)
+		return
+	}
+
+	setGoalETag(w, goal)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(goal)
+}
+
+// visibleTemplate loads a template that is either built in or owned by the logged-in user.
+func (h *TemplateHandler) visibleTemplate(w http.ResponseWriter, r *http.Request, id string) (*models.GoalTemplate, bool) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	template, err := h.Service.GetTemplate(r.Context(), id)
+	if err != nil || template == nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if !template.System && template.UserID.Hex() != claims.UserID {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return template, true
+}
+
+// ownedTemplate loads a private template of the logged-in user for modification.
+func (h *TemplateHandler) ownedTemplate(w http.ResponseWriter, r *http.Request) (*models.GoalTemplate, bool) {
+	template, ok := h.visibleTemplate(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return nil, false
+	}
+
+	if template.System {
+		http.Error(w, "Forbidden: Built-in templates can't be modified", http.StatusForbidden)
+		return nil, false
+	}
+
+	return template, true
+}
+
+// parseStartDate accepts a calendar date or a full RFC 3339 timestamp.
+func parseStartDate(value string) (time.Time, error) {
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// validCategory checks that the template owner can use the category, writing a 400 if not.
+func (h *TemplateHandler) validCategory(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID, category string) bool {
+	if category == "" {
+		return true
+	}
+	valid, err := h.GoalService.ValidCategory(r.Context(), userID, category)
+	if err != nil {
+		http.Error(w, "Failed to check category", http.StatusInternalServerError)
+		return false
+	}
+	if !valid {
+		http.Error(w, "Invalid category", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// responseZone resolves the tz query parameter, which asks for the timestamps of a
+// response to be rendered with a zone's offset: "user" for the timezone of userID, who
+// must be the requesting user, or an IANA zone name. loc is nil when no zone was asked for. ok is false when the zone is
+// unknown, in which case a 400 has been written.
+func responseZone(w http.ResponseWriter, r *http.Request, goalService *services.GoalService, userID primitive.ObjectID) (loc *time.Location, ok bool) {
+	tz := r.URL.Query().Get("tz")
+	switch tz {
+	case "":
+		return nil, true
+	case "user":
+		return goalService.Location(r.Context(), userID), true
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		http.Error(w, "Invalid tz: use user or an IANA timezone name", http.StatusBadRequest)
+		return nil, false
+	}
+	return loc, true
+}
+
+// goalsIn converts the timestamps of goals to loc in place; a nil loc leaves them as stored.
+func goalsIn(goals []models.Goal, loc *time.Location) {
+	if loc == nil {
+		return
+	}
+	for i := range goals {
+		goals[i] = goals[i].In(loc)
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"fmt"
 	"bytes"
@@ -80,7 +81,7 @@ func (h *UserHandler) RegisterUserHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(createdUser)
+	json.NewEncoder(w).Encode(createdUser.Profile())
 }
 
 
@@ -114,7 +115,7 @@ func (h *UserHandler) LoginUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Return the token and user details
 	response := map[string]interface{}{
 		"token": token,
-		"user":  user,
+		"user":  user.Profile(),
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -146,9 +147,10 @@ func (h *UserHandler) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(user.Profile())
 }
 
+// UpdateUserHandler replaces the editable profile fields of the logged-in user.
 func (h *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	requestedUserID := vars["id"]
@@ -166,21 +168,88 @@ func (h *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Decode request body
-	var updatedUser models.User
-	if err := json.NewDecoder(r.Body).Decode(&updatedUser); err != nil {
+	// Decode request body; fields left out are cleared
+	var update services.UserPatch
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
 	defer r.Body.Close()
 
+	if err := services.ValidateUserPatch(&update); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Update user in DB
-	updatedUserData, err := h.Service.UpdateUser(r.Context(), requestedUserID, &updatedUser)
+	updatedUser, err := h.Service.PatchUser(r.Context(), requestedUserID, update)
+	if err != nil {
+		if errors.Is(err, services.ErrEmailInUse) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedUser.Profile())
+}
+
+// PatchUserHandler applies a JSON Merge Patch or JSON Patch to the user's editable profile fields.
+func (h *UserHandler) PatchUserHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	requestedUserID := vars["id"]
+
+	// Get logged-in user
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Ensure only the logged-in user can update their own profile
+	if requestedUserID != claims.UserID {
+		http.Error(w, "Forbidden: You can only update your own profile", http.StatusForbidden)
+		return
+	}
+
+	user, err := h.Service.GetUser(r.Context(), requestedUserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Only the editable fields are exposed to the patch
+	patchedJSON, err := patchDocument(r, services.NewUserPatch(user))
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	var patch services.UserPatch
+	if err := json.Unmarshal(patchedJSON, &patch); err != nil {
+		http.Error(w, "Invalid patch: the result is not a valid user", http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Validate the patched result
+	if err := services.ValidateUserPatch(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	updatedUser, err := h.Service.PatchUser(r.Context(), requestedUserID, patch)
 	if err != nil {
+		if errors.Is(err, services.ErrEmailInUse) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to update user", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedUserData)
+	json.NewEncoder(w).Encode(updatedUser.Profile())
 }
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/services"
+	"github.com/czeful/diplom_back/pkg/middleware"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookHandler handles HTTP requests related to webhooks.
+type WebhookHandler struct {
+	Service *services.WebhookService
+}
+
+// NewWebhookHandler creates a new instance of WebhookHandler.
+func NewWebhookHandler(service *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{Service: service}
+}
+
+// CreateWebhookHandler registers a new webhook endpoint for the logged-in user.
+func (h *WebhookHandler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var webhook models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	if err := services.ValidateWebhook(&webhook); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Service.CheckEndpoint(r.Context(), webhook.URL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	webhook.ID = primitive.NilObjectID
+	webhook.UserID = userID
+	webhook.Active = true
+
+	createdWebhook, err := h.Service.CreateWebhook(r.Context(), &webhook)
+	if err != nil {
+		http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+
+	// The secret is only ever returned once, on creation
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdWebhook)
+}
+
+// GetWebhooksHandler lists the webhooks of the logged-in user.
+func (h *WebhookHandler) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusInternalServerError)
+		return
+	}
+
+	webhooks, err := h.Service.GetWebhooks(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve webhooks", http.StatusInternalServerError)
+		return
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// GetWebhookHandler fetches a single webhook by its ID.
+func (h *WebhookHandler) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	webhook.Secret = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// UpdateWebhookHandler changes the URL, event selection or active flag of a webhook.
+func (h *WebhookHandler) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	existingWebhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var updatedWebhook models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&updatedWebhook); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := services.ValidateWebhook(&updatedWebhook); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Service.CheckEndpoint(r.Context(), updatedWebhook.URL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The owner and signing secret can never be changed through an update
+	updatedWebhook.ID = existingWebhook.ID
+	updatedWebhook.UserID = existingWebhook.UserID
+	updatedWebhook.Secret = existingWebhook.Secret
+	updatedWebhook.CreatedAt = existingWebhook.CreatedAt
+
+	updatedWebhookData, err := h.Service.UpdateWebhook(r.Context(), existingWebhook.ID.Hex(), &updatedWebhook)
+	if err != nil {
+		http.Error(w, "Failed to update webhook", http.StatusInternalServerError)
+		return
+	}
+	updatedWebhookData.Secret = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedWebhookData)
+}
+
+// DeleteWebhookHandler removes a webhook and its delivery log.
+func (h *WebhookHandler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteWebhook(r.Context(), webhook.ID.Hex()); err != nil {
+		http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveriesHandler returns the delivery log of a webhook, newest first.
+func (h *WebhookHandler) GetDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var limit int64 = 50 // default limit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsed, err := strconv.ParseInt(limitParam, 10, 64)
+		if err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	deliveries, err := h.Service.GetDeliveries(r.Context(), webhook.ID, limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// SendTestEventHandler delivers a test event to the webhook and returns the result.
+func (h *WebhookHandler) SendTestEventHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.Service.SendTestEvent(r.Context(), webhook)
+	if err != nil {
+		http.Error(w, "Failed to send test event", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// ownedWebhook loads the webhook from the route and ensures it belongs to the logged-in user.
+// It writes the error response itself and reports whether the caller may continue.
+func (h *WebhookHandler) ownedWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	webhook, err := h.Service.GetWebhook(r.Context(), mux.Vars(r)["id"])
+	if err != nil || webhook == nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if webhook.UserID.Hex() != claims.UserID {
+		http.Error(w, "Forbidden: You can only manage your own webhooks", http.StatusForbidden)
+		return nil, false
+	}
+
+	return webhook, true
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Achievement metrics, what a rule measures to decide whether it is met
+const (
+	// MetricGoalsCompleted counts the user's completed goals
+	MetricGoalsCompleted = "goals_completed"
+	// MetricGoalsCreated counts the user's goals
+	MetricGoalsCreated = "goals_created"
+	// MetricStepsCompleted counts the steps checked across the user's goals
+	MetricStepsCompleted = "steps_completed"
+	// MetricStreak is the user's current run of consecutive active days
+	MetricStreak = "streak"
+	// MetricBeforeDeadline is met when the goal of the event was completed before its deadline
+	MetricBeforeDeadline = "before_deadline"
+)
+
+// AchievementMetrics lists the metrics rules can use.
+var AchievementMetrics = map[string]bool{
+	MetricGoalsCompleted: true,
+	MetricGoalsCreated:   true,
+	MetricStepsCompleted: true,
+	MetricStreak:         true,
+	MetricBeforeDeadline: true,
+}
+
+// AchievementRules configure gamification: the XP each event is worth, the levels and
+// the achievements that can be unlocked. They are loaded from a JSON file.
+type AchievementRules struct {
+	// XP maps an event type, such as "step.completed", to the XP it earns
+	XP map[string]int `json:"xp"`
+	// Levels holds the XP needed for each level from level 1, in increasing order
+	Levels       []int             `json:"levels"`
+	Achievements []AchievementRule `json:"achievements"`
+}
+
+// AchievementRule unlocks an achievement once Metric reaches Threshold. It is checked
+// whenever an event of type On happens to one of the user's goals.
+type AchievementRule struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Icon        string `json:"icon,omitempty"`
+	// XP is awarded on top of the event XP when the achievement is unlocked
+	XP int    `json:"xp,omitempty"`
+	On string `json:"on"`
+	// Metric is one of the AchievementMetrics
+	Metric string `json:"metric"`
+	// Category limits goal counts to goals of this category
+	Category  string `json:"category,omitempty"`
+	Threshold int    `json:"threshold,omitempty"`
+}
+
+// UserAchievement records an achievement unlocked by a user; a user unlocks each
+// achievement once.
+type UserAchievement struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID        primitive.ObjectID `bson:"user_id" json:"-"`
+	AchievementID string             `bson:"achievement_id" json:"id"`
+	Name          string             `bson:"name" json:"name"`
+	Description   string             `bson:"description" json:"description"`
+	Icon          string             `bson:"icon,omitempty" json:"icon,omitempty"`
+	XP            int                `bson:"xp" json:"xp"`
+	GoalID        primitive.ObjectID `bson:"goal_id,omitempty" json:"goal_id,omitempty"`
+	UnlockedAt    time.Time          `bson:"unlocked_at" json:"unlocked_at"`
+}
+
+// XPAward records XP earned by a user. Key identifies what earned it, such as a step of a
+// goal, so that the same thing never earns XP twice.
+type XPAward struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Key       string             `bson:"key"`
+	XP        int                `bson:"xp"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// AchievementSummary is a user's progress in the game.
+type AchievementSummary struct {
+	XP    int `json:"xp"`
+	Level int `json:"level"`
+	// LevelXP and NextLevelXP are the XP needed for the current and the next level;
+	// NextLevelXP is omitted at the highest level
+	LevelXP     int               `json:"level_xp"`
+	NextLevelXP int               `json:"next_level_xp,omitempty"`
+	Unlocked    []UserAchievement `json:"unlocked"`
+	// Locked lists the achievements that can still be unlocked
+	Locked []AchievementRule `json:"locked"`
+}
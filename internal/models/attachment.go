@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment is a file attached to a goal. The content lives in blob storage.
+type Attachment struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GoalID       primitive.ObjectID `bson:"goal_id" json:"goal_id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"user_id"`
+	FileName     string             `bson:"file_name" json:"file_name"`
+	ContentType  string             `bson:"content_type" json:"content_type"`
+	Size         int64              `bson:"size" json:"size"`
+	BlobKey      string             `bson:"blob_key" json:"-"`
+	ThumbnailKey string             `bson:"thumbnail_key,omitempty" json:"-"`
+	HasThumbnail bool               `bson:"has_thumbnail" json:"has_thumbnail"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// StorageUsage reports how much of their attachment quota a user has used, in bytes.
+type StorageUsage struct {
+	Used  int64 `json:"used"`
+	Quota int64 `json:"quota"`
+}
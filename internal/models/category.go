@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Category is a goal category. The AllowedCategories are available to every user as
+// defaults; users can add their own categories on top of them.
+type Category struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Name      string             `bson:"name" json:"name"`
+	Color     string             `bson:"color,omitempty" json:"color,omitempty"`
+	Default   bool               `bson:"-" json:"default"`
+	CreatedAt time.Time          `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
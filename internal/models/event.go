@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Goal lifecycle event types emitted by GoalService.
+const (
+	EventGoalCreated   = "goal.created"
+	EventGoalUpdated   = "goal.updated"
+	EventGoalCompleted = "goal.completed"
+	EventStepCompleted = "step.completed"
+	EventGoalDeleted   = "goal.deleted"
+	EventGoalProgress  = "goal.progress"
+	EventGoalRestored  = "goal.restored"
+	EventGoalPurged    = "goal.purged"
+	EventNoteAdded     = "note.added"
+	EventNoteUpdated   = "note.updated"
+	EventNoteDeleted   = "note.deleted"
+)
+
+// GoalEventTypes lists the event types users can subscribe to with webhooks.
+var GoalEventTypes = map[string]bool{
+	EventGoalCreated:   true,
+	EventGoalUpdated:   true,
+	EventGoalCompleted: true,
+	EventStepCompleted: true,
+	EventGoalDeleted:   true,
+}
+
+// GoalEvent describes a change that happened to a goal. Note events also carry the
+// note, and PreviousNote holds the note as it was before an edit or deletion.
+type GoalEvent struct {
+	ID           primitive.ObjectID `json:"id"`
+	Type         string             `json:"type"`
+	UserID       primitive.ObjectID `json:"user_id"`
+	ActorID      primitive.ObjectID `json:"-"`
+	GoalID       primitive.ObjectID `json:"goal_id"`
+	Goal         *Goal              `json:"goal,omitempty"`
+	Previous     *Goal              `json:"-"`
+	Step         string             `json:"step,omitempty"`
+	Note         *GoalNote          `json:"note,omitempty"`
+	PreviousNote *GoalNote          `json:"-"`
+	OccurredAt   time.Time          `json:"occurred_at"`
+}
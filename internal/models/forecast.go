@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Forecast statuses
+const (
+	ForecastOnTrack    = "on_track"
+	ForecastAtRisk     = "at_risk"
+	ForecastOffTrack   = "off_track"
+	ForecastCompleted  = "completed"
+	ForecastNoDeadline = "no_deadline"
+)
+
+// ValidForecastStatuses lists the statuses a goal forecast can have.
+var ValidForecastStatuses = map[string]bool{
+	ForecastOnTrack:    true,
+	ForecastAtRisk:     true,
+	ForecastOffTrack:   true,
+	ForecastCompleted:  true,
+	ForecastNoDeadline: true,
+}
+
+// GoalForecast projects when a goal will be completed from the pace its steps were checked at.
+type GoalForecast struct {
+	GoalID         primitive.ObjectID `json:"goal_id"`
+	Status         string             `json:"status"`
+	Steps          int                `json:"steps"`
+	CompletedSteps int                `json:"completed_steps"`
+	RemainingSteps int                `json:"remaining_steps"`
+	// Velocity is the number of steps completed per day since the goal was created
+	Velocity float64 `json:"velocity"`
+	// RequiredVelocity is the number of steps per day needed to finish by the deadline
+	RequiredVelocity    float64    `json:"required_velocity,omitempty"`
+	Start               time.Time  `json:"start"`
+	Deadline            *time.Time `json:"deadline,omitempty"`
+	ProjectedCompletion *time.Time `json:"projected_completion,omitempty"`
+	// Series holds a point per day, or per few days for long goals, for burndown and
+	// burnup charts
+	Series []ForecastPoint `json:"series"`
+}
+
+// ForecastPoint is the state of a goal at the end of a day. Completed and Remaining
+// are known up to today, Projected from today on, and Ideal only with a deadline.
+type ForecastPoint struct {
+	Date      string   `json:"date"`
+	Completed *int     `json:"completed,omitempty"`
+	Remaining *int     `json:"remaining,omitempty"`
+	Ideal     *float64 `json:"ideal,omitempty"`
+	Projected *float64 `json:"projected,omitempty"`
+}
@@ -1,12 +1,16 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// Predefined categories (optional, for validation)
+// Predefined categories (optional, for validation); every user has these by default
 var AllowedCategories = map[string]bool{
 	"Health":        true,
 	"Career":        true,
@@ -17,17 +21,177 @@ var AllowedCategories = map[string]bool{
 	"Relationships": true,
 }
 
+// Priority ranks a goal from PriorityHighest (P1) to PriorityLowest (P4); zero means
+// no priority. It is stored and returned as a number, and accepts "P1" to "P4" as input.
+type Priority int
+
+// Goal priorities
+const (
+	PriorityNone    Priority = 0
+	PriorityHighest Priority = 1
+	PriorityLowest  Priority = 4
+)
+
+// UnmarshalJSON accepts a priority given either as a number or as "P1" to "P4".
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var label string
+	if err := json.Unmarshal(data, &label); err != nil {
+		var number int
+		if err := json.Unmarshal(data, &number); err != nil {
+			return fmt.Errorf("priority must be a number or P1-P4")
+		}
+		*p = Priority(number)
+		return nil
+	}
+
+	number, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(label), "P"))
+	if label == "" {
+		number, err = 0, nil
+	}
+	if err != nil {
+		return fmt.Errorf("priority must be a number or P1-P4")
+	}
+	*p = Priority(number)
+	return nil
+}
+
+// StepMeta holds optional per-step details, keyed by step name in Goal.StepMeta.
+type StepMeta struct {
+	DueDate *time.Time `bson:"due_date,omitempty" json:"due_date,omitempty"`
+	// CompletedAt is when the step was last checked; the service maintains it
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	// CompletedBy is the user who checked the step; the service maintains it
+	CompletedBy *primitive.ObjectID `bson:"completed_by,omitempty" json:"completed_by,omitempty"`
+	// AssigneeID is the collaborator responsible for the step
+	AssigneeID *primitive.ObjectID `bson:"assignee_id,omitempty" json:"assignee_id,omitempty"`
+}
+
 // Goal represents a user's goal.
 type Goal struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Name        string             `bson:"name" json:"name"`
-	Description string             `bson:"description" json:"description"`
-	Category    string             `bson:"category,omitempty" json:"category,omitempty"` // New Field
-	Steps       []string           `bson:"steps" json:"steps"`
-	Progress    map[string]bool    `bson:"progress" json:"progress"`
-	Status      string             `bson:"status" json:"status"`
-	DueDate     time.Time          `bson:"due_date,omitempty" json:"due_date,omitempty"`
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	OrgID       *primitive.ObjectID `bson:"org_id,omitempty" json:"org_id,omitempty"`       // Set on team goals
+	ParentID    *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty"` // Set on sub-goals
+	Name        string              `bson:"name" json:"name"`
+	Description string              `bson:"description" json:"description"`
+	Category    string              `bson:"category,omitempty" json:"category,omitempty"` // New Field
+	Tags        []string            `bson:"tags,omitempty" json:"tags,omitempty"`
+	Priority    Priority            `bson:"priority,omitempty" json:"priority,omitempty"`
+	Important   bool                `bson:"important,omitempty" json:"important,omitempty"`
+	Urgent      bool                `bson:"urgent,omitempty" json:"urgent,omitempty"`
+	Steps       []string            `bson:"steps" json:"steps"`
+	Progress    map[string]bool     `bson:"progress" json:"progress"`
+	StepMeta    map[string]StepMeta `bson:"step_meta,omitempty" json:"step_meta,omitempty"`
+	Status      string              `bson:"status" json:"status"`
+	Version     int64               `bson:"version" json:"version"`
+	DueDate     time.Time           `bson:"due_date,omitempty" json:"due_date,omitempty"`
+	DueAllDay   bool                `bson:"due_all_day,omitempty" json:"due_all_day,omitempty"`
+	CreatedAt   time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time           `bson:"updated_at" json:"updated_at"`
+	CompletedAt *time.Time          `bson:"completed_at" json:"completed_at,omitempty"`
+	ArchivedAt  *time.Time          `bson:"archived_at,omitempty" json:"archived_at,omitempty"`
+	DeletedAt   *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// DateLayout is the format of date-only due dates, such as "2026-12-31".
+const DateLayout = "2006-01-02"
+
+// MarshalJSON renders the due date of all-day goals as a plain date.
+func (g Goal) MarshalJSON() ([]byte, error) {
+	type goalJSON Goal
+	if !g.DueAllDay {
+		return json.Marshal(goalJSON(g))
+	}
+	return json.Marshal(struct {
+		goalJSON
+		DueDate string `json:"due_date"`
+	}{goalJSON(g), g.DueDate.UTC().Format(DateLayout)})
+}
+
+// UnmarshalJSON accepts the due date either as a timestamp or as a plain date. A plain
+// date makes the goal due all day: it is stored as midnight UTC of that date and only
+// becomes overdue once the day is over in the owner's timezone.
+func (g *Goal) UnmarshalJSON(data []byte) error {
+	type goalJSON Goal
+	raw := struct {
+		*goalJSON
+		DueDate json.RawMessage `json:"due_date"`
+	}{goalJSON: (*goalJSON)(g)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if len(raw.DueDate) > 0 && string(raw.DueDate) != "null" {
+		var value string
+		if err := json.Unmarshal(raw.DueDate, &value); err != nil {
+			return fmt.Errorf("due_date must be a date or a timestamp")
+		}
+		if date, err := time.Parse(DateLayout, value); err == nil {
+			g.DueDate, g.DueAllDay = date, true
+		} else if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+			g.DueDate, g.DueAllDay = timestamp, false
+		} else {
+			return fmt.Errorf("due_date must be a date or a timestamp")
+		}
+	}
+	if g.DueDate.IsZero() {
+		g.DueAllDay = false
+	}
+	return nil
+}
+
+// Deadline returns the instant the goal becomes overdue in loc: its due date, or for
+// all-day goals the end of the due day. It is zero for goals without a due date.
+func (g *Goal) Deadline(loc *time.Location) time.Time {
+	if g.DueDate.IsZero() || !g.DueAllDay {
+		return g.DueDate
+	}
+	year, month, day := g.DueDate.UTC().Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+}
+
+// IsOverdue reports whether the goal's deadline in loc has passed at now.
+func (g *Goal) IsOverdue(now time.Time, loc *time.Location) bool {
+	deadline := g.Deadline(loc)
+	return !deadline.IsZero() && !now.Before(deadline)
+}
+
+// In returns a copy of the goal with its timestamps in loc, so that they are rendered
+// with that zone's offset. All-day due dates stay plain dates.
+func (g Goal) In(loc *time.Location) Goal {
+	g.CreatedAt = timeIn(g.CreatedAt, loc)
+	g.UpdatedAt = timeIn(g.UpdatedAt, loc)
+	if !g.DueAllDay {
+		g.DueDate = timeIn(g.DueDate, loc)
+	}
+	g.CompletedAt = timePtrIn(g.CompletedAt, loc)
+	g.ArchivedAt = timePtrIn(g.ArchivedAt, loc)
+	g.DeletedAt = timePtrIn(g.DeletedAt, loc)
+
+	if g.StepMeta != nil {
+		stepMeta := make(map[string]StepMeta, len(g.StepMeta))
+		for step, meta := range g.StepMeta {
+			meta.DueDate = timePtrIn(meta.DueDate, loc)
+			meta.CompletedAt = timePtrIn(meta.CompletedAt, loc)
+			stepMeta[step] = meta
+		}
+		g.StepMeta = stepMeta
+	}
+	return g
+}
+
+// timeIn converts t to loc, leaving zero times alone.
+func timeIn(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.In(loc)
+}
+
+func timePtrIn(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	converted := timeIn(*t, loc)
+	return &converted
 }
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGoalDueDateJSON tests that due dates are accepted as plain dates or timestamps
+// and that plain dates survive a round trip.
+func TestGoalDueDateJSON(t *testing.T) {
+	var goal Goal
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"Read","due_date":"2026-12-31"}`), &goal))
+	assert.True(t, goal.DueAllDay)
+	assert.Equal(t, time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), goal.DueDate)
+
+	data, err := json.Marshal(goal)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"due_date":"2026-12-31"`)
+
+	var roundTrip Goal
+	assert.NoError(t, json.Unmarshal(data, &roundTrip))
+	assert.True(t, roundTrip.DueAllDay)
+	assert.Equal(t, goal.DueDate, roundTrip.DueDate)
+
+	var timed Goal
+	assert.NoError(t, json.Unmarshal([]byte(`{"due_date":"2026-12-31T18:00:00+05:00","due_all_day":true}`), &timed))
+	assert.False(t, timed.DueAllDay)
+	assert.True(t, timed.DueDate.Equal(time.Date(2026, 12, 31, 13, 0, 0, 0, time.UTC)))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"due_date":"31.12.2026"}`), &timed))
+}
+
+// TestGoalDeadline tests that all-day goals are due until the end of the day in the
+// owner's timezone.
+func TestGoalDeadline(t *testing.T) {
+	almaty, err := time.LoadLocation("Asia/Almaty")
+	assert.NoError(t, err)
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	assert.NoError(t, err)
+
+	goal := Goal{DueDate: time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), DueAllDay: true}
+	assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, almaty), goal.Deadline(almaty))
+
+	// 20:00 UTC on the due day is already the next day in Almaty, but not in Los Angeles
+	now := time.Date(2026, 12, 31, 20, 0, 0, 0, time.UTC)
+	assert.True(t, goal.IsOverdue(now, almaty))
+	assert.False(t, goal.IsOverdue(now, losAngeles))
+
+	timed := Goal{DueDate: time.Date(2026, 12, 31, 12, 0, 0, 0, time.UTC)}
+	assert.True(t, timed.IsOverdue(now, losAngeles))
+	assert.False(t, (&Goal{}).IsOverdue(now, almaty))
+}
+
+// TestGoalIn tests that timestamps are converted to a zone while all-day dates are kept.
+func TestGoalIn(t *testing.T) {
+	almaty, err := time.LoadLocation("Asia/Almaty")
+	assert.NoError(t, err)
+
+	created := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	goal := Goal{CreatedAt: created, DueDate: time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), DueAllDay: true}
+
+	local := goal.In(almaty)
+	assert.Equal(t, almaty, local.CreatedAt.Location())
+	assert.True(t, local.CreatedAt.Equal(created))
+	assert.Equal(t, time.UTC, local.DueDate.Location())
+	assert.True(t, local.UpdatedAt.IsZero())
+	assert.Equal(t, time.UTC, goal.CreatedAt.Location())
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FieldChange describes how a single goal field changed between two revisions.
+type FieldChange struct {
+	Field string      `bson:"field" json:"field"`
+	From  interface{} `bson:"from" json:"from"`
+	To    interface{} `bson:"to" json:"to"`
+}
+
+// GoalHistoryEntry is an append-only record of a goal mutation.
+type GoalHistoryEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GoalID    primitive.ObjectID `bson:"goal_id" json:"goal_id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ActorID   primitive.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	Revision  int                `bson:"revision" json:"revision"`
+	Event     string             `bson:"event" json:"event"`
+	Changes   []FieldChange      `bson:"changes" json:"changes"`
+	Snapshot  *Goal              `bson:"snapshot,omitempty" json:"snapshot,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GoalNote is a journal entry on a goal, or on one of its steps when Step is set.
+type GoalNote struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GoalID primitive.ObjectID `bson:"goal_id" json:"goal_id"`
+	UserID primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Step   string             `bson:"step,omitempty" json:"step,omitempty"`
+	// Body is Markdown, sanitized before it is stored
+	Body string `bson:"body" json:"body"`
+	// Mood is an optional rating from 1 (bad) to 5 (great)
+	Mood      int       `bson:"mood,omitempty" json:"mood,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification types
+const (
+	NotificationAchievementUnlocked = "achievement.unlocked"
+	NotificationLevelUp             = "level.up"
+)
+
+// Notification is an in-app message to a user.
+type Notification struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID primitive.ObjectID `bson:"user_id" json:"-"`
+	Type   string             `bson:"type" json:"type"`
+	Title  string             `bson:"title" json:"title"`
+	Body   string             `bson:"body,omitempty" json:"body,omitempty"`
+	// Data holds identifiers the client can link to, such as an achievement ID
+	Data      map[string]string `bson:"data,omitempty" json:"data,omitempty"`
+	ReadAt    *time.Time        `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	CreatedAt time.Time         `bson:"created_at" json:"created_at"`
+}
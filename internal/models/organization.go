@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Organization roles. Admins manage the organization, its members and its goals;
+// members work on the organization's goals.
+const (
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// OrgRoles lists the roles a member of an organization can have.
+var OrgRoles = map[string]bool{
+	OrgRoleAdmin:  true,
+	OrgRoleMember: true,
+}
+
+// NotificationOrgInvitation is the notification type of invitations to an organization.
+const NotificationOrgInvitation = "org.invitation"
+
+// Organization groups users working on team goals. Goals created while acting within an
+// organization belong to it; personal goals are never visible to it.
+type Organization struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	CreatedBy primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// OrgMember invites a user, by email, to an organization. Like goal shares, it goes
+// through the pending, accepted and declined statuses, and the role only applies once
+// the invitation is accepted.
+type OrgMember struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrgID  primitive.ObjectID `bson:"org_id" json:"org_id"`
+	Email  string             `bson:"email" json:"email"`
+	UserID primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Role   string             `bson:"role" json:"role"`
+	Status string             `bson:"status" json:"status"`
+	// InvitedBy is empty for the user who created the organization
+	InvitedBy   primitive.ObjectID `bson:"invited_by,omitempty" json:"invited_by,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	RespondedAt *time.Time         `bson:"responded_at,omitempty" json:"responded_at,omitempty"`
+}
+
+// UserOrg is an organization a user belongs to, with the user's role in it.
+type UserOrg struct {
+	Organization Organization `json:"organization"`
+	Role         string       `json:"role"`
+}
+
+// MemberContribution sums up what a member did on the goals of an organization.
+type MemberContribution struct {
+	UserID         primitive.ObjectID `json:"user_id"`
+	Username       string             `json:"username"`
+	Role           string             `json:"role"`
+	GoalsCreated   int                `json:"goals_created"`
+	GoalsCompleted int                `json:"goals_completed"`
+	StepsCompleted int                `json:"steps_completed"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification types for accountability partners
+const (
+	NotificationPartnerRequest  = "partner.request"
+	NotificationPartnerAccepted = "partner.accepted"
+	NotificationPartnerNudge    = "partner.nudge"
+	NotificationPartnerSummary  = "partner.summary"
+)
+
+// PartnerLink pairs two users as accountability partners. Once accepted, each partner
+// can follow the progress of the other's personal goals, except those hidden from partners.
+// Like goal shares, it goes through the pending, accepted and declined statuses.
+type PartnerLink struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RequesterID primitive.ObjectID `bson:"requester_id" json:"requester_id"`
+	PartnerID   primitive.ObjectID `bson:"partner_id" json:"partner_id"`
+	// Pair identifies the two users whoever asked, so that they are linked once
+	Pair        string     `bson:"pair" json:"-"`
+	Status      string     `bson:"status" json:"status"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	RespondedAt *time.Time `bson:"responded_at,omitempty" json:"responded_at,omitempty"`
+	// NudgedAt holds when each partner, by user ID, last nudged the other
+	NudgedAt map[string]time.Time `bson:"nudged_at,omitempty" json:"-"`
+	// SummarizedAt is when the partners were last sent their weekly summaries
+	SummarizedAt *time.Time `bson:"summarized_at,omitempty" json:"-"`
+}
+
+// Other returns the user linked with userID.
+func (l *PartnerLink) Other(userID primitive.ObjectID) primitive.ObjectID {
+	if l.RequesterID == userID {
+		return l.PartnerID
+	}
+	return l.RequesterID
+}
+
+// Partner is a user the caller is linked with.
+type Partner struct {
+	LinkID   primitive.ObjectID `json:"link_id"`
+	UserID   primitive.ObjectID `json:"user_id"`
+	Username string             `json:"username"`
+	Since    *time.Time         `json:"since,omitempty"`
+}
+
+// PartnerSummary sums up what a user did on the goals their partners can see over a period.
+type PartnerSummary struct {
+	UserID         primitive.ObjectID    `json:"user_id"`
+	Username       string                `json:"username"`
+	From           time.Time             `json:"from"`
+	To             time.Time             `json:"to"`
+	StepsCompleted int                   `json:"steps_completed"`
+	GoalsCompleted int                   `json:"goals_completed"`
+	ActiveGoals    int                   `json:"active_goals"`
+	Goals          []PartnerGoalProgress `json:"goals"`
+}
+
+// PartnerGoalProgress is the progress of one goal in a partner summary.
+type PartnerGoalProgress struct {
+	GoalID         primitive.ObjectID `json:"goal_id"`
+	Name           string             `json:"name"`
+	Status         string             `json:"status"`
+	Steps          int                `json:"steps"`
+	CompletedSteps int                `json:"completed_steps"`
+	StepsInPeriod  int                `json:"steps_in_period"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PublicLink lets anyone holding its token view a goal without an account, until it
+// expires or is revoked. A link may also require a password, stored hashed.
+type PublicLink struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GoalID       primitive.ObjectID `bson:"goal_id" json:"goal_id"`
+	CreatedBy    primitive.ObjectID `bson:"created_by" json:"created_by"`
+	Token        string             `bson:"token" json:"token"`
+	PasswordHash string             `bson:"password_hash,omitempty" json:"-"`
+	// Protected tells whether viewing the goal requires the password
+	Protected    bool       `bson:"protected" json:"protected"`
+	ExpiresAt    *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	Views        int64      `bson:"views" json:"views"`
+	LastViewedAt *time.Time `bson:"last_viewed_at,omitempty" json:"last_viewed_at,omitempty"`
+	RevokedAt    *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `bson:"created_at" json:"created_at"`
+}
+
+// Expired tells whether the link has expired at now.
+func (l *PublicLink) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// PublicGoal is the view of a goal shown through a public link. It leaves out the IDs
+// of the goal and its collaborators, the owner's contact details, notes and attachments.
+type PublicGoal struct {
+	Name           string       `json:"name"`
+	Description    string       `json:"description,omitempty"`
+	Category       string       `json:"category,omitempty"`
+	Tags           []string     `json:"tags,omitempty"`
+	Status         string       `json:"status"`
+	Owner          string       `json:"owner"`
+	Steps          []PublicStep `json:"steps"`
+	CompletedSteps int          `json:"completed_steps"`
+	Percent        float64      `json:"percent"`
+	DueDate        *time.Time   `json:"due_date,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	CompletedAt    *time.Time   `json:"completed_at,omitempty"`
+}
+
+// PublicStep is a step of a goal shown through a public link.
+type PublicStep struct {
+	Name        string     `json:"name"`
+	Done        bool       `json:"done"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Week start days
+const (
+	WeekStartMonday   = "monday"
+	WeekStartSunday   = "sunday"
+	WeekStartSaturday = "saturday"
+)
+
+// Notification channels
+const (
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+)
+
+// Themes
+const (
+	ThemeSystem = "system"
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+)
+
+// UserSettings holds the preferences of a user. Settings the user never changed keep
+// their defaults; the document is keyed by the user's ID.
+type UserSettings struct {
+	UserID primitive.ObjectID `bson:"_id" json:"-"`
+	// DefaultCategory is assigned to new goals created without a category
+	DefaultCategory string `bson:"default_category" json:"default_category"`
+	// DefaultSort orders goal listings that don't ask for an order: "" or "priority"
+	DefaultSort string `bson:"default_sort" json:"default_sort"`
+	// WeekStart is the first day of the week in calendars and weekly statistics
+	WeekStart string `bson:"week_start" json:"week_start"`
+	// ReminderLeadHours is how long before a due date reminders are sent
+	ReminderLeadHours int `bson:"reminder_lead_hours" json:"reminder_lead_hours"`
+	// NotificationChannels are where notifications are delivered
+	NotificationChannels []string `bson:"notification_channels" json:"notification_channels"`
+	// AutoArchiveDays archives goals that many days after completion; zero never does
+	AutoArchiveDays int `bson:"auto_archive_days" json:"auto_archive_days"`
+	// Theme is the preferred look of the frontend
+	Theme     string    `bson:"theme" json:"theme"`
+	UpdatedAt time.Time `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// DefaultSettings returns the settings of a user who hasn't changed any.
+func DefaultSettings() UserSettings {
+	return UserSettings{
+		WeekStart:            WeekStartMonday,
+		ReminderLeadHours:    24,
+		NotificationChannels: []string{ChannelInApp},
+		Theme:                ThemeSystem,
+	}
+}
+
+// FirstWeekday returns WeekStart as a time.Weekday.
+func (s UserSettings) FirstWeekday() time.Weekday {
+	switch s.WeekStart {
+	case WeekStartSunday:
+		return time.Sunday
+	case WeekStartSaturday:
+		return time.Saturday
+	default:
+		return time.Monday
+	}
+}
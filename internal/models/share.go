@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Goal access roles, from least to most privileged. The user who created a goal is
+// always an owner; shares grant any of the roles to other users.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+// ShareRoles lists the roles a goal can be shared with.
+var ShareRoles = map[string]bool{
+	RoleViewer: true,
+	RoleEditor: true,
+	RoleOwner:  true,
+}
+
+// Share statuses
+const (
+	SharePending  = "pending"
+	ShareAccepted = "accepted"
+	ShareDeclined = "declined"
+)
+
+// Notification types for sharing
+const (
+	NotificationShareInvitation = "share.invitation"
+	NotificationShareAccepted   = "share.accepted"
+)
+
+// GoalShare invites a user, by email, to collaborate on a goal. The role only applies
+// once the invitation is accepted.
+type GoalShare struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GoalID  primitive.ObjectID `bson:"goal_id" json:"goal_id"`
+	OwnerID primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	// Email is where the invitation was sent, lowercased
+	Email string `bson:"email" json:"email"`
+	// UserID is the invited user, known once an account with the email exists
+	UserID      primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Role        string             `bson:"role" json:"role"`
+	Status      string             `bson:"status" json:"status"`
+	InvitedBy   primitive.ObjectID `bson:"invited_by" json:"invited_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	RespondedAt *time.Time         `bson:"responded_at,omitempty" json:"responded_at,omitempty"`
+}
+
+// SharedGoal is a goal another user shared with the caller, with the caller's role.
+type SharedGoal struct {
+	Goal Goal   `json:"goal"`
+	Role string `json:"role"`
+}
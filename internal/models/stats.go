@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// GoalStats summarizes a user's goals over a date range.
+type GoalStats struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Goals counts the goals created in the range; ByStatus and ByCategory break it down
+	Goals      int            `json:"goals"`
+	ByStatus   map[string]int `json:"by_status"`
+	ByCategory map[string]int `json:"by_category"`
+	// CompletionRate is the share of the goals created in the range that are completed
+	CompletionRate float64 `json:"completion_rate"`
+	// AverageDaysToComplete is the mean time from creation to completion of the goals
+	// completed in the range
+	AverageDaysToComplete float64 `json:"average_days_to_complete"`
+	// OverdueRate is the share of the goals due in the range that missed their deadline
+	OverdueRate float64 `json:"overdue_rate"`
+	// Weeks holds the activity of every week overlapping the range, oldest first
+	Weeks []WeekStats `json:"weeks"`
+	// Weekdays holds the completions per day of the week, most productive first
+	Weekdays    []WeekdayStats `json:"weekdays"`
+	GeneratedAt time.Time      `json:"generated_at"`
+}
+
+// WeekStats is the activity of a single week, which starts on the user's first day of the week.
+type WeekStats struct {
+	Start          time.Time `json:"start"`
+	GoalsCreated   int       `json:"goals_created"`
+	GoalsCompleted int       `json:"goals_completed"`
+	StepsCompleted int       `json:"steps_completed"`
+}
+
+// WeekdayStats counts the goals and steps completed on a day of the week.
+type WeekdayStats struct {
+	Weekday        string `json:"weekday"`
+	GoalsCompleted int    `json:"goals_completed"`
+	StepsCompleted int    `json:"steps_completed"`
+}
+
+// ActivityCalendar is a user's daily activity over the last days, for a contribution calendar.
+type ActivityCalendar struct {
+	// From and To are the first and the last day, in the user's timezone
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Timezone string `json:"timezone"`
+	// Days holds every day of the range, oldest first
+	Days []ActivityDay `json:"days"`
+	// ActiveDays counts the days with any activity
+	ActiveDays int `json:"active_days"`
+	// CurrentStreak counts the consecutive active days up to today, or up to yesterday
+	// while today has no activity yet
+	CurrentStreak int `json:"current_streak"`
+	// LongestStreak is the longest run of consecutive active days in the range
+	LongestStreak int `json:"longest_streak"`
+}
+
+// ActivityDay counts what a user did on a single day. Check-ins are journal notes.
+type ActivityDay struct {
+	Date           string `json:"date"`
+	StepsCompleted int    `json:"steps_completed"`
+	CheckIns       int    `json:"check_ins"`
+	Total          int    `json:"total"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tag holds the display settings of one of a user's tags. Goals reference tags by
+// name in Goal.Tags, so a tag exists as soon as a goal uses it.
+type Tag struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name      string             `bson:"name" json:"name"`
+	Color     string             `bson:"color,omitempty" json:"color,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// TagUsage is a tag together with the number of goals using it.
+type TagUsage struct {
+	Name  string `bson:"_id" json:"name"`
+	Color string `bson:"-" json:"color,omitempty"`
+	Count int    `bson:"count" json:"count"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TemplateStep is a step of a goal template, due a number of days after the goal starts.
+type TemplateStep struct {
+	Name          string `bson:"name" json:"name"`
+	DueOffsetDays int    `bson:"due_offset_days,omitempty" json:"due_offset_days,omitempty"`
+}
+
+// GoalTemplate is a reusable blueprint for creating goals. System templates are
+// built in and visible to everyone; other templates are private to their owner.
+type GoalTemplate struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	System        bool               `bson:"system" json:"system"`
+	Slug          string             `bson:"slug,omitempty" json:"slug,omitempty"`
+	Name          string             `bson:"name" json:"name"`
+	Category      string             `bson:"category,omitempty" json:"category,omitempty"`
+	Description   string             `bson:"description" json:"description"`
+	Steps         []TemplateStep     `bson:"steps" json:"steps"`
+	DueOffsetDays int                `bson:"due_offset_days,omitempty" json:"due_offset_days,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
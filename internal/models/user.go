@@ -6,12 +6,101 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// User represents a user account in the Achievement Manager system.
+// User represents a user account in the Achievement Manager system. It is never
+// serialized in responses as is; handlers return its Profile or PublicProfile instead.
 type User struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty"`
 	Username       string             `bson:"username"`
 	Email          string             `bson:"email"`
-	HashedPassword string             `bson:"hashed_password"`
+	HashedPassword string             `bson:"hashed_password" json:"-"`
+	DisplayName    string             `bson:"display_name,omitempty"`
+	Bio            string             `bson:"bio,omitempty"`
+	Locale         string             `bson:"locale,omitempty"`
+	Timezone       string             `bson:"timezone,omitempty"`
+	Avatar         *Avatar            `bson:"avatar,omitempty"`
 	CreatedAt      time.Time          `bson:"created_at"`
 	UpdatedAt      time.Time          `bson:"updated_at"`
 }
+
+// Avatar identifies the current avatar of a user. Every upload gets a new ID, so the
+// images of an avatar never change and can be cached indefinitely.
+type Avatar struct {
+	ID        primitive.ObjectID `bson:"id" json:"id"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// AvatarSize is one of the sizes avatars are rendered in.
+type AvatarSize struct {
+	Name   string
+	Pixels int
+}
+
+// AvatarSizes are the square sizes every avatar is stored in, smallest first.
+var AvatarSizes = []AvatarSize{
+	{Name: "small", Pixels: 64},
+	{Name: "medium", Pixels: 128},
+	{Name: "large", Pixels: 256},
+}
+
+// URLs returns the path of the avatar image in each size, keyed by size name.
+func (a *Avatar) URLs() map[string]string {
+	if a == nil {
+		return nil
+	}
+	urls := make(map[string]string, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		urls[size.Name] = "/avatars/" + a.ID.Hex() + "/" + size.Name
+	}
+	return urls
+}
+
+// UserProfile is the view of a user returned to the user themselves.
+type UserProfile struct {
+	ID          primitive.ObjectID `json:"id"`
+	Username    string             `json:"username"`
+	Email       string             `json:"email"`
+	DisplayName string             `json:"display_name,omitempty"`
+	Bio         string             `json:"bio,omitempty"`
+	Locale      string             `json:"locale,omitempty"`
+	Timezone    string             `json:"timezone,omitempty"`
+	Avatar      map[string]string  `json:"avatar,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// PublicProfile is the view of a user shown to other users, without contact details
+// or account settings.
+type PublicProfile struct {
+	ID          primitive.ObjectID `json:"id"`
+	Username    string             `json:"username"`
+	DisplayName string             `json:"display_name,omitempty"`
+	Bio         string             `json:"bio,omitempty"`
+	Avatar      map[string]string  `json:"avatar,omitempty"`
+}
+
+// Profile returns the view of the user meant for the user themselves.
+func (u *User) Profile() UserProfile {
+	return UserProfile{
+		ID:          u.ID,
+		Username:    u.Username,
+		Email:       u.Email,
+		DisplayName: u.DisplayName,
+		Bio:         u.Bio,
+		Locale:      u.Locale,
+		Timezone:    u.Timezone,
+		Avatar:      u.Avatar.URLs(),
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+	}
+}
+
+// PublicProfile returns the view of the user meant for other users.
+func (u *User) PublicProfile() PublicProfile {
+	return PublicProfile{
+		ID:          u.ID,
+		Username:    u.Username,
+		DisplayName: u.DisplayName,
+		Bio:         u.Bio,
+		Avatar:      u.Avatar.URLs(),
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook is a user-registered endpoint that receives goal events.
+type Webhook struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	URL       string             `bson:"url" json:"url"`
+	Secret    string             `bson:"secret" json:"secret,omitempty"`
+	Events    []string           `bson:"events" json:"events"`
+	Active    bool               `bson:"active" json:"active"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// WebhookDelivery records a single attempt to deliver an event to a webhook.
+type WebhookDelivery struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WebhookID    primitive.ObjectID `bson:"webhook_id" json:"webhook_id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"user_id"`
+	EventID      primitive.ObjectID `bson:"event_id" json:"event_id"`
+	Event        string             `bson:"event" json:"event"`
+	Payload      string             `bson:"payload" json:"payload"`
+	Attempt      int                `bson:"attempt" json:"attempt"`
+	StatusCode   int                `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	ResponseBody string             `bson:"response_body,omitempty" json:"response_body,omitempty"`
+	Error        string             `bson:"error,omitempty" json:"error,omitempty"`
+	Success      bool               `bson:"success" json:"success"`
+	DurationMS   int64              `bson:"duration_ms" json:"duration_ms"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+}
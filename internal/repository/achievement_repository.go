@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AchievementRepository handles database operations related to achievements and XP.
+// Unique indexes make unlocking an achievement and awarding XP idempotent.
+type AchievementRepository struct {
+	achievements *mongo.Collection
+	awards       *mongo.Collection
+}
+
+// NewAchievementRepository creates a new instance of AchievementRepository.
+func NewAchievementRepository(db *mongo.Database) *AchievementRepository {
+	return &AchievementRepository{
+		achievements: db.Collection("user_achievements"),
+		awards:       db.Collection("xp_awards"),
+	}
+}
+
+// EnsureIndexes creates the unique indexes that keep achievements and XP from being
+// awarded twice.
+func (r *AchievementRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.achievements.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "achievement_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create achievement index: %v", err)
+	}
+	_, err = r.awards.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create XP award index: %v", err)
+	}
+	return nil
+}
+
+// Unlock records an achievement for a user and reports whether it was newly unlocked.
+func (r *AchievementRepository) Unlock(ctx context.Context, achievement *models.UserAchievement) (bool, error) {
+	achievement.UnlockedAt = time.Now()
+	_, err := r.achievements.InsertOne(ctx, achievement)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to insert achievement: %v", err)
+	}
+	return true, nil
+}
+
+// GetAchievements lists the achievements a user unlocked, oldest first.
+func (r *AchievementRepository) GetAchievements(ctx context.Context, userID primitive.ObjectID) ([]models.UserAchievement, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "unlocked_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.achievements.Find(ctx, bson.M{"user_id": userID}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find achievements: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	achievements := []models.UserAchievement{}
+	if err := cursor.All(ctx, &achievements); err != nil {
+		return nil, fmt.Errorf("failed to decode achievements: %v", err)
+	}
+	return achievements, nil
+}
+
+// AwardXP records XP earned by a user and reports whether it was newly awarded; an
+// award with the same key is only ever counted once.
+func (r *AchievementRepository) AwardXP(ctx context.Context, award *models.XPAward) (bool, error) {
+	award.CreatedAt = time.Now()
+	_, err := r.awards.InsertOne(ctx, award)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to insert XP award: %v", err)
+	}
+	return true, nil
+}
+
+// TotalXP sums the XP a user earned.
+func (r *AchievementRepository) TotalXP(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$xp"}}}},
+	}
+	return r.sum(ctx, r.awards, pipeline)
+}
+
+// sum runs a pipeline grouping everything into a single total.
+func (r *AchievementRepository) sum(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline) (int, error) {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("failed to aggregate: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total int `bson:"total"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, fmt.Errorf("failed to decode total: %v", err)
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Total, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AttachmentRepository handles database operations related to goal attachments.
+type AttachmentRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAttachmentRepository creates a new instance of AttachmentRepository.
+func NewAttachmentRepository(db *mongo.Database) *AttachmentRepository {
+	return &AttachmentRepository{
+		collection: db.Collection("attachments"),
+	}
+}
+
+// CreateAttachment inserts a new attachment into the database.
+func (r *AttachmentRepository) CreateAttachment(ctx context.Context, attachment *models.Attachment) (*models.Attachment, error) {
+	attachment.CreatedAt = time.Now()
+
+	if _, err := r.collection.InsertOne(ctx, attachment); err != nil {
+		return nil, fmt.Errorf("failed to insert attachment: %v", err)
+	}
+	return attachment, nil
+}
+
+// GetAttachmentByID fetches an attachment by its ID.
+func (r *AttachmentRepository) GetAttachmentByID(ctx context.Context, id primitive.ObjectID) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&attachment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find attachment by id: %v", err)
+	}
+	return &attachment, nil
+}
+
+// GetAttachmentsByGoal fetches the attachments of a goal, newest first.
+func (r *AttachmentRepository) GetAttachmentsByGoal(ctx context.Context, goalID primitive.ObjectID) ([]models.Attachment, error) {
+	attachments := []models.Attachment{}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"goal_id": goalID}, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attachments: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &attachments); err != nil {
+		return nil, fmt.Errorf("failed to decode attachments: %v", err)
+	}
+	return attachments, nil
+}
+
+// GetStorageUsed sums the size of every attachment of a user.
+func (r *AttachmentRepository) GetStorageUsed(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "used": bson.M{"$sum": "$size"}}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum attachment sizes: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Used int64 `bson:"used"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return 0, fmt.Errorf("failed to decode attachment sizes: %v", err)
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return result[0].Used, nil
+}
+
+// DeleteAttachment removes an attachment from the database.
+func (r *AttachmentRepository) DeleteAttachment(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %v", err)
+	}
+	return nil
+}
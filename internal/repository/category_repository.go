@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CategoryRepository handles database operations related to user-defined categories.
+type CategoryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewCategoryRepository creates a new instance of CategoryRepository.
+func NewCategoryRepository(db *mongo.Database) *CategoryRepository {
+	return &CategoryRepository{
+		collection: db.Collection("categories"),
+	}
+}
+
+// CreateCategory inserts a new category into the database.
+func (r *CategoryRepository) CreateCategory(ctx context.Context, category *models.Category) (*models.Category, error) {
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert category: %v", err)
+	}
+
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast inserted ID")
+	}
+	category.ID = insertedID
+
+	return category, nil
+}
+
+// GetCategoryByID fetches a category by its ID.
+func (r *CategoryRepository) GetCategoryByID(ctx context.Context, id primitive.ObjectID) (*models.Category, error) {
+	var category models.Category
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find category by id: %v", err)
+	}
+	return &category, nil
+}
+
+// GetCategories fetches the categories created by a user.
+func (r *CategoryRepository) GetCategories(ctx context.Context, userID primitive.ObjectID) ([]models.Category, error) {
+	categories := []models.Category{}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, fmt.Errorf("failed to decode categories: %v", err)
+	}
+	return categories, nil
+}
+
+// UpdateCategory updates the name and color of a category.
+func (r *CategoryRepository) UpdateCategory(ctx context.Context, id primitive.ObjectID, category *models.Category) (*models.Category, error) {
+	category.UpdatedAt = time.Now()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"name":       category.Name,
+			"color":      category.Color,
+			"updated_at": category.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update category: %v", err)
+	}
+	return category, nil
+}
+
+// DeleteCategory removes a category from the database.
+func (r *CategoryRepository) DeleteCategory(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %v", err)
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/czeful/diplom_back/internal/models"
@@ -12,6 +14,69 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// AnyVersion disables the version check of conditional updates
+const AnyVersion int64 = -1
+
+var (
+	// ErrVersionConflict is returned when a goal was modified since it was read
+	ErrVersionConflict = errors.New("goal was modified concurrently")
+	// ErrNoMatch is returned when no live goal matches a conditional update
+	ErrNoMatch = errors.New("no matching goal")
+	// ErrTransactionsUnsupported is returned when an atomic bulk write is requested
+	// from a deployment without transaction support, such as a standalone server
+	ErrTransactionsUnsupported = errors.New("transactions are not supported by the database")
+	// ErrBulkIncomplete aborts an atomic bulk write in which some goals could not be written
+	ErrBulkIncomplete = errors.New("bulk write did not apply to every goal")
+)
+
+// GoalWrite is a conditional partial update of a single goal, used by BulkUpdateGoals
+type GoalWrite struct {
+	ID              primitive.ObjectID
+	ExpectedVersion int64
+	Set             bson.M
+	Unset           []string
+}
+
+// Values of GoalFilter.Archived
+const (
+	ArchivedExclude = "false"
+	ArchivedOnly    = "true"
+	ArchivedAll     = "all"
+)
+
+// SortPriority orders goals from the highest priority down, goals without one last
+const SortPriority = "priority"
+
+// GoalFilter narrows down the goals returned by GetGoals
+type GoalFilter struct {
+	UserID   primitive.ObjectID
+	Category string
+	// Tags limits the result to goals carrying every one of the tags
+	Tags []string
+	// Archived is one of ArchivedExclude (the default), ArchivedOnly or ArchivedAll
+	Archived string
+	// Sort is empty for the natural order or SortPriority
+	Sort string
+	// Forecast keeps only the goals with this forecast status; the service applies it
+	Forecast string
+	// OrgID lists the goals of an organization, whoever created them, instead of the
+	// user's personal goals
+	OrgID *primitive.ObjectID
+}
+
+// GoalCounts holds how many goals a user created and completed.
+type GoalCounts struct {
+	UserID    primitive.ObjectID `bson:"_id"`
+	Created   int                `bson:"created"`
+	Completed int                `bson:"completed"`
+}
+
+// StepCount holds how many steps a user completed.
+type StepCount struct {
+	UserID primitive.ObjectID `bson:"_id"`
+	Count  int                `bson:"count"`
+}
+
 // GoalRepository struct handles database operations related to goals
 type GoalRepository struct {
 	collection *mongo.Collection
@@ -24,10 +89,23 @@ func NewGoalRepository(db *mongo.Database) *GoalRepository {
 	}
 }
 
+// EnsureIndexes creates the index used to list the goals of an organization.
+func (r *GoalRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "org_id", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create goal index: %v", err)
+	}
+	return nil
+}
+
 // CreateGoal creates a new goal in the database
 func (r *GoalRepository) CreateGoal(ctx context.Context, goal *models.Goal) (*models.Goal, error) {
 	goal.CreatedAt = time.Now()
 	goal.UpdatedAt = time.Now()
+	goal.Version = 1
 
 	result, err := r.collection.InsertOne(ctx, goal)
 	if err != nil {
@@ -44,12 +122,12 @@ func (r *GoalRepository) CreateGoal(ctx context.Context, goal *models.Goal) (*mo
 	return goal, nil
 }
 
-// GetGoalByID fetches a goal by its ID
+// GetGoalByID fetches a goal by its ID, ignoring goals in the trash
 func (r *GoalRepository) GetGoalByID(ctx context.Context, id primitive.ObjectID) (*models.Goal, error) {
 	var goal models.Goal
 
 	// Find the goal by its ID
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&goal)
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&goal)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find goal by id: %v", err)
 	}
@@ -57,21 +135,380 @@ func (r *GoalRepository) GetGoalByID(ctx context.Context, id primitive.ObjectID)
 	return &goal, nil
 }
 
-// UpdateGoal updates an existing goal in the database
-func (r *GoalRepository) UpdateGoal(ctx context.Context, id primitive.ObjectID, goal *models.Goal) (*models.Goal, error) {
-	goal.UpdatedAt = time.Now()
+// UpdateGoalFields sets and unsets individual fields of a goal if it is still at
+// expectedVersion, leaving every other field untouched.
+func (r *GoalRepository) UpdateGoalFields(ctx context.Context, id primitive.ObjectID, set bson.M, unset []string, expectedVersion int64) (*models.Goal, error) {
+	fields := bson.M{"updated_at": time.Now()}
+	for field, value := range set {
+		fields[field] = value
+	}
+	update := bson.M{"$set": fields, "$inc": bson.M{"version": 1}}
+	if len(unset) > 0 {
+		unsetFields := bson.M{}
+		for _, field := range unset {
+			unsetFields[field] = ""
+		}
+		update["$unset"] = unsetFields
+	}
 
-	// Update the goal in the database
-	_, err := r.collection.UpdateOne(
-		ctx,
-		bson.M{"_id": id},
-		bson.M{"$set": goal},
+	return r.findOneAndUpdate(ctx, versionFilter(id, expectedVersion), update)
+}
+
+// GetGoalsByIDs fetches the live personal goals of a user among the given IDs
+func (r *GoalRepository) GetGoalsByIDs(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) ([]models.Goal, error) {
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}, "user_id": userID, "org_id": nil, "deleted_at": nil})
+}
+
+// GetLiveGoals fetches the live goals among the given IDs, whoever owns them
+func (r *GoalRepository) GetLiveGoals(ctx context.Context, ids []primitive.ObjectID) ([]models.Goal, error) {
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}, "deleted_at": nil})
+}
+
+// GetSubGoals fetches the live goals directly below a parent goal
+func (r *GoalRepository) GetSubGoals(ctx context.Context, parentID primitive.ObjectID) ([]models.Goal, error) {
+	return r.find(ctx, bson.M{"parent_id": parentID, "deleted_at": nil})
+}
+
+// GetGoalsWithTags fetches the live personal goals of a user carrying any of the tags
+func (r *GoalRepository) GetGoalsWithTags(ctx context.Context, userID primitive.ObjectID, tags []string) ([]models.Goal, error) {
+	return r.find(ctx, bson.M{"user_id": userID, "org_id": nil, "tags": bson.M{"$in": tags}, "deleted_at": nil})
+}
+
+// GetGoalsInCategory fetches the live personal goals of a user in a category
+func (r *GoalRepository) GetGoalsInCategory(ctx context.Context, userID primitive.ObjectID, category string) ([]models.Goal, error) {
+	return r.find(ctx, bson.M{"user_id": userID, "org_id": nil, "category": category, "deleted_at": nil})
+}
+
+// CountGoalsInCategory counts the goals of a user in a category, including those in the trash
+func (r *GoalRepository) CountGoalsInCategory(ctx context.Context, userID primitive.ObjectID, category string) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userID, "category": category})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count goals: %v", err)
+	}
+	return count, nil
+}
+
+// CountTags returns every tag used by the live personal goals of a user with the number
+// of goals using it, most used first
+func (r *GoalRepository) CountTags(ctx context.Context, userID primitive.ObjectID) ([]models.TagUsage, error) {
+	usage := []models.TagUsage{}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "org_id": nil, "deleted_at": nil}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tags: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &usage); err != nil {
+		return nil, fmt.Errorf("failed to decode tag counts: %v", err)
+	}
+	return usage, nil
+}
+
+// CountGoals counts a user's goals, only the completed ones if completed is set and only
+// those of category if it isn't empty. Deleted goals don't count.
+func (r *GoalRepository) CountGoals(ctx context.Context, userID primitive.ObjectID, category string, completed bool) (int, error) {
+	filter := bson.M{"user_id": userID, "deleted_at": nil}
+	if category != "" {
+		filter["category"] = category
+	}
+	if completed {
+		filter["status"] = "completed"
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count goals: %v", err)
+	}
+	return int(count), nil
+}
+
+// CountCompletedSteps counts the steps checked across a user's goals.
+func (r *GoalRepository) CountCompletedSteps(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "deleted_at": nil}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": bson.M{"$size": bson.M{"$filter": bson.M{
+			"input": bson.M{"$ifNull": bson.A{bson.M{"$objectToArray": "$progress"}, bson.A{}}},
+			"cond":  bson.M{"$eq": bson.A{"$$this.v", true}},
+		}}}}}}},
+	}
+	var results []struct {
+		Total int `bson:"total"`
+	}
+	if err := r.aggregate(ctx, pipeline, &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Total, nil
+}
+
+// CountGoalsByCreator counts the live goals of an organization each user created and completed.
+func (r *GoalRepository) CountGoalsByCreator(ctx context.Context, orgID primitive.ObjectID) ([]GoalCounts, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"org_id": orgID, "deleted_at": nil}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$user_id",
+			"created": bson.M{"$sum": 1},
+			"completed": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$status", "completed"}}, 1, 0,
+			}}},
+		}}},
+	}
+	var counts []GoalCounts
+	if err := r.aggregate(ctx, pipeline, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// CountStepsByCompleter counts the steps of the live goals of an organization each user
+// completed. Steps completed before completions were attributed aren't counted.
+func (r *GoalRepository) CountStepsByCompleter(ctx context.Context, orgID primitive.ObjectID) ([]StepCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"org_id": orgID, "deleted_at": nil, "step_meta": bson.M{"$exists": true}}}},
+		{{Key: "$project", Value: bson.M{"meta": bson.M{"$objectToArray": "$step_meta"}}}},
+		{{Key: "$unwind", Value: "$meta"}},
+		{{Key: "$match", Value: bson.M{"meta.v.completed_by": bson.M{"$exists": true}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$meta.v.completed_by", "count": bson.M{"$sum": 1}}}},
+	}
+	var counts []StepCount
+	if err := r.aggregate(ctx, pipeline, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// ReleaseOrgGoals turns every goal of an organization, trashed ones included, into a
+// personal goal of the user who created it.
+func (r *GoalRepository) ReleaseOrgGoals(ctx context.Context, orgID primitive.ObjectID) error {
+	if _, err := r.collection.UpdateMany(ctx,
+		bson.M{"org_id": orgID},
+		bson.M{"$unset": bson.M{"org_id": ""}, "$set": bson.M{"updated_at": time.Now()}, "$inc": bson.M{"version": 1}},
+	); err != nil {
+		return fmt.Errorf("failed to release organization goals: %v", err)
+	}
+	return nil
+}
+
+// BulkUpdateGoals applies the writes, inside a transaction when the deployment supports one.
+// It returns the IDs of the goals that were written and whether a transaction was used.
+// With atomic set, either every write applies or none does.
+func (r *GoalRepository) BulkUpdateGoals(ctx context.Context, writes []GoalWrite, atomic bool) (map[primitive.ObjectID]bool, bool, error) {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	result, err := session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		applied, err := r.bulkUpdate(sessionCtx, writes)
+		if err != nil {
+			return nil, err
+		}
+		if atomic && len(applied) != len(writes) {
+			return nil, ErrBulkIncomplete
+		}
+		return applied, nil
+	})
+	if err == nil {
+		return result.(map[primitive.ObjectID]bool), true, nil
+	}
+	if errors.Is(err, ErrBulkIncomplete) {
+		return nil, true, err
+	}
+	if !transactionsUnsupported(err) {
+		return nil, false, fmt.Errorf("failed to bulk update goals: %v", err)
+	}
+
+	// Standalone servers can't run transactions; fall back to a plain bulk write
+	if atomic {
+		return nil, false, ErrTransactionsUnsupported
+	}
+	applied, err := r.bulkUpdate(ctx, writes)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to bulk update goals: %v", err)
+	}
+	return applied, false, nil
+}
+
+// bulkUpdate runs each write on its own, so that the matched count of every write tells
+// whether it applied. A goal changed concurrently no longer matches its expected version.
+func (r *GoalRepository) bulkUpdate(ctx context.Context, writes []GoalWrite) (map[primitive.ObjectID]bool, error) {
+	applied := make(map[primitive.ObjectID]bool)
+	now := time.Now()
+	for _, write := range writes {
+		set := bson.M{"updated_at": now}
+		for field, value := range write.Set {
+			set[field] = value
+		}
+		update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+		if len(write.Unset) > 0 {
+			unset := bson.M{}
+			for _, field := range write.Unset {
+				unset[field] = ""
+			}
+			update["$unset"] = unset
+		}
+
+		result, err := r.collection.UpdateOne(ctx, versionFilter(write.ID, write.ExpectedVersion), update)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 1 {
+			applied[write.ID] = true
+		}
+	}
+	return applied, nil
+}
+
+// SetStepProgress atomically marks a single step as done or not done, along with the
+// time it was completed and by whom, and returns the goal as it was before the change.
+// by may be zero when the change has no actor. expectedVersion may be AnyVersion.
+func (r *GoalRepository) SetStepProgress(ctx context.Context, id primitive.ObjectID, step string, done bool, by primitive.ObjectID, expectedVersion int64) (*models.Goal, error) {
+	var previous models.Goal
+
+	filter := versionFilter(id, expectedVersion)
+	filter["progress."+step] = bson.M{"$exists": true}
+
+	// A step checked again keeps the time it was first checked, and is credited to
+	// whoever checked it last
+	now := time.Now()
+	set := bson.M{"progress." + step: done, "updated_at": now}
+	unset := bson.M{}
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	if done {
+		update["$min"] = bson.M{"step_meta." + step + ".completed_at": now}
+		if by.IsZero() {
+			unset["step_meta."+step+".completed_by"] = ""
+		} else {
+			set["step_meta."+step+".completed_by"] = by
+		}
+	} else {
+		unset["step_meta."+step+".completed_at"] = ""
+		unset["step_meta."+step+".completed_by"] = ""
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	findOptions := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, findOptions).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNoMatch
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update step progress: %v", err)
+	}
+
+	return &previous, nil
+}
+
+// SetStatus updates the derived completion status of a goal if it is still at expectedVersion.
+func (r *GoalRepository) SetStatus(ctx context.Context, id primitive.ObjectID, status string, completedAt *time.Time, expectedVersion int64) (*models.Goal, error) {
+	return r.findOneAndUpdate(ctx,
+		versionFilter(id, expectedVersion),
+		bson.M{
+			"$set": bson.M{"status": status, "completed_at": completedAt, "updated_at": time.Now()},
+			"$inc": bson.M{"version": 1},
+		},
 	)
+}
+
+// GetDeletedGoalByID fetches a goal from the trash by its ID
+func (r *GoalRepository) GetDeletedGoalByID(ctx context.Context, id primitive.ObjectID) (*models.Goal, error) {
+	var goal models.Goal
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}}).Decode(&goal)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update goal: %v", err)
+		return nil, fmt.Errorf("failed to find deleted goal by id: %v", err)
 	}
 
-	return goal, nil
+	return &goal, nil
+}
+
+// SoftDeleteGoal moves a goal to the trash by setting its deletion timestamp
+func (r *GoalRepository) SoftDeleteGoal(ctx context.Context, id primitive.ObjectID) (*models.Goal, error) {
+	var goal models.Goal
+
+	findOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$set": bson.M{"deleted_at": time.Now()}, "$inc": bson.M{"version": 1}},
+		findOptions,
+	).Decode(&goal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to soft delete goal: %v", err)
+	}
+
+	return &goal, nil
+}
+
+// RestoreGoal takes a goal out of the trash
+func (r *GoalRepository) RestoreGoal(ctx context.Context, id primitive.ObjectID) (*models.Goal, error) {
+	var goal models.Goal
+
+	findOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}},
+		bson.M{"$unset": bson.M{"deleted_at": ""}, "$set": bson.M{"updated_at": time.Now()}, "$inc": bson.M{"version": 1}},
+		findOptions,
+	).Decode(&goal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore goal: %v", err)
+	}
+
+	return &goal, nil
+}
+
+// GetDeletedGoals fetches the goals a user has in the trash, most recently deleted first
+func (r *GoalRepository) GetDeletedGoals(ctx context.Context, userID primitive.ObjectID) ([]models.Goal, error) {
+	findOptions := options.Find().SetSort(bson.M{"deleted_at": -1})
+	return r.find(ctx, bson.M{"user_id": userID, "deleted_at": bson.M{"$ne": nil}}, findOptions)
+}
+
+// GetGoalsDeletedBefore fetches goals of any user that were moved to the trash before the given time
+func (r *GoalRepository) GetGoalsDeletedBefore(ctx context.Context, before time.Time) ([]models.Goal, error) {
+	return r.find(ctx, bson.M{"deleted_at": bson.M{"$lt": before}})
+}
+
+// ArchiveGoal marks a goal as archived
+func (r *GoalRepository) ArchiveGoal(ctx context.Context, id primitive.ObjectID) (*models.Goal, error) {
+	return r.findOneAndUpdate(ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$set": bson.M{"archived_at": time.Now(), "updated_at": time.Now()}, "$inc": bson.M{"version": 1}},
+	)
+}
+
+// UnarchiveGoal moves an archived goal back to the active list
+func (r *GoalRepository) UnarchiveGoal(ctx context.Context, id primitive.ObjectID) (*models.Goal, error) {
+	return r.findOneAndUpdate(ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$unset": bson.M{"archived_at": ""}, "$set": bson.M{"updated_at": time.Now()}, "$inc": bson.M{"version": 1}},
+	)
+}
+
+// GetGoalsToAutoArchive fetches unarchived goals of any user completed before the cutoff.
+// Goals completed before completion times were tracked fall back to their last update.
+func (r *GoalRepository) GetGoalsToAutoArchive(ctx context.Context, cutoff time.Time) ([]models.Goal, error) {
+	return r.find(ctx, bson.M{
+		"status":      "completed",
+		"archived_at": nil,
+		"deleted_at":  nil,
+		"$or": bson.A{
+			bson.M{"completed_at": bson.M{"$lt": cutoff}},
+			bson.M{"completed_at": nil, "updated_at": bson.M{"$lt": cutoff}},
+		},
+	})
 }
 
 // DeleteGoal deletes a goal from the database by its ID
@@ -89,7 +526,7 @@ func (r *GoalRepository) GetAllGoals(ctx context.Context, limit int64) ([]models
 	var goals []models.Goal
 
 	findOptions := options.Find().SetLimit(limit)
-	cursor, err := r.collection.Find(ctx, bson.M{}, findOptions)
+	cursor, err := r.collection.Find(ctx, bson.M{"deleted_at": nil}, findOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch goals: %v", err)
 	}
@@ -106,14 +543,27 @@ func (r *GoalRepository) GetAllGoals(ctx context.Context, limit int64) ([]models
 	return goals, nil
 }
 
-// GetGoals fetches goals for a specific user with an optional category filter
-func (r *GoalRepository) GetGoals(ctx context.Context, userID primitive.ObjectID, category string) ([]models.Goal, error) {
+// GetGoals fetches goals for a specific user matching the given filter
+func (r *GoalRepository) GetGoals(ctx context.Context, goalFilter GoalFilter) ([]models.Goal, error) {
 	var goals []models.Goal
 
 	// Build the filter for MongoDB query
-	filter := bson.M{"user_id": userID}
-	if category != "" {
-		filter["category"] = category
+	filter := bson.M{"user_id": goalFilter.UserID, "org_id": nil, "deleted_at": nil}
+	if goalFilter.OrgID != nil {
+		filter = bson.M{"org_id": *goalFilter.OrgID, "deleted_at": nil}
+	}
+	if goalFilter.Category != "" {
+		filter["category"] = goalFilter.Category
+	}
+	if len(goalFilter.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": goalFilter.Tags}
+	}
+	switch goalFilter.Archived {
+	case ArchivedOnly:
+		filter["archived_at"] = bson.M{"$ne": nil}
+	case ArchivedAll:
+	default:
+		filter["archived_at"] = nil
 	}
 
 	cursor, err := r.collection.Find(ctx, filter)
@@ -132,3 +582,70 @@ func (r *GoalRepository) GetGoals(ctx context.Context, userID primitive.ObjectID
 
 	return goals, nil
 }
+
+func (r *GoalRepository) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]models.Goal, error) {
+	goals := []models.Goal{}
+
+	cursor, err := r.collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch goals: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &goals); err != nil {
+		return nil, fmt.Errorf("failed to decode goals: %v", err)
+	}
+
+	return goals, nil
+}
+
+func (r *GoalRepository) aggregate(ctx context.Context, pipeline mongo.Pipeline, results interface{}) error {
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, results); err != nil {
+		return fmt.Errorf("failed to decode counts: %v", err)
+	}
+	return nil
+}
+
+func (r *GoalRepository) findOneAndUpdate(ctx context.Context, filter, update bson.M) (*models.Goal, error) {
+	var goal models.Goal
+
+	findOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, findOptions).Decode(&goal)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNoMatch
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update goal: %v", err)
+	}
+
+	return &goal, nil
+}
+
+// versionFilter matches a live goal by ID and, unless expectedVersion is AnyVersion,
+// by version. Goals written before versioning was introduced count as version 0.
+func versionFilter(id primitive.ObjectID, expectedVersion int64) bson.M {
+	filter := bson.M{"_id": id, "deleted_at": nil}
+	switch {
+	case expectedVersion == AnyVersion:
+	case expectedVersion == 0:
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	default:
+		filter["version"] = expectedVersion
+	}
+	return filter
+}
+
+// transactionsUnsupported reports whether err means the deployment can't run transactions
+func transactionsUnsupported(err error) bool {
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == 20 {
+		return true
+	}
+	return strings.Contains(err.Error(), "Transaction numbers are only allowed")
+}
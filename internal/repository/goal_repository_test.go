@@ -55,3 +55,43 @@ func TestCreateAndGetGoal(t *testing.T) {
 	assert.Equal(t, createdGoal.Name, fetchedGoal.Name)
 	assert.Equal(t, createdGoal.Description, fetchedGoal.Description)
 }
+
+// TestPersonalGoalQueriesSkipTeamGoals tests that the bulk, tag and category queries only
+// return the personal goals of a user, not the team goals they created.
+func TestPersonalGoalQueriesSkipTeamGoals(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	db := client.Database("achievement_manager_test")
+	if err := db.Collection("goals").Drop(ctx); err != nil {
+		t.Fatalf("Failed to drop collection: %v", err)
+	}
+	repo := NewGoalRepository(db)
+
+	userID := primitive.NewObjectID()
+	orgID := primitive.NewObjectID()
+	personal, err := repo.CreateGoal(ctx, &models.Goal{UserID: userID, Name: "Personal", Category: "Work", Tags: []string{"q1"}})
+	assert.NoError(t, err)
+	team, err := repo.CreateGoal(ctx, &models.Goal{UserID: userID, OrgID: &orgID, Name: "Team", Category: "Work", Tags: []string{"q1"}})
+	assert.NoError(t, err)
+
+	byIDs, err := repo.GetGoalsByIDs(ctx, userID, []primitive.ObjectID{personal.ID, team.ID})
+	assert.NoError(t, err)
+	withTags, err := repo.GetGoalsWithTags(ctx, userID, []string{"q1"})
+	assert.NoError(t, err)
+	inCategory, err := repo.GetGoalsInCategory(ctx, userID, "Work")
+	assert.NoError(t, err)
+	for _, goals := range [][]models.Goal{byIDs, withTags, inCategory} {
+		if assert.Len(t, goals, 1) {
+			assert.Equal(t, personal.ID, goals[0].ID)
+		}
+	}
+
+	usage, err := repo.CountTags(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TagUsage{{Name: "q1", Count: 1}}, usage)
+}
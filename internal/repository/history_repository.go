@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HistoryRepository handles database operations related to the goal activity log.
+type HistoryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewHistoryRepository creates a new instance of HistoryRepository.
+func NewHistoryRepository(db *mongo.Database) *HistoryRepository {
+	return &HistoryRepository{
+		collection: db.Collection("goal_events"),
+	}
+}
+
+// maxRevisionAttempts bounds how often CreateEntry retries when a concurrent writer
+// took the revision number it picked.
+const maxRevisionAttempts = 5
+
+// ErrRevisionNotFound is returned when a goal has no entry with the requested revision.
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// EnsureIndexes creates the indexes the activity log relies on. The unique index on
+// goal and revision keeps concurrent writers from recording the same revision twice.
+func (r *HistoryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "goal_id", Value: 1}, {Key: "revision", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create history indexes: %v", err)
+	}
+	return nil
+}
+
+// CreateEntry appends an entry to the goal activity log, assigning the next revision number.
+// When another entry of the goal takes the same number first, it retries with the next one.
+func (r *HistoryRepository) CreateEntry(ctx context.Context, entry *models.GoalHistoryEntry) (*models.GoalHistoryEntry, error) {
+	entry.CreatedAt = time.Now()
+
+	var result *mongo.InsertOneResult
+	for attempt := 1; ; attempt++ {
+		var latest models.GoalHistoryEntry
+		findOptions := options.FindOne().SetSort(bson.M{"revision": -1})
+		err := r.collection.FindOne(ctx, bson.M{"goal_id": entry.GoalID}, findOptions).Decode(&latest)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("failed to find latest revision: %v", err)
+		}
+		entry.Revision = latest.Revision + 1
+
+		result, err = r.collection.InsertOne(ctx, entry)
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) || attempt == maxRevisionAttempts {
+			return nil, fmt.Errorf("failed to insert history entry: %v", err)
+		}
+	}
+
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast inserted ID")
+	}
+	entry.ID = insertedID
+
+	return entry, nil
+}
+
+// GetEntries fetches a page of a goal's activity log, newest first, along with the total count.
+func (r *HistoryRepository) GetEntries(ctx context.Context, goalID primitive.ObjectID, page, limit int64) ([]models.GoalHistoryEntry, int64, error) {
+	entries := []models.GoalHistoryEntry{}
+	filter := bson.M{"goal_id": goalID}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count history entries: %v", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.M{"revision": -1}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit).
+		SetProjection(bson.M{"snapshot": 0})
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch history entries: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode history entries: %v", err)
+	}
+	return entries, total, nil
+}
+
+// GetEntryByRevision fetches a single revision of a goal, including its snapshot.
+func (r *HistoryRepository) GetEntryByRevision(ctx context.Context, goalID primitive.ObjectID, revision int) (*models.GoalHistoryEntry, error) {
+	var entry models.GoalHistoryEntry
+	err := r.collection.FindOne(ctx, bson.M{"goal_id": goalID, "revision": revision}).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find history entry: %v", err)
+	}
+	return &entry, nil
+}
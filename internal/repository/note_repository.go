@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NoteRepository handles database operations related to goal notes.
+type NoteRepository struct {
+	collection *mongo.Collection
+}
+
+// NewNoteRepository creates a new instance of NoteRepository.
+func NewNoteRepository(db *mongo.Database) *NoteRepository {
+	return &NoteRepository{
+		collection: db.Collection("goal_notes"),
+	}
+}
+
+// CreateNote inserts a new note into the database.
+func (r *NoteRepository) CreateNote(ctx context.Context, note *models.GoalNote) (*models.GoalNote, error) {
+	note.CreatedAt = time.Now()
+	note.UpdatedAt = note.CreatedAt
+
+	result, err := r.collection.InsertOne(ctx, note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert note: %v", err)
+	}
+
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast inserted ID")
+	}
+	note.ID = insertedID
+
+	return note, nil
+}
+
+// CreateNotes inserts several notes at once, keeping their timestamps.
+func (r *NoteRepository) CreateNotes(ctx context.Context, notes []models.GoalNote) error {
+	if len(notes) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, len(notes))
+	for i := range notes {
+		documents[i] = notes[i]
+	}
+	if _, err := r.collection.InsertMany(ctx, documents); err != nil {
+		return fmt.Errorf("failed to insert notes: %v", err)
+	}
+	return nil
+}
+
+// GetNoteByID fetches a note by its ID.
+func (r *NoteRepository) GetNoteByID(ctx context.Context, id primitive.ObjectID) (*models.GoalNote, error) {
+	var note models.GoalNote
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find note by id: %v", err)
+	}
+	return &note, nil
+}
+
+// GetNotes fetches a page of a goal's notes, newest first, along with the total count.
+// A non-empty step limits the result to the notes on that step.
+func (r *NoteRepository) GetNotes(ctx context.Context, goalID primitive.ObjectID, step string, page, limit int64) ([]models.GoalNote, int64, error) {
+	notes := []models.GoalNote{}
+	filter := bson.M{"goal_id": goalID}
+	if step != "" {
+		filter["step"] = step
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count notes: %v", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch notes: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &notes); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode notes: %v", err)
+	}
+	return notes, total, nil
+}
+
+// GetAllNotes fetches every note of a goal, oldest first.
+func (r *NoteRepository) GetAllNotes(ctx context.Context, goalID primitive.ObjectID) ([]models.GoalNote, error) {
+	notes := []models.GoalNote{}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"goal_id": goalID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notes: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &notes); err != nil {
+		return nil, fmt.Errorf("failed to decode notes: %v", err)
+	}
+	return notes, nil
+}
+
+// UpdateNote updates the body and mood of a note.
+func (r *NoteRepository) UpdateNote(ctx context.Context, id primitive.ObjectID, note *models.GoalNote) (*models.GoalNote, error) {
+	note.UpdatedAt = time.Now()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"body":       note.Body,
+			"mood":       note.Mood,
+			"updated_at": note.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update note: %v", err)
+	}
+	return note, nil
+}
+
+// DeleteNote removes a note from the database.
+func (r *NoteRepository) DeleteNote(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete note: %v", err)
+	}
+	return nil
+}
+
+// DeleteNotesByGoal removes every note of a goal.
+func (r *NoteRepository) DeleteNotesByGoal(ctx context.Context, goalID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"goal_id": goalID})
+	if err != nil {
+		return fmt.Errorf("failed to delete notes: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OrgRepository handles database operations related to organizations and their members.
+type OrgRepository struct {
+	orgs    *mongo.Collection
+	members *mongo.Collection
+}
+
+// NewOrgRepository creates a new instance of OrgRepository.
+func NewOrgRepository(db *mongo.Database) *OrgRepository {
+	return &OrgRepository{
+		orgs:    db.Collection("organizations"),
+		members: db.Collection("org_members"),
+	}
+}
+
+// EnsureIndexes creates the indexes used to check memberships and to list the invitations
+// of a user.
+func (r *OrgRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.members.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create member indexes: %v", err)
+	}
+	return nil
+}
+
+// CreateOrg inserts a new organization into the database.
+func (r *OrgRepository) CreateOrg(ctx context.Context, org *models.Organization) (*models.Organization, error) {
+	org.CreatedAt = time.Now()
+	org.UpdatedAt = org.CreatedAt
+
+	result, err := r.orgs.InsertOne(ctx, org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert organization: %v", err)
+	}
+
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast inserted ID")
+	}
+	org.ID = insertedID
+
+	return org, nil
+}
+
+// GetOrgByID fetches an organization by its ID.
+func (r *OrgRepository) GetOrgByID(ctx context.Context, id primitive.ObjectID) (*models.Organization, error) {
+	var org models.Organization
+	err := r.orgs.FindOne(ctx, bson.M{"_id": id}).Decode(&org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find organization: %v", err)
+	}
+	return &org, nil
+}
+
+// GetOrgsByIDs fetches the organizations among the given IDs.
+func (r *OrgRepository) GetOrgsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Organization, error) {
+	cursor, err := r.orgs.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find organizations: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	orgs := []models.Organization{}
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, fmt.Errorf("failed to decode organizations: %v", err)
+	}
+	return orgs, nil
+}
+
+// RenameOrg changes the name of an organization.
+func (r *OrgRepository) RenameOrg(ctx context.Context, id primitive.ObjectID, name string) (*models.Organization, error) {
+	var org models.Organization
+	err := r.orgs.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"name": name, "updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename organization: %v", err)
+	}
+	return &org, nil
+}
+
+// DeleteOrg removes an organization and its members.
+func (r *OrgRepository) DeleteOrg(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := r.members.DeleteMany(ctx, bson.M{"org_id": id}); err != nil {
+		return fmt.Errorf("failed to delete members: %v", err)
+	}
+	if _, err := r.orgs.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to delete organization: %v", err)
+	}
+	return nil
+}
+
+// CreateMember inserts a new member or invitation into the database.
+func (r *OrgRepository) CreateMember(ctx context.Context, member *models.OrgMember) (*models.OrgMember, error) {
+	member.CreatedAt = time.Now()
+
+	result, err := r.members.InsertOne(ctx, member)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert member: %v", err)
+	}
+
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast inserted ID")
+	}
+	member.ID = insertedID
+
+	return member, nil
+}
+
+// GetMemberByID fetches a member or invitation by its ID.
+func (r *OrgRepository) GetMemberByID(ctx context.Context, id primitive.ObjectID) (*models.OrgMember, error) {
+	var member models.OrgMember
+	err := r.members.FindOne(ctx, bson.M{"_id": id}).Decode(&member)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find member: %v", err)
+	}
+	return &member, nil
+}
+
+// GetMemberByEmail fetches the member of an organization invited with an email address,
+// or nil if there is none.
+func (r *OrgRepository) GetMemberByEmail(ctx context.Context, orgID primitive.ObjectID, email string) (*models.OrgMember, error) {
+	return r.findOne(ctx, bson.M{"org_id": orgID, "email": email})
+}
+
+// GetMembership fetches the accepted membership of a user in an organization, or nil if
+// the user isn't a member.
+func (r *OrgRepository) GetMembership(ctx context.Context, orgID, userID primitive.ObjectID) (*models.OrgMember, error) {
+	return r.findOne(ctx, bson.M{"org_id": orgID, "user_id": userID, "status": models.ShareAccepted})
+}
+
+// GetMembers lists the members and invitations of an organization, oldest first.
+func (r *OrgRepository) GetMembers(ctx context.Context, orgID primitive.ObjectID) ([]models.OrgMember, error) {
+	return r.find(ctx, bson.M{"org_id": orgID})
+}
+
+// GetUserMemberships lists the accepted memberships of a user.
+func (r *OrgRepository) GetUserMemberships(ctx context.Context, userID primitive.ObjectID) ([]models.OrgMember, error) {
+	return r.find(ctx, bson.M{"user_id": userID, "status": models.ShareAccepted})
+}
+
+// GetPendingInvitations lists the pending invitations sent to a user or to their email address.
+func (r *OrgRepository) GetPendingInvitations(ctx context.Context, userID primitive.ObjectID, email string) ([]models.OrgMember, error) {
+	return r.find(ctx, bson.M{
+		"status": models.SharePending,
+		"$or":    bson.A{bson.M{"user_id": userID}, bson.M{"email": email}},
+	})
+}
+
+// CountAdmins counts the accepted admins of an organization.
+func (r *OrgRepository) CountAdmins(ctx context.Context, orgID primitive.ObjectID) (int64, error) {
+	count, err := r.members.CountDocuments(ctx, bson.M{"org_id": orgID, "role": models.OrgRoleAdmin, "status": models.ShareAccepted})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count admins: %v", err)
+	}
+	return count, nil
+}
+
+// UpdateMember replaces the role, status and user of a member.
+func (r *OrgRepository) UpdateMember(ctx context.Context, member *models.OrgMember) (*models.OrgMember, error) {
+	_, err := r.members.UpdateOne(ctx, bson.M{"_id": member.ID}, bson.M{"$set": bson.M{
+		"user_id":      member.UserID,
+		"role":         member.Role,
+		"status":       member.Status,
+		"invited_by":   member.InvitedBy,
+		"responded_at": member.RespondedAt,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update member: %v", err)
+	}
+	return member, nil
+}
+
+// DeleteMember removes a member or invitation.
+func (r *OrgRepository) DeleteMember(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.members.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete member: %v", err)
+	}
+	return nil
+}
+
+func (r *OrgRepository) findOne(ctx context.Context, filter bson.M) (*models.OrgMember, error) {
+	var member models.OrgMember
+	err := r.members.FindOne(ctx, filter).Decode(&member)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find member: %v", err)
+	}
+	return &member, nil
+}
+
+func (r *OrgRepository) find(ctx context.Context, filter bson.M) ([]models.OrgMember, error) {
+	cursor, err := r.members.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find members: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	members := []models.OrgMember{}
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, fmt.Errorf("failed to decode members: %v", err)
+	}
+	return members, nil
+}
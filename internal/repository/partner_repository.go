@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PartnerRepository handles database operations related to accountability partners and
+// to the goals hidden from them.
+type PartnerRepository struct {
+	links  *mongo.Collection
+	hidden *mongo.Collection
+}
+
+// NewPartnerRepository creates a new instance of PartnerRepository.
+func NewPartnerRepository(db *mongo.Database) *PartnerRepository {
+	return &PartnerRepository{
+		links:  db.Collection("partner_links"),
+		hidden: db.Collection("partner_hidden_goals"),
+	}
+}
+
+// PartnerPair identifies two users regardless of their order.
+func PartnerPair(a, b primitive.ObjectID) string {
+	if a.Hex() > b.Hex() {
+		a, b = b, a
+	}
+	return a.Hex() + ":" + b.Hex()
+}
+
+// EnsureIndexes creates the indexes that link two users once and that look up the
+// partners of a user and the goals they hid.
+func (r *PartnerRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.links.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "pair", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "requester_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "partner_id", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create partner indexes: %v", err)
+	}
+	_, err = r.hidden.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "goal_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create hidden goal index: %v", err)
+	}
+	return nil
+}
+
+// CreateLink inserts a new partner request into the database.
+func (r *PartnerRepository) CreateLink(ctx context.Context, link *models.PartnerLink) (*models.PartnerLink, error) {
+	link.Pair = PartnerPair(link.RequesterID, link.PartnerID)
+	link.CreatedAt = time.Now()
+
+	result, err := r.links.InsertOne(ctx, link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert partner link: %v", err)
+	}
+
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast inserted ID")
+	}
+	link.ID = insertedID
+
+	return link, nil
+}
+
+// GetLinkByID fetches a partner link by its ID.
+func (r *PartnerRepository) GetLinkByID(ctx context.Context, id primitive.ObjectID) (*models.PartnerLink, error) {
+	var link models.PartnerLink
+	err := r.links.FindOne(ctx, bson.M{"_id": id}).Decode(&link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find partner link: %v", err)
+	}
+	return &link, nil
+}
+
+// GetLinkBetween fetches the link between two users, or nil if there is none.
+func (r *PartnerRepository) GetLinkBetween(ctx context.Context, a, b primitive.ObjectID) (*models.PartnerLink, error) {
+	var link models.PartnerLink
+	err := r.links.FindOne(ctx, bson.M{"pair": PartnerPair(a, b)}).Decode(&link)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find partner link: %v", err)
+	}
+	return &link, nil
+}
+
+// GetLinks lists the links of a user with a status, oldest first.
+func (r *PartnerRepository) GetLinks(ctx context.Context, userID primitive.ObjectID, status string) ([]models.PartnerLink, error) {
+	return r.find(ctx, bson.M{
+		"status": status,
+		"$or":    bson.A{bson.M{"requester_id": userID}, bson.M{"partner_id": userID}},
+	})
+}
+
+// GetIncomingRequests lists the pending requests other users sent to a user.
+func (r *PartnerRepository) GetIncomingRequests(ctx context.Context, userID primitive.ObjectID) ([]models.PartnerLink, error) {
+	return r.find(ctx, bson.M{"partner_id": userID, "status": models.SharePending})
+}
+
+// GetLinksToSummarize lists the accepted links whose partners weren't sent a summary since before.
+func (r *PartnerRepository) GetLinksToSummarize(ctx context.Context, before time.Time) ([]models.PartnerLink, error) {
+	return r.find(ctx, bson.M{
+		"status": models.ShareAccepted,
+		"$or":    bson.A{bson.M{"summarized_at": nil}, bson.M{"summarized_at": bson.M{"$lt": before}}},
+	})
+}
+
+// UpdateLink replaces the requester, partner, status, request, response and summary times of a link.
+func (r *PartnerRepository) UpdateLink(ctx context.Context, link *models.PartnerLink) (*models.PartnerLink, error) {
+	_, err := r.links.UpdateOne(ctx, bson.M{"_id": link.ID}, bson.M{"$set": bson.M{
+		"requester_id":  link.RequesterID,
+		"partner_id":    link.PartnerID,
+		"status":        link.Status,
+		"responded_at":  link.RespondedAt,
+		"created_at":    link.CreatedAt,
+		"summarized_at": link.SummarizedAt,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update partner link: %v", err)
+	}
+	return link, nil
+}
+
+// MarkNudged records that userID nudged their partner, unless they already did since
+// before. It reports whether the nudge was recorded.
+func (r *PartnerRepository) MarkNudged(ctx context.Context, linkID, userID primitive.ObjectID, before time.Time) (bool, error) {
+	field := "nudged_at." + userID.Hex()
+	result, err := r.links.UpdateOne(ctx,
+		bson.M{"_id": linkID, "$or": bson.A{bson.M{field: nil}, bson.M{field: bson.M{"$lt": before}}}},
+		bson.M{"$set": bson.M{field: time.Now()}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record nudge: %v", err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// MarkSummarized records that the partners of a link were sent their summaries, unless
+// that already happened since before. It reports whether this call claimed the summaries.
+func (r *PartnerRepository) MarkSummarized(ctx context.Context, linkID primitive.ObjectID, before time.Time) (bool, error) {
+	result, err := r.links.UpdateOne(ctx,
+		bson.M{"_id": linkID, "$or": bson.A{bson.M{"summarized_at": nil}, bson.M{"summarized_at": bson.M{"$lt": before}}}},
+		bson.M{"$set": bson.M{"summarized_at": time.Now()}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record summary: %v", err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// DeleteLink removes a partner link.
+func (r *PartnerRepository) DeleteLink(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.links.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete partner link: %v", err)
+	}
+	return nil
+}
+
+// SetHidden hides a goal of a user from their partners, or shows it again.
+func (r *PartnerRepository) SetHidden(ctx context.Context, userID, goalID primitive.ObjectID, hidden bool) error {
+	var err error
+	if hidden {
+		_, err = r.hidden.UpdateOne(ctx,
+			bson.M{"goal_id": goalID},
+			bson.M{"$set": bson.M{"user_id": userID}, "$setOnInsert": bson.M{"created_at": time.Now()}},
+			options.Update().SetUpsert(true),
+		)
+	} else {
+		_, err = r.hidden.DeleteOne(ctx, bson.M{"goal_id": goalID})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update goal visibility: %v", err)
+	}
+	return nil
+}
+
+// IsHidden reports whether a goal is hidden from partners.
+func (r *PartnerRepository) IsHidden(ctx context.Context, goalID primitive.ObjectID) (bool, error) {
+	count, err := r.hidden.CountDocuments(ctx, bson.M{"goal_id": goalID}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to check goal visibility: %v", err)
+	}
+	return count > 0, nil
+}
+
+// GetHiddenGoals returns the IDs of the goals a user hid from partners.
+func (r *PartnerRepository) GetHiddenGoals(ctx context.Context, userID primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	cursor, err := r.hidden.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find hidden goals: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		GoalID primitive.ObjectID `bson:"goal_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode hidden goals: %v", err)
+	}
+	hidden := make(map[primitive.ObjectID]bool, len(docs))
+	for _, doc := range docs {
+		hidden[doc.GoalID] = true
+	}
+	return hidden, nil
+}
+
+// DeleteHiddenGoal forgets the visibility of a goal that no longer exists.
+func (r *PartnerRepository) DeleteHiddenGoal(ctx context.Context, goalID primitive.ObjectID) error {
+	return r.SetHidden(ctx, primitive.NilObjectID, goalID, false)
+}
+
+func (r *PartnerRepository) find(ctx context.Context, filter bson.M) ([]models.PartnerLink, error) {
+	cursor, err := r.links.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find partner links: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	links := []models.PartnerLink{}
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, fmt.Errorf("failed to decode partner links: %v", err)
+	}
+	return links, nil
+}
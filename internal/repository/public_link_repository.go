@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PublicLinkRepository handles database operations related to public goal links.
+type PublicLinkRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPublicLinkRepository creates a new instance of PublicLinkRepository.
+func NewPublicLinkRepository(db *mongo.Database) *PublicLinkRepository {
+	return &PublicLinkRepository{
+		collection: db.Collection("public_links"),
+	}
+}
+
+// EnsureIndexes creates the indexes used to look links up by token and by goal.
+func (r *PublicLinkRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "goal_id", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create public link indexes: %v", err)
+	}
+	return nil
+}
+
+// CreateLink inserts a new public link into the database.
+func (r *PublicLinkRepository) CreateLink(ctx context.Context, link *models.PublicLink) (*models.PublicLink, error) {
+	link.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert public link: %v", err)
+	}
+
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast inserted ID")
+	}
+	link.ID = insertedID
+
+	return link, nil
+}
+
+// GetLinkByID fetches a public link by its ID.
+func (r *PublicLinkRepository) GetLinkByID(ctx context.Context, id primitive.ObjectID) (*models.PublicLink, error) {
+	var link models.PublicLink
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find public link: %v", err)
+	}
+	return &link, nil
+}
+
+// GetLinkByToken fetches a public link by its token, or nil if there is none.
+func (r *PublicLinkRepository) GetLinkByToken(ctx context.Context, token string) (*models.PublicLink, error) {
+	var link models.PublicLink
+	err := r.collection.FindOne(ctx, bson.M{"token": token}).Decode(&link)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find public link: %v", err)
+	}
+	return &link, nil
+}
+
+// GetLinksByGoal lists the public links of a goal, newest first.
+func (r *PublicLinkRepository) GetLinksByGoal(ctx context.Context, goalID primitive.ObjectID) ([]models.PublicLink, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"goal_id": goalID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find public links: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	links := []models.PublicLink{}
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, fmt.Errorf("failed to decode public links: %v", err)
+	}
+	return links, nil
+}
+
+// RecordView counts a view of a link.
+func (r *PublicLinkRepository) RecordView(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$inc": bson.M{"views": 1},
+		"$set": bson.M{"last_viewed_at": time.Now()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record view: %v", err)
+	}
+	return nil
+}
+
+// RevokeLink marks a link as revoked, keeping it along with its view count. It reports
+// whether the link was still active.
+func (r *PublicLinkRepository) RevokeLink(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke public link: %v", err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// DeleteLinksByGoal removes every public link of a goal.
+func (r *PublicLinkRepository) DeleteLinksByGoal(ctx context.Context, goalID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"goal_id": goalID})
+	if err != nil {
+		return fmt.Errorf("failed to delete public links: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SettingsRepository handles database operations related to user settings.
+type SettingsRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSettingsRepository creates a new instance of SettingsRepository.
+func NewSettingsRepository(db *mongo.Database) *SettingsRepository {
+	return &SettingsRepository{
+		collection: db.Collection("user_settings"),
+	}
+}
+
+// GetSettings decodes the stored settings of a user into settings, which should hold the
+// defaults: fields missing from the stored document keep them. It reports whether the
+// user has stored settings at all.
+func (r *SettingsRepository) GetSettings(ctx context.Context, userID primitive.ObjectID, settings *models.UserSettings) (bool, error) {
+	err := r.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(settings)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to find settings: %v", err)
+	}
+	return true, nil
+}
+
+// SaveSettings replaces the stored settings of a user, creating them if needed.
+func (r *SettingsRepository) SaveSettings(ctx context.Context, settings *models.UserSettings) (*models.UserSettings, error) {
+	settings.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": settings.UserID}, settings, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save settings: %v", err)
+	}
+	return settings, nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ShareRepository handles database operations related to goal shares.
+type ShareRepository struct {
+	collection *mongo.Collection
+}
+
+// NewShareRepository creates a new instance of ShareRepository.
+func NewShareRepository(db *mongo.Database) *ShareRepository {
+	return &ShareRepository{
+		collection: db.Collection("goal_shares"),
+	}
+}
+
+// EnsureIndexes creates the indexes used to check access to a goal and to list the
+// invitations of a user.
+func (r *ShareRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "goal_id", Value: 1}, {Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create share indexes: %v", err)
+	}
+	return nil
+}
+
+// CreateShare inserts a new share into the database.
+func (r *ShareRepository) CreateShare(ctx context.Context, share *models.GoalShare) (*models.GoalShare, error) {
+	share.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, share)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert share: %v", err)
+	}
+
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast inserted ID")
+	}
+	share.ID = insertedID
+
+	return share, nil
+}
+
+// GetShareByID fetches a share by its ID.
+func (r *ShareRepository) GetShareByID(ctx context.Context, id primitive.ObjectID) (*models.GoalShare, error) {
+	var share models.GoalShare
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&share)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find share: %v", err)
+	}
+	return &share, nil
+}
+
+// GetShareByEmail fetches the share of a goal sent to an email address, or nil if there is none.
+func (r *ShareRepository) GetShareByEmail(ctx context.Context, goalID primitive.ObjectID, email string) (*models.GoalShare, error) {
+	var share models.GoalShare
+	err := r.collection.FindOne(ctx, bson.M{"goal_id": goalID, "email": email}).Decode(&share)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find share: %v", err)
+	}
+	return &share, nil
+}
+
+// GetAcceptedShare fetches the accepted share of a goal with a user, or nil if there is none.
+func (r *ShareRepository) GetAcceptedShare(ctx context.Context, goalID, userID primitive.ObjectID) (*models.GoalShare, error) {
+	var share models.GoalShare
+	err := r.collection.FindOne(ctx, bson.M{"goal_id": goalID, "user_id": userID, "status": models.ShareAccepted}).Decode(&share)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find share: %v", err)
+	}
+	return &share, nil
+}
+
+// GetSharesByGoal lists the shares of a goal, oldest first.
+func (r *ShareRepository) GetSharesByGoal(ctx context.Context, goalID primitive.ObjectID) ([]models.GoalShare, error) {
+	return r.find(ctx, bson.M{"goal_id": goalID})
+}
+
+// GetAcceptedShares lists the shares a user accepted.
+func (r *ShareRepository) GetAcceptedShares(ctx context.Context, userID primitive.ObjectID) ([]models.GoalShare, error) {
+	return r.find(ctx, bson.M{"user_id": userID, "status": models.ShareAccepted})
+}
+
+// GetPendingInvitations lists the pending shares sent to a user or to their email address.
+func (r *ShareRepository) GetPendingInvitations(ctx context.Context, userID primitive.ObjectID, email string) ([]models.GoalShare, error) {
+	return r.find(ctx, bson.M{
+		"status": models.SharePending,
+		"$or":    bson.A{bson.M{"user_id": userID}, bson.M{"email": email}},
+	})
+}
+
+// UpdateShare replaces the role, status and invitee of a share.
+func (r *ShareRepository) UpdateShare(ctx context.Context, share *models.GoalShare) (*models.GoalShare, error) {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": share.ID}, bson.M{"$set": bson.M{
+		"user_id":      share.UserID,
+		"role":         share.Role,
+		"status":       share.Status,
+		"invited_by":   share.InvitedBy,
+		"responded_at": share.RespondedAt,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update share: %v", err)
+	}
+	return share, nil
+}
+
+// DeleteShare removes a share.
+func (r *ShareRepository) DeleteShare(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete share: %v", err)
+	}
+	return nil
+}
+
+// DeleteSharesByGoal removes every share of a goal.
+func (r *ShareRepository) DeleteSharesByGoal(ctx context.Context, goalID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"goal_id": goalID})
+	if err != nil {
+		return fmt.Errorf("failed to delete shares: %v", err)
+	}
+	return nil
+}
+
+func (r *ShareRepository) find(ctx context.Context, filter bson.M) ([]models.GoalShare, error) {
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shares: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	shares := []models.GoalShare{}
+	if err := cursor.All(ctx, &shares); err != nil {
+		return nil, fmt.Errorf("failed to decode shares: %v", err)
+	}
+	return shares, nil
+}
@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StatsQuery selects the goals statistics are computed over.
+type StatsQuery struct {
+	UserID primitive.ObjectID
+	// From and To bound the range, To exclusive
+	From time.Time
+	To   time.Time
+	// Now caps the deadlines counted towards the overdue rate
+	Now time.Time
+	// Timezone is the IANA zone weeks and weekdays are computed in
+	Timezone string
+	// WeekStart is the full English name of the first day of the week
+	WeekStart string
+}
+
+// CountByKey is an aggregation bucket keyed by a string, such as a status.
+type CountByKey struct {
+	Key   string `bson:"_id"`
+	Count int    `bson:"count"`
+}
+
+// CountByDate is an aggregation bucket keyed by the start of a period.
+type CountByDate struct {
+	Date  time.Time `bson:"_id"`
+	Count int       `bson:"count"`
+}
+
+// CountByWeekday is an aggregation bucket keyed by ISO day of the week, 1 for Monday.
+type CountByWeekday struct {
+	Weekday int `bson:"_id"`
+	Count   int `bson:"count"`
+}
+
+// GoalAggregates are the raw goal statistics of a StatsQuery.
+type GoalAggregates struct {
+	ByStatus   []CountByKey     `bson:"by_status"`
+	ByCategory []CountByKey     `bson:"by_category"`
+	Created    []CountByDate    `bson:"created"`
+	Completed  []CountByDate    `bson:"completed"`
+	Weekdays   []CountByWeekday `bson:"weekdays"`
+	Completion []struct {
+		Count     int     `bson:"count"`
+		AvgMillis float64 `bson:"avg_millis"`
+	} `bson:"completion"`
+	Overdue []struct {
+		Due    int `bson:"due"`
+		Missed int `bson:"missed"`
+	} `bson:"overdue"`
+}
+
+// CountByDay is an aggregation bucket keyed by a local date, such as "2026-10-18".
+type CountByDay struct {
+	Day   string `bson:"_id"`
+	Count int    `bson:"count"`
+}
+
+// StepAggregates are the raw step completion statistics of a StatsQuery.
+type StepAggregates struct {
+	Weeks    []CountByDate    `bson:"weeks"`
+	Weekdays []CountByWeekday `bson:"weekdays"`
+}
+
+// StatsRepository computes statistics with aggregation pipelines over the goals and
+// their activity log.
+type StatsRepository struct {
+	goals   *mongo.Collection
+	history *mongo.Collection
+	notes   *mongo.Collection
+}
+
+// NewStatsRepository creates a new instance of StatsRepository.
+func NewStatsRepository(db *mongo.Database) *StatsRepository {
+	return &StatsRepository{
+		goals:   db.Collection("goals"),
+		history: db.Collection("goal_events"),
+		notes:   db.Collection("goal_notes"),
+	}
+}
+
+// EnsureIndexes creates the indexes that keep the statistics pipelines from scanning
+// every goal of a user.
+func (r *StatsRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.goals.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "completed_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "due_date", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create goal indexes: %v", err)
+	}
+	_, err = r.history.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "event", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create history index: %v", err)
+	}
+	_, err = r.notes.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create note index: %v", err)
+	}
+	return nil
+}
+
+// GoalAggregates computes the goal statistics of a query in a single pipeline.
+func (r *StatsRepository) GoalAggregates(ctx context.Context, query StatsQuery) (*GoalAggregates, error) {
+	createdInRange := bson.M{"$match": bson.M{"created_at": bson.M{"$gte": query.From, "$lt": query.To}}}
+	completedInRange := bson.M{"$match": bson.M{
+		"status":       "completed",
+		"completed_at": bson.M{"$gte": query.From, "$lt": query.To},
+	}}
+	deadlineEnd := query.To
+	if query.Now.Before(deadlineEnd) {
+		deadlineEnd = query.Now
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": query.UserID, "deleted_at": nil}}},
+		{{Key: "$facet", Value: bson.M{
+			"by_status": bson.A{
+				createdInRange,
+				bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+			},
+			"by_category": bson.A{
+				createdInRange,
+				bson.M{"$group": bson.M{"_id": bson.M{"$ifNull": bson.A{"$category", ""}}, "count": bson.M{"$sum": 1}}},
+			},
+			"created": bson.A{
+				createdInRange,
+				bson.M{"$group": bson.M{"_id": weekOf("$created_at", query), "count": bson.M{"$sum": 1}}},
+			},
+			"completed": bson.A{
+				completedInRange,
+				bson.M{"$group": bson.M{"_id": weekOf("$completed_at", query), "count": bson.M{"$sum": 1}}},
+			},
+			"weekdays": bson.A{
+				completedInRange,
+				bson.M{"$group": bson.M{"_id": weekdayOf("$completed_at", query), "count": bson.M{"$sum": 1}}},
+			},
+			"completion": bson.A{
+				completedInRange,
+				bson.M{"$group": bson.M{
+					"_id":        nil,
+					"count":      bson.M{"$sum": 1},
+					"avg_millis": bson.M{"$avg": bson.M{"$subtract": bson.A{"$completed_at", "$created_at"}}},
+				}},
+			},
+			"overdue": bson.A{
+				bson.M{"$match": bson.M{"due_date": bson.M{"$exists": true}}},
+				// All-day goals are due until the end of their day in the user's timezone
+				bson.M{"$addFields": bson.M{"deadline": bson.M{"$cond": bson.A{
+					"$due_all_day",
+					bson.M{"$dateFromParts": bson.M{
+						"year":     bson.M{"$year": "$due_date"},
+						"month":    bson.M{"$month": "$due_date"},
+						"day":      bson.M{"$add": bson.A{bson.M{"$dayOfMonth": "$due_date"}, 1}},
+						"timezone": query.Timezone,
+					}},
+					"$due_date",
+				}}}},
+				bson.M{"$match": bson.M{"deadline": bson.M{"$gte": query.From, "$lt": deadlineEnd}}},
+				bson.M{"$group": bson.M{
+					"_id": nil,
+					"due": bson.M{"$sum": 1},
+					"missed": bson.M{"$sum": bson.M{"$cond": bson.A{
+						bson.M{"$or": bson.A{
+							bson.M{"$ne": bson.A{"$status", "completed"}},
+							bson.M{"$gt": bson.A{"$completed_at", "$deadline"}},
+						}},
+						1, 0,
+					}}},
+				}},
+			},
+		}}},
+	}
+
+	var results []GoalAggregates
+	if err := r.aggregate(ctx, r.goals, pipeline, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &GoalAggregates{}, nil
+	}
+	return &results[0], nil
+}
+
+// StepAggregates computes when the steps of a user's goals were checked, from the
+// progress changes recorded in the activity log.
+func (r *StatsRepository) StepAggregates(ctx context.Context, query StatsQuery) (*StepAggregates, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"user_id":    query.UserID,
+			"event":      models.EventGoalUpdated,
+			"created_at": bson.M{"$gte": query.From, "$lt": query.To},
+		}}},
+		{{Key: "$unwind", Value: "$changes"}},
+		{{Key: "$match", Value: bson.M{"changes.field": bson.M{"$regex": `^progress\.`}, "changes.to": true}}},
+		{{Key: "$facet", Value: bson.M{
+			"weeks": bson.A{
+				bson.M{"$group": bson.M{"_id": weekOf("$created_at", query), "count": bson.M{"$sum": 1}}},
+			},
+			"weekdays": bson.A{
+				bson.M{"$group": bson.M{"_id": weekdayOf("$created_at", query), "count": bson.M{"$sum": 1}}},
+			},
+		}}},
+	}
+
+	var results []StepAggregates
+	if err := r.aggregate(ctx, r.history, pipeline, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &StepAggregates{}, nil
+	}
+	return &results[0], nil
+}
+
+// StepsByDay counts the steps completed on each day of a query, from the completion
+// times stored on the goals. Steps that were unchecked or removed since don't count, even
+// if a completion time was left behind.
+func (r *StatsRepository) StepsByDay(ctx context.Context, query StatsQuery) ([]CountByDay, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": query.UserID, "deleted_at": nil, "step_meta": bson.M{"$exists": true}}}},
+		{{Key: "$project", Value: bson.M{
+			"steps": bson.M{"$objectToArray": "$step_meta"},
+			// The names of the steps that are done now; a stale completion time doesn't count
+			"done": bson.M{"$map": bson.M{
+				"input": bson.M{"$filter": bson.M{
+					"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$progress", bson.M{}}}},
+					"cond":  bson.M{"$eq": bson.A{"$$this.v", true}},
+				}},
+				"in": "$$this.k",
+			}},
+		}}},
+		{{Key: "$unwind", Value: "$steps"}},
+		{{Key: "$match", Value: bson.M{
+			"steps.v.completed_at": bson.M{"$gte": query.From, "$lt": query.To},
+			"$expr":                bson.M{"$in": bson.A{"$steps.k", "$done"}},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": dayOf("$steps.v.completed_at", query), "count": bson.M{"$sum": 1}}}},
+	}
+
+	var results []CountByDay
+	if err := r.aggregate(ctx, r.goals, pipeline, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// NotesByDay counts the notes written on each day of a query.
+func (r *StatsRepository) NotesByDay(ctx context.Context, query StatsQuery) ([]CountByDay, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"user_id":    query.UserID,
+			"created_at": bson.M{"$gte": query.From, "$lt": query.To},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": dayOf("$created_at", query), "count": bson.M{"$sum": 1}}}},
+	}
+
+	var results []CountByDay
+	if err := r.aggregate(ctx, r.notes, pipeline, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *StatsRepository) aggregate(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline, results interface{}) error {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate statistics: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, results); err != nil {
+		return fmt.Errorf("failed to decode statistics: %v", err)
+	}
+	return nil
+}
+
+// weekOf truncates a date field to the start of its week in the query's timezone.
+func weekOf(field string, query StatsQuery) bson.M {
+	return bson.M{"$dateTrunc": bson.M{
+		"date":        field,
+		"unit":        "week",
+		"timezone":    query.Timezone,
+		"startOfWeek": query.WeekStart,
+	}}
+}
+
+// weekdayOf returns the ISO day of the week of a date field in the query's timezone.
+func weekdayOf(field string, query StatsQuery) bson.M {
+	return bson.M{"$isoDayOfWeek": bson.M{"date": field, "timezone": query.Timezone}}
+}
+
+// dayOf formats a date field as the date it falls on in the query's timezone.
+func dayOf(field string, query StatsQuery) bson.M {
+	return bson.M{"$dateToString": bson.M{"date": field, "format": "%Y-%m-%d", "timezone": query.Timezone}}
+}
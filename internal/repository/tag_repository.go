@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TagRepository handles database operations related to tag settings.
+type TagRepository struct {
+	collection *mongo.Collection
+}
+
+// NewTagRepository creates a new instance of TagRepository.
+func NewTagRepository(db *mongo.Database) *TagRepository {
+	return &TagRepository{
+		collection: db.Collection("tags"),
+	}
+}
+
+// GetTags fetches the tag settings of a user.
+func (r *TagRepository) GetTags(ctx context.Context, userID primitive.ObjectID) ([]models.Tag, error) {
+	tags := []models.Tag{}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags: %v", err)
+	}
+	return tags, nil
+}
+
+// GetTag fetches the settings of a single tag, or nil if the tag has none.
+func (r *TagRepository) GetTag(ctx context.Context, userID primitive.ObjectID, name string) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID, "name": name}).Decode(&tag)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tag: %v", err)
+	}
+	return &tag, nil
+}
+
+// SetColor creates or updates the color of a tag.
+func (r *TagRepository) SetColor(ctx context.Context, userID primitive.ObjectID, name, color string) (*models.Tag, error) {
+	var tag models.Tag
+
+	now := time.Now()
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"user_id": userID, "name": name},
+		bson.M{
+			"$set":         bson.M{"color": color, "updated_at": now},
+			"$setOnInsert": bson.M{"created_at": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tag color: %v", err)
+	}
+	return &tag, nil
+}
+
+// DeleteTags removes the settings of the given tags.
+func (r *TagRepository) DeleteTags(ctx context.Context, userID primitive.ObjectID, names []string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID, "name": bson.M{"$in": names}})
+	if err != nil {
+		return fmt.Errorf("failed to delete tags: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TemplateRepository handles database operations related to goal templates.
+type TemplateRepository struct {
+	collection *mongo.Collection
+}
+
+// NewTemplateRepository creates a new instance of TemplateRepository.
+func NewTemplateRepository(db *mongo.Database) *TemplateRepository {
+	return &TemplateRepository{
+		collection: db.Collection("goal_templates"),
+	}
+}
+
+// UpsertSystemTemplate creates or refreshes a built-in template, identified by its slug.
+func (r *TemplateRepository) UpsertSystemTemplate(ctx context.Context, template *models.GoalTemplate) error {
+	template.System = true
+	template.UpdatedAt = time.Now()
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"slug": template.Slug, "system": true},
+		bson.M{
+			"$set": bson.M{
+				"name":            template.Name,
+				"category":        template.Category,
+				"description":     template.Description,
+				"steps":           template.Steps,
+				"due_offset_days": template.DueOffsetDays,
+				"updated_at":      template.UpdatedAt,
+			},
+			"$setOnInsert": bson.M{"system": true, "slug": template.Slug, "created_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert system template: %v", err)
+	}
+	return nil
+}
+
+// CreateTemplate inserts a new template into the database.
+func (r *TemplateRepository) CreateTemplate(ctx context.Context, template *models.GoalTemplate) (*models.GoalTemplate, error) {
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert template: %v", err)
+	}
+
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast inserted ID")
+	}
+	template.ID = insertedID
+
+	return template, nil
+}
+
+// GetTemplateByID fetches a template by its ID.
+func (r *TemplateRepository) GetTemplateByID(ctx context.Context, id primitive.ObjectID) (*models.GoalTemplate, error) {
+	var template models.GoalTemplate
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find template by id: %v", err)
+	}
+	return &template, nil
+}
+
+// GetTemplates fetches the system templates together with the user's private ones.
+func (r *TemplateRepository) GetTemplates(ctx context.Context, userID primitive.ObjectID, category string) ([]models.GoalTemplate, error) {
+	templates := []models.GoalTemplate{}
+
+	filter := bson.M{"$or": bson.A{bson.M{"system": true}, bson.M{"user_id": userID}}}
+	if category != "" {
+		filter["category"] = category
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "system", Value: -1}, {Key: "name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch templates: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, fmt.Errorf("failed to decode templates: %v", err)
+	}
+	return templates, nil
+}
+
+// UpdateTemplate updates an existing private template.
+func (r *TemplateRepository) UpdateTemplate(ctx context.Context, id primitive.ObjectID, template *models.GoalTemplate) (*models.GoalTemplate, error) {
+	template.UpdatedAt = time.Now()
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "system": false}, bson.M{"$set": template})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update template: %v", err)
+	}
+	return template, nil
+}
+
+// DeleteTemplate deletes a private template.
+func (r *TemplateRepository) DeleteTemplate(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "system": false})
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %v", err)
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // UserRepository handles database operations related to users.
@@ -73,6 +74,22 @@ func (r *UserRepository) UpdateUser(ctx context.Context, id primitive.ObjectID,
 	return user, nil
 }
 
+// UpdateUserFields sets individual fields of a user, leaving every other field untouched.
+func (r *UserRepository) UpdateUserFields(ctx context.Context, id primitive.ObjectID, fields bson.M) (*models.User, error) {
+	set := bson.M{"updated_at": time.Now()}
+	for field, value := range fields {
+		set[field] = value
+	}
+
+	var user models.User
+	findOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": set}, findOptions).Decode(&user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %v", err)
+	}
+	return &user, nil
+}
+
 // DeleteUser deletes a user from the database.
 func (r *UserRepository) DeleteUser(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebhookRepository handles database operations related to webhooks and their deliveries.
+type WebhookRepository struct {
+	collection *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+// NewWebhookRepository creates a new instance of WebhookRepository.
+func NewWebhookRepository(db *mongo.Database) *WebhookRepository {
+	return &WebhookRepository{
+		collection: db.Collection("webhooks"),
+		deliveries: db.Collection("webhook_deliveries"),
+	}
+}
+
+// CreateWebhook inserts a new webhook into the database.
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	webhook.CreatedAt = time.Now()
+	webhook.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert webhook: %v", err)
+	}
+
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast inserted ID")
+	}
+	webhook.ID = insertedID
+
+	return webhook, nil
+}
+
+// GetWebhookByID fetches a webhook by its ID.
+func (r *WebhookRepository) GetWebhookByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&webhook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook by id: %v", err)
+	}
+	return &webhook, nil
+}
+
+// GetWebhooks fetches all webhooks registered by a user.
+func (r *WebhookRepository) GetWebhooks(ctx context.Context, userID primitive.ObjectID) ([]models.Webhook, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+// GetSubscribedWebhooks fetches the active webhooks of a user subscribed to the given event type.
+func (r *WebhookRepository) GetSubscribedWebhooks(ctx context.Context, userID primitive.ObjectID, event string) ([]models.Webhook, error) {
+	return r.find(ctx, bson.M{"user_id": userID, "active": true, "events": event})
+}
+
+// UpdateWebhook updates an existing webhook.
+func (r *WebhookRepository) UpdateWebhook(ctx context.Context, id primitive.ObjectID, webhook *models.Webhook) (*models.Webhook, error) {
+	webhook.UpdatedAt = time.Now()
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": webhook})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %v", err)
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook together with its delivery log.
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %v", err)
+	}
+	if _, err := r.deliveries.DeleteMany(ctx, bson.M{"webhook_id": id}); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %v", err)
+	}
+	return nil
+}
+
+// CreateDelivery stores a delivery attempt in the delivery log.
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	delivery.CreatedAt = time.Now()
+
+	result, err := r.deliveries.InsertOne(ctx, delivery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert webhook delivery: %v", err)
+	}
+
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast inserted ID")
+	}
+	delivery.ID = insertedID
+
+	return delivery, nil
+}
+
+// GetDeliveries fetches the most recent delivery attempts of a webhook.
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, webhookID primitive.ObjectID, limit int64) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+
+	findOptions := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit)
+	cursor, err := r.deliveries.Find(ctx, bson.M{"webhook_id": webhookID}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook deliveries: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook deliveries: %v", err)
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) find(ctx context.Context, filter bson.M) ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhooks: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %v", err)
+	}
+	return webhooks, nil
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// achievementQueueSize is how many goal events can wait for the award workers.
+const achievementQueueSize = 256
+
+// AchievementService awards XP and unlocks achievements as goal events happen,
+// following declarative rules. Awards are idempotent: checking the same step twice,
+// or an event handled twice, never earns anything twice.
+type AchievementService struct {
+	repo          *repository.AchievementRepository
+	rules         *models.AchievementRules
+	goals         *GoalService
+	stats         *StatsService
+	notifications *NotificationService
+	queue         chan models.GoalEvent
+}
+
+// NewAchievementService creates a new instance of AchievementService. Events are queued
+// until RunAwards starts the workers that process them.
+func NewAchievementService(repo *repository.AchievementRepository, rules *models.AchievementRules, goals *GoalService, stats *StatsService, notifications *NotificationService) *AchievementService {
+	return &AchievementService{
+		repo:          repo,
+		rules:         rules,
+		goals:         goals,
+		stats:         stats,
+		notifications: notifications,
+		queue:         make(chan models.GoalEvent, achievementQueueSize),
+	}
+}
+
+// LoadAchievementRules reads and validates the rules in a JSON file.
+func LoadAchievementRules(path string) (*models.AchievementRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read achievement rules: %v", err)
+	}
+
+	var rules models.AchievementRules
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("failed to parse achievement rules: %v", err)
+	}
+	if err := ValidateAchievementRules(&rules); err != nil {
+		return nil, fmt.Errorf("invalid achievement rules: %v", err)
+	}
+	return &rules, nil
+}
+
+// ValidateAchievementRules checks that rules only use known events and metrics, that
+// achievement IDs are unique and that levels start at 0 XP and keep increasing.
+func ValidateAchievementRules(rules *models.AchievementRules) error {
+	for event, xp := range rules.XP {
+		if !models.GoalEventTypes[event] {
+			return fmt.Errorf("xp: unknown event %q", event)
+		}
+		if xp < 0 {
+			return fmt.Errorf("xp: %s must not be negative", event)
+		}
+	}
+
+	if len(rules.Levels) == 0 || rules.Levels[0] != 0 {
+		return fmt.Errorf("levels must start at 0 XP")
+	}
+	for i := 1; i < len(rules.Levels); i++ {
+		if rules.Levels[i] <= rules.Levels[i-1] {
+			return fmt.Errorf("levels must need increasing XP")
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, rule := range rules.Achievements {
+		switch {
+		case rule.ID == "":
+			return fmt.Errorf("achievement id is required")
+		case seen[rule.ID]:
+			return fmt.Errorf("achievement %q is defined twice", rule.ID)
+		case rule.Name == "":
+			return fmt.Errorf("achievement %q: name is required", rule.ID)
+		case !models.GoalEventTypes[rule.On]:
+			return fmt.Errorf("achievement %q: unknown event %q", rule.ID, rule.On)
+		case !models.AchievementMetrics[rule.Metric]:
+			return fmt.Errorf("achievement %q: unknown metric %q", rule.ID, rule.Metric)
+		case rule.Metric != models.MetricBeforeDeadline && rule.Threshold < 1:
+			return fmt.Errorf("achievement %q: threshold must be at least 1", rule.ID)
+		case rule.XP < 0:
+			return fmt.Errorf("achievement %q: xp must not be negative", rule.ID)
+		}
+		seen[rule.ID] = true
+	}
+	return nil
+}
+
+// HandleGoalEvent is a GoalEventListener that queues the event so that the user who caused
+// it earns its XP and the achievements it completes. Changes made by the system earn
+// nothing. The work happens in the background so that it doesn't slow down the request
+// that changed the goal; when the queue is full, the event is dropped.
+func (s *AchievementService) HandleGoalEvent(ctx context.Context, event models.GoalEvent) {
+	if event.Goal == nil || event.ActorID.IsZero() || !models.GoalEventTypes[event.Type] {
+		return
+	}
+	select {
+	case s.queue <- event:
+	default:
+		log.Printf("Achievement queue is full, dropping event %s", event.ID.Hex())
+	}
+}
+
+// RunAwards processes queued events with the given number of workers until ctx is
+// cancelled, and returns once every worker has stopped. At least one worker runs.
+func (s *AchievementService) RunAwards(ctx context.Context, workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event := <-s.queue:
+					s.process(ctx, event)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *AchievementService) process(ctx context.Context, event models.GoalEvent) {
+	if xp := s.rules.XP[event.Type]; xp > 0 {
+		if err := s.award(ctx, event.ActorID, eventKey(event), xp); err != nil {
+			log.Printf("Failed to award XP for event %s: %v", event.ID.Hex(), err)
+		}
+	}
+
+	unlocked, err := s.repo.GetAchievements(ctx, event.ActorID)
+	if err != nil {
+		log.Printf("Failed to load achievements: %v", err)
+		return
+	}
+	have := make(map[string]bool, len(unlocked))
+	for _, achievement := range unlocked {
+		have[achievement.AchievementID] = true
+	}
+
+	for _, rule := range s.rules.Achievements {
+		if rule.On != event.Type || have[rule.ID] {
+			continue
+		}
+		met, err := s.met(ctx, rule, event)
+		if err != nil {
+			log.Printf("Failed to check achievement %s: %v", rule.ID, err)
+			continue
+		}
+		if met {
+			if err := s.unlock(ctx, rule, event); err != nil {
+				log.Printf("Failed to unlock achievement %s: %v", rule.ID, err)
+			}
+		}
+	}
+}
+
+// met reports whether the metric of a rule reached its threshold.
+func (s *AchievementService) met(ctx context.Context, rule models.AchievementRule, event models.GoalEvent) (bool, error) {
+	var value int
+	var err error
+	switch rule.Metric {
+	case models.MetricGoalsCompleted:
+		value, err = s.goals.CountGoals(ctx, event.ActorID, rule.Category, true)
+	case models.MetricGoalsCreated:
+		value, err = s.goals.CountGoals(ctx, event.ActorID, rule.Category, false)
+	case models.MetricStepsCompleted:
+		value, err = s.goals.CountCompletedSteps(ctx, event.ActorID)
+	case models.MetricStreak:
+		var calendar *models.ActivityCalendar
+		calendar, err = s.stats.GetActivity(ctx, event.ActorID, rule.Threshold)
+		if err == nil {
+			value = calendar.CurrentStreak
+		}
+	case models.MetricBeforeDeadline:
+		return CompletedBeforeDeadline(event.Goal, rule.Category, s.goals.Location(ctx, event.ActorID)), nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return value >= rule.Threshold, nil
+}
+
+func (s *AchievementService) unlock(ctx context.Context, rule models.AchievementRule, event models.GoalEvent) error {
+	newly, err := s.repo.Unlock(ctx, &models.UserAchievement{
+		UserID:        event.ActorID,
+		AchievementID: rule.ID,
+		Name:          rule.Name,
+		Description:   rule.Description,
+		Icon:          rule.Icon,
+		XP:            rule.XP,
+		GoalID:        event.GoalID,
+	})
+	if err != nil || !newly {
+		return err
+	}
+
+	s.notify(ctx, &models.Notification{
+		UserID: event.ActorID,
+		Type:   models.NotificationAchievementUnlocked,
+		Title:  "Achievement unlocked: " + rule.Name,
+		Body:   rule.Description,
+		Data:   map[string]string{"achievement_id": rule.ID, "goal_id": event.GoalID.Hex()},
+	})
+	if rule.XP > 0 {
+		return s.award(ctx, event.ActorID, "achievement:"+rule.ID, rule.XP)
+	}
+	return nil
+}
+
+// award records XP under key and announces the levels it takes the user to. Levels
+// reached are recorded as awards without XP, so that each level is announced once.
+func (s *AchievementService) award(ctx context.Context, userID primitive.ObjectID, key string, xp int) error {
+	newly, err := s.repo.AwardXP(ctx, &models.XPAward{UserID: userID, Key: key, XP: xp})
+	if err != nil || !newly {
+		return err
+	}
+
+	total, err := s.repo.TotalXP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	before, _, _ := Level(total-xp, s.rules.Levels)
+	after, _, _ := Level(total, s.rules.Levels)
+	for level := before + 1; level <= after; level++ {
+		reached, err := s.repo.AwardXP(ctx, &models.XPAward{UserID: userID, Key: fmt.Sprintf("level:%d", level)})
+		if err != nil {
+			return err
+		}
+		if reached {
+			s.notify(ctx, &models.Notification{
+				UserID: userID,
+				Type:   models.NotificationLevelUp,
+				Title:  fmt.Sprintf("You reached level %d", level),
+				Data:   map[string]string{"level": fmt.Sprint(level)},
+			})
+		}
+	}
+	return nil
+}
+
+func (s *AchievementService) notify(ctx context.Context, notification *models.Notification) {
+	if _, err := s.notifications.Notify(ctx, notification); err != nil {
+		log.Printf("Failed to notify user %s: %v", notification.UserID.Hex(), err)
+	}
+}
+
+// GetSummary returns a user's XP, level and achievements.
+func (s *AchievementService) GetSummary(ctx context.Context, userID primitive.ObjectID) (*models.AchievementSummary, error) {
+	xp, err := s.repo.TotalXP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	unlocked, err := s.repo.GetAchievements(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.AchievementSummary{XP: xp, Unlocked: unlocked, Locked: []models.AchievementRule{}}
+	summary.Level, summary.LevelXP, summary.NextLevelXP = Level(xp, s.rules.Levels)
+
+	have := make(map[string]bool, len(unlocked))
+	for _, achievement := range unlocked {
+		have[achievement.AchievementID] = true
+	}
+	for _, rule := range s.rules.Achievements {
+		if !have[rule.ID] {
+			summary.Locked = append(summary.Locked, rule)
+		}
+	}
+	return summary, nil
+}
+
+// Level returns the level reached with xp, the XP that level needs and the XP the next
+// level needs, or 0 at the highest level. levels holds the XP needed from level 1 on.
+func Level(xp int, levels []int) (level, levelXP, nextLevelXP int) {
+	for level < len(levels) && xp >= levels[level] {
+		levelXP = levels[level]
+		level++
+	}
+	if level < len(levels) {
+		nextLevelXP = levels[level]
+	}
+	return level, levelXP, nextLevelXP
+}
+
+// CompletedBeforeDeadline reports whether a completed goal, of category if it isn't
+// empty, was completed before its deadline in loc.
+func CompletedBeforeDeadline(goal *models.Goal, category string, loc *time.Location) bool {
+	if goal.Status != "completed" || goal.CompletedAt == nil || goal.DueDate.IsZero() {
+		return false
+	}
+	if category != "" && goal.Category != category {
+		return false
+	}
+	return goal.CompletedAt.Before(goal.Deadline(loc))
+}
+
+// eventKey identifies what an event awards XP for: a step of a goal, or the goal itself.
+func eventKey(event models.GoalEvent) string {
+	if event.Type == models.EventStepCompleted {
+		return fmt.Sprintf("%s:%s:%s", event.Type, event.GoalID.Hex(), event.Step)
+	}
+	return fmt.Sprintf("%s:%s", event.Type, event.GoalID.Hex())
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestLoadAchievementRules tests that the shipped rules file is valid.
+func TestLoadAchievementRules(t *testing.T) {
+	rules, err := LoadAchievementRules("../../config/achievements.json")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, rules.XP[models.EventStepCompleted])
+	assert.NotEmpty(t, rules.Achievements)
+
+	_, err = LoadAchievementRules("../../config/missing.json")
+	assert.Error(t, err)
+}
+
+// TestValidateAchievementRules tests that unknown events and metrics, duplicate IDs and
+// unordered levels are rejected.
+func TestValidateAchievementRules(t *testing.T) {
+	valid := func() *models.AchievementRules {
+		return &models.AchievementRules{
+			XP:     map[string]int{models.EventGoalCompleted: 50},
+			Levels: []int{0, 100},
+			Achievements: []models.AchievementRule{
+				{ID: "first", Name: "First", On: models.EventGoalCompleted, Metric: models.MetricGoalsCompleted, Threshold: 1},
+				{ID: "early", Name: "Early", On: models.EventGoalCompleted, Metric: models.MetricBeforeDeadline},
+			},
+		}
+	}
+	assert.NoError(t, ValidateAchievementRules(valid()))
+
+	rules := valid()
+	rules.XP["goal.exploded"] = 5
+	assert.Error(t, ValidateAchievementRules(rules))
+
+	rules = valid()
+	rules.Levels = []int{0, 100, 100}
+	assert.Error(t, ValidateAchievementRules(rules))
+
+	rules = valid()
+	rules.Achievements[1].ID = "first"
+	assert.Error(t, ValidateAchievementRules(rules))
+
+	rules = valid()
+	rules.Achievements[0].Metric = "coffee_drunk"
+	assert.Error(t, ValidateAchievementRules(rules))
+
+	rules = valid()
+	rules.Achievements[0].Threshold = 0
+	assert.Error(t, ValidateAchievementRules(rules))
+}
+
+// TestLevel tests the level reached with an amount of XP.
+func TestLevel(t *testing.T) {
+	levels := []int{0, 100, 250}
+
+	level, levelXP, next := Level(0, levels)
+	assert.Equal(t, []int{1, 0, 100}, []int{level, levelXP, next})
+
+	level, levelXP, next = Level(120, levels)
+	assert.Equal(t, []int{2, 100, 250}, []int{level, levelXP, next})
+
+	level, levelXP, next = Level(900, levels)
+	assert.Equal(t, []int{3, 250, 0}, []int{level, levelXP, next})
+}
+
+// TestCompletedBeforeDeadline tests that all-day deadlines end in the user's timezone.
+func TestCompletedBeforeDeadline(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+
+	// Completed at 20:00 UTC on the due day, which is already the next day in Tokyo
+	completedAt := time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC)
+	goal := &models.Goal{
+		Category:    "Health",
+		Status:      "completed",
+		CompletedAt: &completedAt,
+		DueDate:     time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		DueAllDay:   true,
+	}
+	assert.True(t, CompletedBeforeDeadline(goal, "", time.UTC))
+	assert.False(t, CompletedBeforeDeadline(goal, "", tokyo))
+	assert.False(t, CompletedBeforeDeadline(goal, "Career", time.UTC))
+
+	goal.DueDate = time.Time{}
+	assert.False(t, CompletedBeforeDeadline(goal, "", time.UTC))
+}
+
+// TestAchievementQueue tests that events are queued for the user who caused them, that
+// system changes are skipped and that a full queue drops events instead of blocking.
+func TestAchievementQueue(t *testing.T) {
+	s := NewAchievementService(nil, &models.AchievementRules{}, nil, nil, nil)
+	event := models.GoalEvent{Type: models.EventStepCompleted, Goal: &models.Goal{}, ActorID: primitive.NewObjectID()}
+
+	s.HandleGoalEvent(context.Background(), models.GoalEvent{Type: models.EventStepCompleted, Goal: &models.Goal{}})
+	assert.Len(t, s.queue, 0)
+
+	for i := 0; i < achievementQueueSize+1; i++ {
+		s.HandleGoalEvent(context.Background(), event)
+	}
+	assert.Len(t, s.queue, achievementQueueSize)
+	assert.Equal(t, event.ActorID, (<-s.queue).ActorID)
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"github.com/czeful/diplom_back/internal/storage"
+	"github.com/czeful/diplom_back/pkg/imaging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ThumbnailSize is the largest width or height of attachment thumbnails, in pixels.
+const ThumbnailSize = 256
+
+// AllowedAttachmentTypes are the content types accepted for attachments. The type is
+// detected from the content, not taken from the client.
+var AllowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+var (
+	// ErrAttachmentTooLarge is returned when a file exceeds the maximum attachment size
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	// ErrUnsupportedType is returned when a file is not of an allowed content type
+	ErrUnsupportedType = errors.New("unsupported attachment type")
+	// ErrQuotaExceeded is returned when a file doesn't fit in the user's storage quota
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+)
+
+// AttachmentLimits bounds the attachments of each user, in bytes.
+type AttachmentLimits struct {
+	MaxSize int64
+	Quota   int64
+}
+
+// AttachmentService encapsulates the business logic for goal attachments.
+type AttachmentService struct {
+	repo   *repository.AttachmentRepository
+	store  storage.BlobStore
+	limits AttachmentLimits
+}
+
+// NewAttachmentService creates a new instance of AttachmentService.
+func NewAttachmentService(repo *repository.AttachmentRepository, store storage.BlobStore, limits AttachmentLimits) *AttachmentService {
+	return &AttachmentService{
+		repo:   repo,
+		store:  store,
+		limits: limits,
+	}
+}
+
+// Limits returns the size limits attachments are subject to.
+func (s *AttachmentService) Limits() AttachmentLimits {
+	return s.limits
+}
+
+// Upload stores a file as an attachment of the goal, along with a thumbnail for images.
+func (s *AttachmentService) Upload(ctx context.Context, goal *models.Goal, fileName string, r io.Reader) (*models.Attachment, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.limits.MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %v", err)
+	}
+	if int64(len(data)) > s.limits.MaxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	contentType := DetectContentType(data)
+	if !AllowedAttachmentTypes[contentType] {
+		return nil, ErrUnsupportedType
+	}
+	if err := s.checkQuota(ctx, goal.UserID, int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		ID:          primitive.NewObjectID(),
+		GoalID:      goal.ID,
+		UserID:      goal.UserID,
+		FileName:    SanitizeFileName(fileName),
+		ContentType: contentType,
+	}
+	attachment.BlobKey = attachmentKey(attachment)
+	if attachment.Size, err = s.store.Put(ctx, attachment.BlobKey, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	// Thumbnails are a convenience; images the decoder can't handle, like WebP, go without
+	if thumbnail, err := makeThumbnail(data); err == nil {
+		key := attachment.BlobKey + "-thumb"
+		if _, err := s.store.Put(ctx, key, bytes.NewReader(thumbnail)); err == nil {
+			attachment.ThumbnailKey = key
+			attachment.HasThumbnail = true
+		}
+	}
+
+	createdAttachment, err := s.repo.CreateAttachment(ctx, attachment)
+	if err != nil {
+		s.deleteBlobs(ctx, attachment)
+		return nil, err
+	}
+	return createdAttachment, nil
+}
+
+// GetAttachment retrieves an attachment by its ID.
+func (s *AttachmentService) GetAttachment(ctx context.Context, id string) (*models.Attachment, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attachment ID: %v", err)
+	}
+	attachment, err := s.repo.GetAttachmentByID(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %v", err)
+	}
+	return attachment, nil
+}
+
+// GetAttachments retrieves the attachments of a goal, newest first.
+func (s *AttachmentService) GetAttachments(ctx context.Context, goalID primitive.ObjectID) ([]models.Attachment, error) {
+	return s.repo.GetAttachmentsByGoal(ctx, goalID)
+}
+
+// Open opens the content of an attachment, or its thumbnail.
+func (s *AttachmentService) Open(ctx context.Context, attachment *models.Attachment, thumbnail bool) (storage.Blob, error) {
+	if !thumbnail {
+		return s.store.Open(ctx, attachment.BlobKey)
+	}
+	if !attachment.HasThumbnail {
+		return nil, storage.ErrNotFound
+	}
+	return s.store.Open(ctx, attachment.ThumbnailKey)
+}
+
+// DeleteAttachment removes an attachment and its blobs.
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, attachment *models.Attachment) error {
+	if err := s.repo.DeleteAttachment(ctx, attachment.ID); err != nil {
+		return err
+	}
+	s.deleteBlobs(ctx, attachment)
+	return nil
+}
+
+// GetStorageUsage reports how much of the storage quota a user has used.
+func (s *AttachmentService) GetStorageUsage(ctx context.Context, userID primitive.ObjectID) (*models.StorageUsage, error) {
+	used, err := s.repo.GetStorageUsed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.StorageUsage{Used: used, Quota: s.limits.Quota}, nil
+}
+
+// HandleGoalEvent is a GoalEventListener that removes the attachments of purged goals.
+// Soft-deleted goals keep their attachments so that restoring them is lossless.
+func (s *AttachmentService) HandleGoalEvent(ctx context.Context, event models.GoalEvent) {
+	if event.Type != models.EventGoalPurged {
+		return
+	}
+	attachments, err := s.repo.GetAttachmentsByGoal(ctx, event.GoalID)
+	if err != nil {
+		log.Printf("Failed to load attachments of purged goal %s: %v", event.GoalID.Hex(), err)
+		return
+	}
+	for i := range attachments {
+		if err := s.DeleteAttachment(ctx, &attachments[i]); err != nil {
+			log.Printf("Failed to delete attachment %s: %v", attachments[i].ID.Hex(), err)
+		}
+	}
+}
+
+// CopyAttachments is a CloneHook that copies the attachments of the source goal to the
+// clone when the clone options ask for it. Either every attachment is copied or, when
+// they don't all fit in the storage quota or a copy fails, none is.
+func (s *AttachmentService) CopyAttachments(ctx context.Context, source, clone *models.Goal, opts CloneOptions) error {
+	if !opts.CopyAttachments {
+		return nil
+	}
+	attachments, err := s.repo.GetAttachmentsByGoal(ctx, source.ID)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, attachment := range attachments {
+		total += attachment.Size
+	}
+	if err := s.checkQuota(ctx, clone.UserID, total); err != nil {
+		return err
+	}
+
+	copies := make([]models.Attachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		copied, err := s.copyAttachment(ctx, attachment, clone)
+		if err != nil {
+			for i := range copies {
+				if err := s.DeleteAttachment(ctx, &copies[i]); err != nil {
+					log.Printf("Failed to roll back copied attachment %s: %v", copies[i].ID.Hex(), err)
+				}
+			}
+			return err
+		}
+		copies = append(copies, *copied)
+	}
+	return nil
+}
+
+// copyAttachment copies an attachment and its blobs to another goal.
+func (s *AttachmentService) copyAttachment(ctx context.Context, attachment models.Attachment, goal *models.Goal) (*models.Attachment, error) {
+	copied := attachment
+	copied.ID = primitive.NewObjectID()
+	copied.GoalID = goal.ID
+	copied.UserID = goal.UserID
+	copied.BlobKey = attachmentKey(&copied)
+	copied.ThumbnailKey = ""
+	copied.HasThumbnail = false
+
+	if err := s.copyBlob(ctx, attachment.BlobKey, copied.BlobKey); err != nil {
+		return nil, err
+	}
+	if attachment.HasThumbnail && s.copyBlob(ctx, attachment.ThumbnailKey, copied.BlobKey+"-thumb") == nil {
+		copied.ThumbnailKey = copied.BlobKey + "-thumb"
+		copied.HasThumbnail = true
+	}
+	createdAttachment, err := s.repo.CreateAttachment(ctx, &copied)
+	if err != nil {
+		s.deleteBlobs(ctx, &copied)
+		return nil, err
+	}
+	return createdAttachment, nil
+}
+
+// checkQuota returns ErrQuotaExceeded when size more bytes don't fit in the user's quota.
+// Space is not reserved: usage is summed from the stored attachments, so concurrent uploads
+// that each fit on their own can together exceed the quota, by at most the maximum
+// attachment size per upload. A per-user usage counter updated with $inc would close this.
+func (s *AttachmentService) checkQuota(ctx context.Context, userID primitive.ObjectID, size int64) error {
+	used, err := s.repo.GetStorageUsed(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if used+size > s.limits.Quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (s *AttachmentService) copyBlob(ctx context.Context, from, to string) error {
+	blob, err := s.store.Open(ctx, from)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+	_, err = s.store.Put(ctx, to, blob)
+	return err
+}
+
+func (s *AttachmentService) deleteBlobs(ctx context.Context, attachment *models.Attachment) {
+	for _, key := range []string{attachment.BlobKey, attachment.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// attachmentKey returns the blob key of an attachment's content.
+func attachmentKey(attachment *models.Attachment) string {
+	return "attachments/" + attachment.UserID.Hex() + "/" + attachment.ID.Hex()
+}
+
+// makeThumbnail scales a JPEG, PNG or GIF image down to ThumbnailSize.
+func makeThumbnail(data []byte) ([]byte, error) {
+	img, format, err := imaging.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, imaging.Fit(img, ThumbnailSize), format); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DetectContentType sniffs the media type of a file from its content, without parameters.
+func DetectContentType(data []byte) string {
+	contentType := http.DetectContentType(data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType
+}
+
+// SanitizeFileName reduces a client-supplied file name to a safe base name.
+func SanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if len(name) > 255 {
+		name = strings.ToValidUTF8(name[:255], "")
+	}
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSanitizeFileName tests that client file names are reduced to safe base names.
+func TestSanitizeFileName(t *testing.T) {
+	assert.Equal(t, "receipt.pdf", SanitizeFileName("receipt.pdf"))
+	assert.Equal(t, "passwd", SanitizeFileName("../../etc/passwd"))
+	assert.Equal(t, "photo.jpg", SanitizeFileName(`C:\Users\me\photo.jpg`))
+	assert.Equal(t, "evil.txt", SanitizeFileName("evil\".txt\r\n"))
+	assert.Equal(t, "attachment", SanitizeFileName(""))
+}
+
+// TestAttachmentContent tests content type detection and thumbnails of uploaded files.
+func TestAttachmentContent(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1024, 512))))
+
+	assert.Equal(t, "image/png", DetectContentType(buf.Bytes()))
+	assert.Equal(t, "text/plain", DetectContentType([]byte("plain notes")))
+	assert.False(t, AllowedAttachmentTypes[DetectContentType([]byte("<html><script></script></html>"))])
+
+	thumbnail, err := makeThumbnail(buf.Bytes())
+	assert.NoError(t, err)
+	config, _, err := image.DecodeConfig(bytes.NewReader(thumbnail))
+	assert.NoError(t, err)
+	assert.Equal(t, ThumbnailSize, config.Width)
+	assert.Equal(t, ThumbnailSize/2, config.Height)
+
+	_, err = makeThumbnail([]byte("%PDF-1.4"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrCategoryExists is returned when a category name is already taken
+	ErrCategoryExists = errors.New("category already exists")
+	// ErrCategoryInUse is returned when deleting a category that goals still use
+	ErrCategoryInUse = errors.New("category is in use")
+)
+
+// CategoryService encapsulates the business logic for user-defined categories.
+type CategoryService struct {
+	repo  *repository.CategoryRepository
+	goals *GoalService
+}
+
+// NewCategoryService creates a new instance of CategoryService.
+func NewCategoryService(repo *repository.CategoryRepository, goals *GoalService) *CategoryService {
+	return &CategoryService{
+		repo:  repo,
+		goals: goals,
+	}
+}
+
+// GetCategories lists the default categories followed by the user's own.
+func (s *CategoryService) GetCategories(ctx context.Context, userID primitive.ObjectID) ([]models.Category, error) {
+	var categories []models.Category
+	for name := range models.AllowedCategories {
+		categories = append(categories, models.Category{Name: name, Default: true})
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+
+	custom, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return append(categories, custom...), nil
+}
+
+// CategoryNames returns the names of every category available to the user. It is
+// the CategoryLookup of the goal service.
+func (s *CategoryService) CategoryNames(ctx context.Context, userID primitive.ObjectID) (map[string]bool, error) {
+	custom, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for name := range models.AllowedCategories {
+		names[name] = true
+	}
+	for _, category := range custom {
+		names[category.Name] = true
+	}
+	return names, nil
+}
+
+// GetCategory retrieves a user-defined category by its ID.
+func (s *CategoryService) GetCategory(ctx context.Context, id string) (*models.Category, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid category ID: %v", err)
+	}
+	category, err := s.repo.GetCategoryByID(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %v", err)
+	}
+	return category, nil
+}
+
+// CreateCategory stores a new category for the user.
+func (s *CategoryService) CreateCategory(ctx context.Context, category *models.Category) (*models.Category, error) {
+	if err := s.checkNameFree(ctx, category.UserID, category.Name); err != nil {
+		return nil, err
+	}
+	category.Default = false
+	createdCategory, err := s.repo.CreateCategory(ctx, category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create category: %v", err)
+	}
+	return createdCategory, nil
+}
+
+// UpdateCategory renames or recolors a user-defined category. Renaming moves the user's
+// goals to the new name.
+func (s *CategoryService) UpdateCategory(ctx context.Context, existing *models.Category, category *models.Category) (*models.Category, error) {
+	if !strings.EqualFold(category.Name, existing.Name) {
+		if err := s.checkNameFree(ctx, existing.UserID, category.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	category.ID = existing.ID
+	category.UserID = existing.UserID
+	category.CreatedAt = existing.CreatedAt
+	updatedCategory, err := s.repo.UpdateCategory(ctx, existing.ID, category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update category: %v", err)
+	}
+
+	if category.Name != existing.Name {
+		if _, err := s.goals.RenameCategory(ctx, existing.UserID, existing.Name, category.Name); err != nil {
+			return nil, fmt.Errorf("failed to rename category on goals: %v", err)
+		}
+	}
+	return updatedCategory, nil
+}
+
+// DeleteCategory removes a user-defined category. Categories still used by a goal,
+// including goals in the trash, can't be deleted.
+func (s *CategoryService) DeleteCategory(ctx context.Context, category *models.Category) error {
+	count, err := s.goals.CountGoalsInCategory(ctx, category.UserID, category.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryInUse
+	}
+	return s.repo.DeleteCategory(ctx, category.ID)
+}
+
+func (s *CategoryService) checkNameFree(ctx context.Context, userID primitive.ObjectID, name string) error {
+	names, err := s.CategoryNames(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for existing := range names {
+		if strings.EqualFold(existing, name) {
+			return ErrCategoryExists
+		}
+	}
+	return nil
+}
+
+// ValidateCategory checks the name and color of a category.
+func ValidateCategory(category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return fmt.Errorf("category name is required")
+	}
+	if len(category.Name) > MaxTagLength {
+		return fmt.Errorf("category name is longer than %d characters", MaxTagLength)
+	}
+	if category.Color != "" {
+		return ValidateColor(category.Color)
+	}
+	return nil
+}
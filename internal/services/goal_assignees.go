@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidAssignee is returned when a step is assigned to a user who can't edit the goal.
+var ErrInvalidAssignee = errors.New("assignee can't edit the goal")
+
+// AssignStep assigns a step of a goal to a user who may edit it, or unassigns it when
+// assignee is nil, on behalf of actor. It fails with repository.ErrVersionConflict if the
+// goal changed since it was read.
+func (s *GoalService) AssignStep(ctx context.Context, goal *models.Goal, step string, assignee *primitive.ObjectID, actor primitive.ObjectID) (*models.Goal, error) {
+	if _, exists := goal.Progress[step]; !exists {
+		return nil, ErrStepNotFound
+	}
+	if assignee != nil {
+		role, err := s.Role(ctx, goal, *assignee)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check assignee: %v", err)
+		}
+		if !RoleAllows(role, ActionEdit) {
+			return nil, ErrInvalidAssignee
+		}
+	}
+
+	return s.setAssignees(ctx, goal, actor, func(current *primitive.ObjectID, name string) *primitive.ObjectID {
+		if name == step {
+			return assignee
+		}
+		return current
+	})
+}
+
+// UnassignUser clears the assignments of a user on a goal, for when actor takes their
+// access to it away.
+func (s *GoalService) UnassignUser(ctx context.Context, goal *models.Goal, userID, actor primitive.ObjectID) error {
+	_, err := s.setAssignees(ctx, goal, actor, func(current *primitive.ObjectID, _ string) *primitive.ObjectID {
+		if current != nil && *current == userID {
+			return nil
+		}
+		return current
+	})
+	return err
+}
+
+// setAssignees rewrites the assignee of every step of a goal with assign and saves the
+// step details if any changed.
+func (s *GoalService) setAssignees(ctx context.Context, goal *models.Goal, actor primitive.ObjectID, assign func(current *primitive.ObjectID, step string) *primitive.ObjectID) (*models.Goal, error) {
+	meta := make(map[string]models.StepMeta, len(goal.StepMeta))
+	changed := false
+	for _, step := range goal.Steps {
+		details := goal.StepMeta[step]
+		assignee := assign(details.AssigneeID, step)
+		if (assignee == nil) != (details.AssigneeID == nil) || (assignee != nil && *assignee != *details.AssigneeID) {
+			changed = true
+		}
+		details.AssigneeID = assignee
+		if details != (models.StepMeta{}) {
+			meta[step] = details
+		}
+	}
+	if !changed {
+		return goal, nil
+	}
+
+	set, unset := bson.M{"step_meta": meta}, []string(nil)
+	if len(meta) == 0 {
+		set, unset = bson.M{}, []string{"step_meta"}
+	}
+	updated, err := s.repo.UpdateGoalFields(ctx, goal.ID, set, unset, goal.Version)
+	if errors.Is(err, repository.ErrNoMatch) {
+		return nil, fmt.Errorf("failed to assign step: %w", repository.ErrVersionConflict)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign step: %v", err)
+	}
+	s.emitUpdate(ctx, goal, updated, actor)
+	return updated, nil
+}
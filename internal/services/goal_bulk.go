@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Bulk operation types accepted by BulkUpdate.
+const (
+	BulkSetStatus     = "set_status"
+	BulkSetCategory   = "set_category"
+	BulkAddTag        = "add_tag"
+	BulkArchive       = "archive"
+	BulkDelete        = "delete"
+	BulkCompleteSteps = "complete_steps"
+)
+
+// MaxBulkItems limits the number of goal operations in a single bulk request.
+const MaxBulkItems = 500
+
+// BulkOperation applies one action to a list of goals.
+type BulkOperation struct {
+	Op    string   `json:"op"`
+	IDs   []string `json:"ids"`
+	Value string   `json:"value,omitempty"`
+	// Steps limits complete_steps to the listed steps; all steps are completed when empty
+	Steps []string `json:"steps,omitempty"`
+}
+
+// BulkRequest is a list of operations, optionally executed all-or-nothing.
+type BulkRequest struct {
+	Atomic     bool            `json:"atomic"`
+	Operations []BulkOperation `json:"operations"`
+}
+
+// BulkItemResult is the outcome of one operation on one goal.
+type BulkItemResult struct {
+	Op      string `json:"op"`
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkResult reports the per-item outcome of a bulk request.
+type BulkResult struct {
+	Atomic      bool             `json:"atomic"`
+	Transaction bool             `json:"transaction"`
+	Succeeded   int              `json:"succeeded"`
+	Failed      int              `json:"failed"`
+	Results     []BulkItemResult `json:"results"`
+}
+
+// ErrBulkRolledBack is returned when an atomic bulk request was not applied.
+var ErrBulkRolledBack = errors.New("bulk request rolled back")
+
+// BulkUpdate applies the operations to personal goals owned by userID. Operations are first applied
+// in memory, then every touched goal is written once with a conditional bulk write. In atomic
+// mode nothing is written unless every item succeeds, and ErrBulkRolledBack is returned
+// together with the per-item results.
+func (s *GoalService) BulkUpdate(ctx context.Context, userID primitive.ObjectID, request BulkRequest) (*BulkResult, error) {
+	result := &BulkResult{Atomic: request.Atomic, Results: []BulkItemResult{}}
+
+	// Load every referenced goal the user owns in one query
+	var ids []primitive.ObjectID
+	for _, operation := range request.Operations {
+		for _, id := range operation.IDs {
+			if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+				ids = append(ids, objID)
+			}
+		}
+	}
+	goals, err := s.repo.GetGoalsByIDs(ctx, userID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch goals: %v", err)
+	}
+	previous := make(map[string]*models.Goal)
+	current := make(map[string]*models.Goal)
+	for i := range goals {
+		id := goals[i].ID.Hex()
+		copied := goals[i]
+		previous[id] = &goals[i]
+		current[id] = &copied
+		copied.Progress = make(map[string]bool)
+		for step, done := range goals[i].Progress {
+			copied.Progress[step] = done
+		}
+		copied.Tags = append([]string(nil), goals[i].Tags...)
+	}
+
+	categories, err := s.Categories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %v", err)
+	}
+
+	// Apply the operations in memory, in order
+	itemsByGoal := make(map[string][]int)
+	now := time.Now()
+	for _, operation := range request.Operations {
+		for _, id := range operation.IDs {
+			item := BulkItemResult{Op: operation.Op, ID: id, Success: true}
+			goal, exists := current[id]
+			switch {
+			case !exists:
+				item.Success, item.Error = false, "goal not found"
+			case goal.DeletedAt != nil:
+				item.Success, item.Error = false, "goal is deleted"
+			default:
+				if err := applyBulkOperation(goal, operation, categories, now); err != nil {
+					item.Success, item.Error = false, err.Error()
+				}
+			}
+			if item.Success {
+				itemsByGoal[id] = append(itemsByGoal[id], len(result.Results))
+			}
+			result.Results = append(result.Results, item)
+		}
+	}
+
+	if request.Atomic && countFailed(result.Results) > 0 {
+		return s.rollBack(result, "not applied: another item failed"), ErrBulkRolledBack
+	}
+
+	// Turn each touched goal into a single conditional write
+	var writes []repository.GoalWrite
+	for id := range itemsByGoal {
+		applyStepLifecycle(previous[id], current[id], now, userID)
+		write, err := goalWrite(previous[id], current[id])
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff goal: %v", err)
+		}
+		writes = append(writes, write)
+	}
+
+	applied, transaction, err := s.repo.BulkUpdateGoals(ctx, writes, request.Atomic)
+	result.Transaction = transaction
+	if errors.Is(err, repository.ErrBulkIncomplete) {
+		return s.rollBack(result, "not applied: a goal was modified concurrently"), ErrBulkRolledBack
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for id, items := range itemsByGoal {
+		goal := current[id]
+		if !applied[goal.ID] {
+			for _, i := range items {
+				result.Results[i].Success, result.Results[i].Error = false, "goal was modified concurrently"
+			}
+			continue
+		}
+
+		goal.Version = previous[id].Version + 1
+		goal.UpdatedAt = now
+		if goal.DeletedAt != nil {
+			s.emit(ctx, models.EventGoalDeleted, previous[id], goal, "", userID)
+		} else {
+			s.emitUpdate(ctx, previous[id], goal, userID)
+		}
+	}
+
+	result.Failed = countFailed(result.Results)
+	result.Succeeded = len(result.Results) - result.Failed
+	return result, nil
+}
+
+func (s *GoalService) rollBack(result *BulkResult, reason string) *BulkResult {
+	for i := range result.Results {
+		if result.Results[i].Success {
+			result.Results[i].Success, result.Results[i].Error = false, reason
+		}
+	}
+	result.Failed = len(result.Results)
+	result.Succeeded = 0
+	return result
+}
+
+// applyBulkOperation applies a single bulk operation to the in-memory goal. categories
+// holds the categories the owner can assign.
+func applyBulkOperation(goal *models.Goal, operation BulkOperation, categories map[string]bool, now time.Time) error {
+	switch operation.Op {
+	case BulkSetStatus:
+		if operation.Value == "" {
+			return fmt.Errorf("status is required")
+		}
+		goal.CompletedAt = completionTime(goal, operation.Value)
+		goal.Status = operation.Value
+	case BulkSetCategory:
+		if operation.Value != "" && !categories[operation.Value] {
+			return fmt.Errorf("invalid category")
+		}
+		goal.Category = operation.Value
+	case BulkAddTag:
+		if err := ValidateTagName(operation.Value); err != nil {
+			return err
+		}
+		for _, tag := range goal.Tags {
+			if tag == operation.Value {
+				return nil
+			}
+		}
+		goal.Tags = append(goal.Tags, operation.Value)
+	case BulkArchive:
+		if goal.ArchivedAt == nil {
+			goal.ArchivedAt = &now
+		}
+	case BulkDelete:
+		goal.DeletedAt = &now
+	case BulkCompleteSteps:
+		steps := operation.Steps
+		if len(steps) == 0 {
+			steps = goal.Steps
+		}
+		for _, step := range steps {
+			if _, exists := goal.Progress[step]; !exists {
+				return fmt.Errorf("step not found in goal: %s", step)
+			}
+			goal.Progress[step] = true
+		}
+		status := progressStatus(goal.Progress)
+		goal.CompletedAt = completionTime(goal, status)
+		goal.Status = status
+	default:
+		return fmt.Errorf("unknown operation %q", operation.Op)
+	}
+	return nil
+}
+
+// goalWrite builds the conditional partial update turning previous into current.
+func goalWrite(previous, current *models.Goal) (repository.GoalWrite, error) {
+	set, unset, err := changedFields(previous, current)
+	if err != nil {
+		return repository.GoalWrite{}, err
+	}
+	// deleted_at is protected from patches but is how bulk deletes move goals to the trash
+	if current.DeletedAt != nil {
+		if set == nil {
+			set = bson.M{}
+		}
+		set["deleted_at"] = *current.DeletedAt
+	}
+	return repository.GoalWrite{
+		ID:              previous.ID,
+		ExpectedVersion: previous.Version,
+		Set:             set,
+		Unset:           unset,
+	}, nil
+}
+
+// rewriteAttempts bounds how often rewriteGoals retries goals modified concurrently.
+const rewriteAttempts = 3
+
+// rewriteGoals applies change to the goals returned by load and writes every goal it
+// modified with a conditional bulk write, emitting the usual update events. load must
+// only return goals that still need the change, so that goals modified concurrently can
+// be loaded and rewritten again, and attributes the events to actor. It returns the number
+// of goals written.
+func (s *GoalService) rewriteGoals(ctx context.Context, actor primitive.ObjectID, load func() ([]models.Goal, error), change func(goal *models.Goal) bool) (int, error) {
+	written := 0
+	for attempt := 0; attempt < rewriteAttempts; attempt++ {
+		goals, err := load()
+		if err != nil {
+			return written, err
+		}
+
+		previous := make(map[primitive.ObjectID]*models.Goal)
+		current := make(map[primitive.ObjectID]*models.Goal)
+		var writes []repository.GoalWrite
+		for i := range goals {
+			goal := goals[i]
+			goal.Tags = append([]string(nil), goals[i].Tags...)
+			if !change(&goal) {
+				continue
+			}
+			write, err := goalWrite(&goals[i], &goal)
+			if err != nil {
+				return written, fmt.Errorf("failed to diff goal: %v", err)
+			}
+			previous[goal.ID] = &goals[i]
+			current[goal.ID] = &goal
+			writes = append(writes, write)
+		}
+		if len(writes) == 0 {
+			return written, nil
+		}
+
+		applied, _, err := s.repo.BulkUpdateGoals(ctx, writes, false)
+		if err != nil {
+			return written, err
+		}
+		now := time.Now()
+		for id := range applied {
+			goal := current[id]
+			goal.Version = previous[id].Version + 1
+			goal.UpdatedAt = now
+			s.emitUpdate(ctx, previous[id], goal, actor)
+		}
+		written += len(applied)
+		if len(applied) == len(writes) {
+			return written, nil
+		}
+	}
+	return written, fmt.Errorf("goals kept being modified concurrently")
+}
+
+// ReplaceTags replaces the source tags with target on every live personal goal of a user, which
+// renames a tag or merges several tags into one. An empty target removes the tags.
+// It returns the number of goals changed.
+func (s *GoalService) ReplaceTags(ctx context.Context, userID primitive.ObjectID, sources []string, target string) (int, error) {
+	return s.rewriteGoals(ctx, userID,
+		func() ([]models.Goal, error) {
+			return s.repo.GetGoalsWithTags(ctx, userID, sources)
+		},
+		func(goal *models.Goal) bool {
+			return replaceTags(goal, sources, target)
+		},
+	)
+}
+
+// RenameCategory moves every live personal goal of a user from one category to another.
+// It returns the number of goals changed.
+func (s *GoalService) RenameCategory(ctx context.Context, userID primitive.ObjectID, from, to string) (int, error) {
+	return s.rewriteGoals(ctx, userID,
+		func() ([]models.Goal, error) {
+			return s.repo.GetGoalsInCategory(ctx, userID, from)
+		},
+		func(goal *models.Goal) bool {
+			goal.Category = to
+			return from != to
+		},
+	)
+}
+
+// CountGoalsInCategory counts a user's goals in a category, including those in the trash.
+func (s *GoalService) CountGoalsInCategory(ctx context.Context, userID primitive.ObjectID, category string) (int64, error) {
+	return s.repo.CountGoalsInCategory(ctx, userID, category)
+}
+
+// CountTags returns the tags used by a user's live goals with their usage counts.
+func (s *GoalService) CountTags(ctx context.Context, userID primitive.ObjectID) ([]models.TagUsage, error) {
+	return s.repo.CountTags(ctx, userID)
+}
+
+// replaceTags replaces the source tags of a goal with target, keeping the tag order and
+// dropping duplicates. It reports whether the tags changed.
+func replaceTags(goal *models.Goal, sources []string, target string) bool {
+	replace := make(map[string]bool)
+	for _, source := range sources {
+		replace[source] = true
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+	changed := false
+	for _, tag := range goal.Tags {
+		if replace[tag] {
+			changed = true
+			tag = target
+		}
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	goal.Tags = tags
+	return changed
+}
+
+func countFailed(results []BulkItemResult) int {
+	failed := 0
+	for _, item := range results {
+		if !item.Success {
+			failed++
+		}
+	}
+	return failed
+}
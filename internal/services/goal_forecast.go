@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// AtRiskMargin is how far past the deadline, as a share of the goal's planned
+	// duration, a projected completion may fall while the goal is only at risk
+	AtRiskMargin = 0.2
+
+	// maxForecastPoints bounds the length of the forecast series
+	maxForecastPoints = 366
+)
+
+// GetForecast projects when a goal will be completed, with days following the owner's timezone.
+func (s *GoalService) GetForecast(ctx context.Context, goal *models.Goal) *models.GoalForecast {
+	return BuildForecast(goal, time.Now(), s.Location(ctx, goal.UserID))
+}
+
+// filterByForecast keeps the goals whose forecast has the given status. Like GetForecast,
+// each goal is forecast in its owner's timezone, since team goals mix owners.
+func (s *GoalService) filterByForecast(ctx context.Context, goals []models.Goal, status string) []models.Goal {
+	now := time.Now()
+	locations := make(map[primitive.ObjectID]*time.Location)
+
+	filtered := []models.Goal{}
+	for i := range goals {
+		loc, ok := locations[goals[i].UserID]
+		if !ok {
+			loc = s.Location(ctx, goals[i].UserID)
+			locations[goals[i].UserID] = loc
+		}
+		if BuildForecast(&goals[i], now, loc).Status == status {
+			filtered = append(filtered, goals[i])
+		}
+	}
+	return filtered
+}
+
+// BuildForecast projects the completion of a goal from the steps completed since it was
+// created. Steps checked before completion times were recorded count as done from the start.
+func BuildForecast(goal *models.Goal, now time.Time, loc *time.Location) *models.GoalForecast {
+	start := goal.CreatedAt.In(loc)
+	forecast := &models.GoalForecast{
+		GoalID: goal.ID,
+		Steps:  len(goal.Steps),
+		Start:  start,
+		Series: []models.ForecastPoint{},
+	}
+
+	// When each done step was completed, oldest first
+	var completions []time.Time
+	for _, step := range goal.Steps {
+		if !goal.Progress[step] {
+			continue
+		}
+		completedAt := start
+		if meta := goal.StepMeta[step]; meta.CompletedAt != nil {
+			completedAt = *meta.CompletedAt
+		}
+		completions = append(completions, completedAt)
+	}
+	sort.Slice(completions, func(i, j int) bool { return completions[i].Before(completions[j]) })
+	forecast.CompletedSteps = len(completions)
+	forecast.RemainingSteps = forecast.Steps - forecast.CompletedSteps
+
+	elapsedDays := max(now.Sub(start).Hours()/24, 1)
+	velocity := float64(forecast.CompletedSteps) / elapsedDays
+	forecast.Velocity = roundTo(velocity, 2)
+
+	var deadline time.Time
+	if !goal.DueDate.IsZero() {
+		deadline = goal.Deadline(loc)
+		forecast.Deadline = &deadline
+		if daysLeft := deadline.Sub(now).Hours() / 24; daysLeft > 0 && forecast.RemainingSteps > 0 {
+			forecast.RequiredVelocity = roundTo(float64(forecast.RemainingSteps)/max(daysLeft, 1), 2)
+		}
+	}
+
+	var projected time.Time
+	switch {
+	case forecast.RemainingSteps == 0:
+		projected = now
+		if len(completions) > 0 {
+			projected = completions[len(completions)-1].In(loc)
+		}
+		forecast.ProjectedCompletion = &projected
+	case velocity > 0:
+		projected = now.Add(time.Duration(float64(forecast.RemainingSteps) / velocity * float64(24*time.Hour))).In(loc)
+		forecast.ProjectedCompletion = &projected
+	}
+
+	forecast.Status = forecastStatus(goal, forecast, now, start, deadline, projected)
+	forecast.Series = forecastSeries(forecast, completions, now, loc, deadline, projected)
+	return forecast
+}
+
+// forecastStatus compares the projected completion with the deadline. Goals nothing was
+// done on yet are on track until the ideal burndown expects a first step.
+func forecastStatus(goal *models.Goal, forecast *models.GoalForecast, now, start, deadline, projected time.Time) string {
+	switch {
+	case goal.Status == "completed" || forecast.RemainingSteps == 0:
+		return models.ForecastCompleted
+	case deadline.IsZero():
+		return models.ForecastNoDeadline
+	case !now.Before(deadline):
+		return models.ForecastOffTrack
+	}
+
+	planned := deadline.Sub(start)
+	if projected.IsZero() {
+		if planned > 0 && float64(forecast.Steps)*float64(now.Sub(start))/float64(planned) < 1 {
+			return models.ForecastOnTrack
+		}
+		return models.ForecastOffTrack
+	}
+	switch {
+	case !projected.After(deadline):
+		return models.ForecastOnTrack
+	case !projected.After(deadline.Add(time.Duration(AtRiskMargin * float64(planned)))):
+		return models.ForecastAtRisk
+	default:
+		return models.ForecastOffTrack
+	}
+}
+
+// forecastSeries builds the chart points from the first day of the goal up to the
+// later of today, the deadline and the projected completion.
+func forecastSeries(forecast *models.GoalForecast, completions []time.Time, now time.Time, loc *time.Location, deadline, projected time.Time) []models.ForecastPoint {
+	year, month, day := forecast.Start.Date()
+	first := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	last := now.In(loc)
+	for _, t := range []time.Time{deadline, projected} {
+		if t.After(last) {
+			last = t.In(loc)
+		}
+	}
+	days := int(last.Sub(first).Hours()/24) + 1
+	interval := (days + maxForecastPoints - 1) / maxForecastPoints
+
+	var series []models.ForecastPoint
+	for i := 0; ; i += interval {
+		date := first.AddDate(0, 0, i)
+		if date.After(last) {
+			break
+		}
+		point := models.ForecastPoint{Date: date.Format(models.DateLayout)}
+		end := date.AddDate(0, 0, 1)
+
+		if !date.After(now) {
+			at := end
+			if now.Before(at) {
+				at = now
+			}
+			completed := sort.Search(len(completions), func(i int) bool { return completions[i].After(at) })
+			remaining := forecast.Steps - completed
+			point.Completed, point.Remaining = &completed, &remaining
+		}
+		if !deadline.IsZero() {
+			ideal := float64(forecast.Steps)
+			if planned := deadline.Sub(forecast.Start); planned > 0 {
+				ideal *= 1 - float64(end.Sub(forecast.Start))/float64(planned)
+			} else {
+				ideal = 0
+			}
+			ideal = roundTo(min(max(ideal, 0), float64(forecast.Steps)), 2)
+			point.Ideal = &ideal
+		}
+		if !projected.IsZero() && forecast.RemainingSteps > 0 && end.After(now) {
+			left := float64(forecast.RemainingSteps) * (1 - float64(end.Sub(now))/float64(projected.Sub(now)))
+			left = roundTo(max(left, 0), 2)
+			point.Projected = &left
+		}
+		series = append(series, point)
+	}
+	return series
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func forecastGoal(created time.Time, dueDate time.Time, completed ...time.Time) *models.Goal {
+	goal := &models.Goal{
+		Steps:     []string{"1", "2", "3", "4"},
+		Progress:  map[string]bool{"1": false, "2": false, "3": false, "4": false},
+		StepMeta:  map[string]models.StepMeta{},
+		Status:    "in_progress",
+		DueDate:   dueDate,
+		CreatedAt: created,
+	}
+	for i := range completed {
+		step := goal.Steps[i]
+		goal.Progress[step] = true
+		goal.StepMeta[step] = models.StepMeta{CompletedAt: &completed[i]}
+	}
+	return goal
+}
+
+// TestBuildForecast tests velocity, projection and the on-track, at-risk and off-track statuses.
+func TestBuildForecast(t *testing.T) {
+	created := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC)
+
+	// Two steps in ten days: the other two take another ten, finishing on 21 October
+	done := []time.Time{created.AddDate(0, 0, 3), created.AddDate(0, 0, 7)}
+	forecast := BuildForecast(forecastGoal(created, time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC), done...), now, time.UTC)
+	assert.Equal(t, models.ForecastOnTrack, forecast.Status)
+	assert.Equal(t, 0.2, forecast.Velocity)
+	assert.Equal(t, 2, forecast.RemainingSteps)
+	assert.Equal(t, time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC), *forecast.ProjectedCompletion)
+	assert.Equal(t, 0.14, forecast.RequiredVelocity)
+
+	// Due on 19 October: 2 days late out of 18 planned is within the margin
+	forecast = BuildForecast(forecastGoal(created, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), done...), now, time.UTC)
+	assert.Equal(t, models.ForecastAtRisk, forecast.Status)
+
+	forecast = BuildForecast(forecastGoal(created, time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), done...), now, time.UTC)
+	assert.Equal(t, models.ForecastOffTrack, forecast.Status)
+
+	// Nothing done yet, but the ideal burndown doesn't expect a step before 8 October
+	fresh := forecastGoal(created, time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, models.ForecastOnTrack, BuildForecast(fresh, created.AddDate(0, 0, 5), time.UTC).Status)
+	assert.Equal(t, models.ForecastOffTrack, BuildForecast(fresh, now, time.UTC).Status)
+	assert.Nil(t, BuildForecast(fresh, now, time.UTC).ProjectedCompletion)
+
+	assert.Equal(t, models.ForecastNoDeadline, BuildForecast(forecastGoal(created, time.Time{}, done...), now, time.UTC).Status)
+
+	finished := forecastGoal(created, time.Time{}, done[0], done[0], done[1], done[1])
+	forecast = BuildForecast(finished, now, time.UTC)
+	assert.Equal(t, models.ForecastCompleted, forecast.Status)
+	assert.Equal(t, done[1], *forecast.ProjectedCompletion)
+}
+
+// TestForecastSeries tests the actual, ideal and projected burndown points.
+func TestForecastSeries(t *testing.T) {
+	created := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 10, 11, 12, 0, 0, 0, time.UTC)
+	done := []time.Time{created.AddDate(0, 0, 3), created.AddDate(0, 0, 7)}
+	goal := forecastGoal(created, time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), done...)
+	goal.DueAllDay = true
+
+	forecast := BuildForecast(goal, now, time.UTC)
+	series := forecast.Series
+	// From 1 October up to the projected completion on 22 October
+	assert.Equal(t, "2026-10-01", series[0].Date)
+	assert.Equal(t, "2026-10-22", series[len(series)-1].Date)
+
+	assert.Equal(t, 0, *series[0].Completed)
+	assert.Equal(t, 1, *series[3].Completed)
+	assert.Equal(t, 2, *series[10].Completed)
+	assert.Equal(t, 2, *series[10].Remaining)
+	assert.Nil(t, series[11].Completed)
+
+	// The ideal line reaches zero at the end of the due day
+	assert.Equal(t, 3.8, *series[0].Ideal)
+	assert.Equal(t, 0.0, *series[19].Ideal)
+
+	assert.Nil(t, series[9].Projected)
+	assert.NotNil(t, series[10].Projected)
+	assert.Equal(t, 0.0, *series[len(series)-1].Projected)
+}
+
+// TestFilterByForecastOwnerZone tests that each goal is forecast in its own owner's zone.
+func TestFilterByForecastOwnerZone(t *testing.T) {
+	kiritimati, err := time.LoadLocation("Pacific/Kiritimati")
+	assert.NoError(t, err)
+	pagoPago, err := time.LoadLocation("Pacific/Pago_Pago")
+	assert.NoError(t, err)
+	early, late := primitive.NewObjectID(), primitive.NewObjectID()
+
+	s := NewGoalService(nil)
+	s.SetLocationLookup(func(ctx context.Context, userID primitive.ObjectID) (*time.Location, error) {
+		if userID == early {
+			return kiritimati, nil
+		}
+		return pagoPago, nil
+	})
+
+	// Due all day on a date that has already ended at UTC+14 but not yet at UTC-11
+	now := time.Now()
+	year, month, day := now.Add(-10 * time.Hour).UTC().Date()
+	goal := forecastGoal(now.Add(-time.Minute), time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+	goal.DueAllDay = true
+	goals := []models.Goal{*goal, *goal}
+	goals[0].UserID, goals[1].UserID = late, early
+
+	offTrack := s.filterByForecast(context.Background(), goals, models.ForecastOffTrack)
+	if assert.Len(t, offTrack, 1) {
+		assert.Equal(t, early, offTrack[0].UserID)
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultUrgentWithin is how close a due date makes a goal urgent when urgency is derived automatically.
+const DefaultUrgentWithin = 3 * 24 * time.Hour
+
+// EisenhowerMatrix buckets goals into the four quadrants of importance and urgency.
+type EisenhowerMatrix struct {
+	// Do holds goals that are important and urgent
+	Do []models.Goal `json:"do"`
+	// Schedule holds goals that are important but not urgent
+	Schedule []models.Goal `json:"schedule"`
+	// Delegate holds goals that are urgent but not important
+	Delegate []models.Goal `json:"delegate"`
+	// Eliminate holds goals that are neither
+	Eliminate []models.Goal `json:"eliminate"`
+}
+
+// MatrixOptions controls how goals are classified as urgent.
+type MatrixOptions struct {
+	// AutoUrgency also treats goals due within UrgentWithin, or overdue, as urgent
+	AutoUrgency  bool
+	UrgentWithin time.Duration
+	Now          time.Time
+	// Location is the owner's timezone, which all-day due dates end in; nil means UTC
+	Location *time.Location
+}
+
+// GetMatrix buckets the user's active goals, those neither completed nor archived,
+// into the Eisenhower quadrants.
+func (s *GoalService) GetMatrix(ctx context.Context, userID primitive.ObjectID, opts MatrixOptions) (*EisenhowerMatrix, error) {
+	goals, err := s.repo.GetGoals(ctx, repository.GoalFilter{UserID: userID, Archived: repository.ArchivedExclude})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch goals: %v", err)
+	}
+
+	var active []models.Goal
+	for _, goal := range goals {
+		if goal.Status != "completed" {
+			active = append(active, goal)
+		}
+	}
+	return BuildMatrix(active, opts), nil
+}
+
+// BuildMatrix buckets goals into the Eisenhower quadrants, each sorted by priority.
+func BuildMatrix(goals []models.Goal, opts MatrixOptions) *EisenhowerMatrix {
+	matrix := &EisenhowerMatrix{
+		Do:        []models.Goal{},
+		Schedule:  []models.Goal{},
+		Delegate:  []models.Goal{},
+		Eliminate: []models.Goal{},
+	}
+	for _, goal := range goals {
+		important, urgent := IsImportant(&goal), IsUrgent(&goal, opts)
+		switch {
+		case important && urgent:
+			matrix.Do = append(matrix.Do, goal)
+		case important:
+			matrix.Schedule = append(matrix.Schedule, goal)
+		case urgent:
+			matrix.Delegate = append(matrix.Delegate, goal)
+		default:
+			matrix.Eliminate = append(matrix.Eliminate, goal)
+		}
+	}
+
+	for _, quadrant := range [][]models.Goal{matrix.Do, matrix.Schedule, matrix.Delegate, matrix.Eliminate} {
+		SortByPriority(quadrant)
+	}
+	return matrix
+}
+
+// IsImportant reports whether a goal is flagged important or has priority P1 or P2.
+func IsImportant(goal *models.Goal) bool {
+	return goal.Important || (goal.Priority >= models.PriorityHighest && goal.Priority <= 2)
+}
+
+// IsUrgent reports whether a goal is flagged urgent or, with automatic urgency, is due soon.
+func IsUrgent(goal *models.Goal, opts MatrixOptions) bool {
+	if goal.Urgent {
+		return true
+	}
+	if !opts.AutoUrgency || goal.DueDate.IsZero() {
+		return false
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return goal.IsOverdue(opts.Now.Add(opts.UrgentWithin), loc)
+}
+
+// SortByPriority orders goals from the highest priority down, goals without a priority
+// last. Goals of equal priority are ordered by due date, then by creation.
+func SortByPriority(goals []models.Goal) {
+	rank := func(goal *models.Goal) models.Priority {
+		if goal.Priority == models.PriorityNone {
+			return models.PriorityLowest + 1
+		}
+		return goal.Priority
+	}
+	sort.SliceStable(goals, func(i, j int) bool {
+		a, b := &goals[i], &goals[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		if !a.DueDate.Equal(b.DueDate) {
+			if a.DueDate.IsZero() || b.DueDate.IsZero() {
+				return b.DueDate.IsZero()
+			}
+			return a.DueDate.Before(b.DueDate)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+}
+
+// ValidatePriority checks that a priority is unset or between P1 and P4.
+func ValidatePriority(priority models.Priority) error {
+	if priority != models.PriorityNone && (priority < models.PriorityHighest || priority > models.PriorityLowest) {
+		return fmt.Errorf("priority must be between 1 (P1) and 4 (P4)")
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuildMatrix tests quadrant assignment with flags, priority and automatic urgency.
+func TestBuildMatrix(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	goals := []models.Goal{
+		{Name: "Tax return", Priority: 1, DueDate: now.Add(24 * time.Hour)},
+		{Name: "Learn Spanish", Important: true, DueDate: now.AddDate(0, 6, 0)},
+		{Name: "Reply to landlord", Urgent: true},
+		{Name: "Sort photos"},
+	}
+
+	matrix := BuildMatrix(goals, MatrixOptions{AutoUrgency: true, UrgentWithin: DefaultUrgentWithin, Now: now})
+	assert.Equal(t, "Tax return", matrix.Do[0].Name)
+	assert.Equal(t, "Learn Spanish", matrix.Schedule[0].Name)
+	assert.Equal(t, "Reply to landlord", matrix.Delegate[0].Name)
+	assert.Equal(t, "Sort photos", matrix.Eliminate[0].Name)
+
+	matrix = BuildMatrix(goals, MatrixOptions{Now: now})
+	assert.Len(t, matrix.Do, 0)
+	assert.Len(t, matrix.Schedule, 2)
+}
+
+// TestIsUrgentAllDay tests that an all-day goal becomes overdue, and so urgent, at the end
+// of the due day in the owner's timezone.
+func TestIsUrgentAllDay(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+
+	// 20:00 UTC on 1 October is already 2 October in Tokyo
+	now := time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC)
+	goal := models.Goal{DueDate: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), DueAllDay: true}
+
+	assert.False(t, IsUrgent(&goal, MatrixOptions{AutoUrgency: true, Now: now}))
+	assert.True(t, IsUrgent(&goal, MatrixOptions{AutoUrgency: true, Now: now, Location: tokyo}))
+}
+
+// TestSortByPriority tests that goals without a priority come last.
+func TestSortByPriority(t *testing.T) {
+	goals := []models.Goal{{Name: "none"}, {Name: "p3", Priority: 3}, {Name: "p1", Priority: 1}}
+	SortByPriority(goals)
+	assert.Equal(t, "p1", goals[0].Name)
+	assert.Equal(t, "p3", goals[1].Name)
+	assert.Equal(t, "none", goals[2].Name)
+}
+
+// TestPriorityJSON tests that priorities are accepted as numbers and as P1-P4.
+func TestPriorityJSON(t *testing.T) {
+	var goal models.Goal
+	assert.NoError(t, json.Unmarshal([]byte(`{"priority":"P2"}`), &goal))
+	assert.Equal(t, models.Priority(2), goal.Priority)
+	assert.NoError(t, json.Unmarshal([]byte(`{"priority":3}`), &goal))
+	assert.Equal(t, models.Priority(3), goal.Priority)
+	assert.Error(t, json.Unmarshal([]byte(`{"priority":"high"}`), &goal))
+
+	assert.NoError(t, ValidatePriority(4))
+	assert.Error(t, ValidatePriority(5))
+}
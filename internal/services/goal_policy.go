@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GoalAction is something a user can do to a goal.
+type GoalAction int
+
+// Goal actions, each needing a more privileged role than the previous one
+const (
+	// ActionView reads a goal and everything attached to it
+	ActionView GoalAction = iota
+	// ActionEdit changes a goal's content, progress, notes and attachments
+	ActionEdit
+	// ActionManage deletes, archives, clones and shares a goal
+	ActionManage
+)
+
+// ErrForbidden is returned when a user's role on a goal doesn't allow an action.
+var ErrForbidden = errors.New("forbidden")
+
+// AccessLookup returns the role a user has on a personal goal they don't own, or "" for none.
+type AccessLookup func(ctx context.Context, goal *models.Goal, userID primitive.ObjectID) (string, error)
+
+// AudienceLookup lists the users who may have access to a goal through one source, such
+// as shares. Viewers checks every one of them against Role.
+type AudienceLookup func(ctx context.Context, goal *models.Goal) ([]primitive.ObjectID, error)
+
+// roleRanks orders the roles by privilege; an unknown role ranks lowest.
+var roleRanks = map[string]int{
+	models.RoleViewer: 1,
+	models.RoleEditor: 2,
+	models.RoleOwner:  3,
+}
+
+// actionRoles is the least privileged role allowed to perform each action.
+var actionRoles = map[GoalAction]string{
+	ActionView:   models.RoleViewer,
+	ActionEdit:   models.RoleEditor,
+	ActionManage: models.RoleOwner,
+}
+
+// AddAccessLookup registers a source of access to other users' goals, such as shares.
+// Without any, users can only access the goals they own.
+func (s *GoalService) AddAccessLookup(lookup AccessLookup) {
+	s.access = append(s.access, lookup)
+}
+
+// AddAudienceLookup registers a source of users who may have access to goals they don't
+// own, so that Viewers can find them.
+func (s *GoalService) AddAudienceLookup(lookup AudienceLookup) {
+	s.audience = append(s.audience, lookup)
+}
+
+// Viewers returns every user allowed to view a goal, as decided by Role.
+func (s *GoalService) Viewers(ctx context.Context, goal *models.Goal) ([]primitive.ObjectID, error) {
+	candidates := []primitive.ObjectID{goal.UserID}
+	for _, lookup := range s.audience {
+		users, err := lookup(ctx, goal)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, users...)
+	}
+
+	seen := make(map[primitive.ObjectID]bool, len(candidates))
+	viewers := []primitive.ObjectID{}
+	for _, userID := range candidates {
+		if userID.IsZero() || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		role, err := s.Role(ctx, goal, userID)
+		if err != nil {
+			return nil, err
+		}
+		if RoleAllows(role, ActionView) {
+			viewers = append(viewers, userID)
+		}
+	}
+	return viewers, nil
+}
+
+// Role returns the most privileged role a user has on a goal, or "" for none. On team
+// goals it only follows the user's current membership of the organization, so creators
+// who left keep no access. On personal goals the owner is an owner and other users get
+// the best role any access lookup grants.
+func (s *GoalService) Role(ctx context.Context, goal *models.Goal, userID primitive.ObjectID) (string, error) {
+	if goal.OrgID != nil {
+		if s.orgs == nil {
+			return "", nil
+		}
+		role, err := s.orgs(ctx, *goal.OrgID, userID)
+		if err != nil {
+			return "", err
+		}
+		return GoalRoleForOrgRole(role), nil
+	}
+	if goal.UserID == userID {
+		return models.RoleOwner, nil
+	}
+	best := ""
+	for _, lookup := range s.access {
+		role, err := lookup(ctx, goal, userID)
+		if err != nil {
+			return "", err
+		}
+		if roleRanks[role] > roleRanks[best] {
+			best = role
+		}
+	}
+	return best, nil
+}
+
+// Authorize returns ErrForbidden unless the user's role on the goal allows the action.
+// It is the single place goal access is decided.
+func (s *GoalService) Authorize(ctx context.Context, goal *models.Goal, userID primitive.ObjectID, action GoalAction) error {
+	role, err := s.Role(ctx, goal, userID)
+	if err != nil {
+		return err
+	}
+	if !RoleAllows(role, action) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// RoleAllows reports whether a role may perform an action.
+func RoleAllows(role string, action GoalAction) bool {
+	return roleRanks[role] > 0 && roleRanks[role] >= roleRanks[actionRoles[action]]
+}
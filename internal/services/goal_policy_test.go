@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestAuthorize tests that owners may do anything, that shared users get the best role
+// granted to them and that everyone else is forbidden.
+func TestAuthorize(t *testing.T) {
+	ctx := context.Background()
+	owner, editor, viewer, stranger := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	goal := &models.Goal{ID: primitive.NewObjectID(), UserID: owner}
+
+	service := &GoalService{}
+	service.AddAccessLookup(func(_ context.Context, _ *models.Goal, userID primitive.ObjectID) (string, error) {
+		if userID == editor || userID == viewer {
+			return models.RoleViewer, nil
+		}
+		return "", nil
+	})
+	service.AddAccessLookup(func(_ context.Context, _ *models.Goal, userID primitive.ObjectID) (string, error) {
+		if userID == editor {
+			return models.RoleEditor, nil
+		}
+		return "", nil
+	})
+
+	role, err := service.Role(ctx, goal, editor)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleEditor, role)
+
+	for _, test := range []struct {
+		userID primitive.ObjectID
+		action GoalAction
+		allow  bool
+	}{
+		{owner, ActionManage, true},
+		{editor, ActionEdit, true},
+		{editor, ActionManage, false},
+		{viewer, ActionView, true},
+		{viewer, ActionEdit, false},
+		{stranger, ActionView, false},
+	} {
+		err := service.Authorize(ctx, goal, test.userID, test.action)
+		if test.allow {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, ErrForbidden)
+		}
+	}
+
+	failing := &GoalService{}
+	failing.AddAccessLookup(func(context.Context, *models.Goal, primitive.ObjectID) (string, error) {
+		return "", errors.New("lookup failed")
+	})
+	err = failing.Authorize(ctx, goal, stranger, ActionView)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrForbidden)
+	assert.NoError(t, failing.Authorize(ctx, goal, owner, ActionManage))
+}
+
+// TestAuthorizeTeamGoal tests that team goals follow current organization membership
+// only, so that their creator loses access once removed from the organization.
+func TestAuthorizeTeamGoal(t *testing.T) {
+	ctx := context.Background()
+	creator, admin, shared := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	orgID := primitive.NewObjectID()
+	goal := &models.Goal{ID: primitive.NewObjectID(), UserID: creator, OrgID: &orgID}
+
+	roles := map[primitive.ObjectID]string{creator: models.OrgRoleMember, admin: models.OrgRoleAdmin}
+	service := &GoalService{}
+	service.SetOrgLookup(func(_ context.Context, org, userID primitive.ObjectID) (string, error) {
+		if org != orgID {
+			return "", nil
+		}
+		return roles[userID], nil
+	})
+	service.AddAccessLookup(func(context.Context, *models.Goal, primitive.ObjectID) (string, error) {
+		return models.RoleOwner, nil
+	})
+
+	assert.NoError(t, service.Authorize(ctx, goal, creator, ActionEdit))
+	assert.ErrorIs(t, service.Authorize(ctx, goal, creator, ActionManage), ErrForbidden)
+	assert.NoError(t, service.Authorize(ctx, goal, admin, ActionManage))
+	assert.ErrorIs(t, service.Authorize(ctx, goal, shared, ActionView), ErrForbidden)
+
+	delete(roles, creator)
+	assert.ErrorIs(t, service.Authorize(ctx, goal, creator, ActionView), ErrForbidden)
+}
+
+// TestViewers tests that only the audience members Role lets view a goal are viewers.
+func TestViewers(t *testing.T) {
+	ctx := context.Background()
+	owner, partner, revoked := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	goal := &models.Goal{ID: primitive.NewObjectID(), UserID: owner}
+
+	service := &GoalService{}
+	service.AddAccessLookup(func(_ context.Context, _ *models.Goal, userID primitive.ObjectID) (string, error) {
+		if userID == partner {
+			return models.RoleViewer, nil
+		}
+		return "", nil
+	})
+	service.AddAudienceLookup(func(context.Context, *models.Goal) ([]primitive.ObjectID, error) {
+		return []primitive.ObjectID{partner, revoked, owner, primitive.NilObjectID}, nil
+	})
+
+	viewers, err := service.Viewers(ctx, goal)
+	assert.NoError(t, err)
+	assert.Equal(t, []primitive.ObjectID{owner, partner}, viewers)
+
+	// Creators who left the organization of a team goal are no longer viewers
+	orgID := primitive.NewObjectID()
+	service.SetOrgLookup(func(context.Context, primitive.ObjectID, primitive.ObjectID) (string, error) {
+		return "", nil
+	})
+	viewers, err = service.Viewers(ctx, &models.Goal{UserID: owner, OrgID: &orgID})
+	assert.NoError(t, err)
+	assert.Empty(t, viewers)
+}
+
+// TestRoleAllows tests the least role each action needs.
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, RoleAllows(models.RoleViewer, ActionView))
+	assert.False(t, RoleAllows(models.RoleViewer, ActionEdit))
+	assert.True(t, RoleAllows(models.RoleEditor, ActionEdit))
+	assert.False(t, RoleAllows(models.RoleEditor, ActionManage))
+	assert.True(t, RoleAllows(models.RoleOwner, ActionManage))
+	assert.False(t, RoleAllows("", ActionView))
+	assert.False(t, RoleAllows("admin", ActionView))
+}
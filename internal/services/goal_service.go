@@ -2,16 +2,49 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/czeful/diplom_back/internal/models"
 	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// GoalEventListener is called after a goal change has been written to the database.
+type GoalEventListener func(ctx context.Context, event models.GoalEvent)
+
+// CategoryLookup returns the categories a user can assign to goals.
+type CategoryLookup func(ctx context.Context, userID primitive.ObjectID) (map[string]bool, error)
+
+// LocationLookup returns the timezone of a user.
+type LocationLookup func(ctx context.Context, userID primitive.ObjectID) (*time.Location, error)
+
+// SettingsLookup returns the settings of a user.
+type SettingsLookup func(ctx context.Context, userID primitive.ObjectID) (*models.UserSettings, error)
+
+// OrgLookup returns the role a user has in an organization, or "" if they aren't a member.
+type OrgLookup func(ctx context.Context, orgID, userID primitive.ObjectID) (string, error)
+
+// CloneHook copies data kept outside the goal document, such as notes, to a new clone.
+type CloneHook func(ctx context.Context, source, clone *models.Goal, opts CloneOptions) error
+
 // GoalService encapsulates the business logic for goals.
 type GoalService struct {
-	repo *repository.GoalRepository
+	repo       *repository.GoalRepository
+	listeners  []GoalEventListener
+	cloneHooks []CloneHook
+	categories CategoryLookup
+	locations  LocationLookup
+	settings   SettingsLookup
+	orgs       OrgLookup
+	access     []AccessLookup
+	audience   []AudienceLookup
 }
 
 // NewGoalService creates a new instance of GoalService.
@@ -21,6 +54,116 @@ func NewGoalService(repo *repository.GoalRepository) *GoalService {
 	}
 }
 
+// AddListener registers a listener that receives every goal lifecycle event.
+func (s *GoalService) AddListener(listener GoalEventListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// AddCloneHook registers a hook that runs after a goal has been cloned.
+func (s *GoalService) AddCloneHook(hook CloneHook) {
+	s.cloneHooks = append(s.cloneHooks, hook)
+}
+
+// SetCategoryLookup sets where user-defined categories come from. Without one, only
+// the default categories are valid.
+func (s *GoalService) SetCategoryLookup(lookup CategoryLookup) {
+	s.categories = lookup
+}
+
+// Categories returns the categories a user can assign to goals.
+func (s *GoalService) Categories(ctx context.Context, userID primitive.ObjectID) (map[string]bool, error) {
+	if s.categories == nil {
+		return models.AllowedCategories, nil
+	}
+	return s.categories(ctx, userID)
+}
+
+// ValidCategory reports whether a user can assign the category to a goal.
+func (s *GoalService) ValidCategory(ctx context.Context, userID primitive.ObjectID, category string) (bool, error) {
+	categories, err := s.Categories(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return categories[category], nil
+}
+
+// SetLocationLookup sets where the timezones of users come from. Without one, every
+// user is in UTC.
+func (s *GoalService) SetLocationLookup(lookup LocationLookup) {
+	s.locations = lookup
+}
+
+// Location returns the timezone due dates of the user are interpreted in, UTC when it
+// is not set or can't be loaded.
+func (s *GoalService) Location(ctx context.Context, userID primitive.ObjectID) *time.Location {
+	if s.locations == nil {
+		return time.UTC
+	}
+	loc, err := s.locations(ctx, userID)
+	if err != nil || loc == nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// SetSettingsLookup sets where user settings come from. Without one, every user has
+// the default settings.
+func (s *GoalService) SetSettingsLookup(lookup SettingsLookup) {
+	s.settings = lookup
+}
+
+// Settings returns the settings of a user, the defaults when they can't be loaded.
+func (s *GoalService) Settings(ctx context.Context, userID primitive.ObjectID) models.UserSettings {
+	if s.settings == nil {
+		return models.DefaultSettings()
+	}
+	settings, err := s.settings(ctx, userID)
+	if err != nil || settings == nil {
+		return models.DefaultSettings()
+	}
+	return *settings
+}
+
+// SetOrgLookup sets where organization memberships come from. Without one, users can
+// only act on their own.
+func (s *GoalService) SetOrgLookup(lookup OrgLookup) {
+	s.orgs = lookup
+}
+
+// ActiveOrg returns the organization active, as named by the user's token, that the user
+// is acting within, or nil when active is empty and they act on their own. It returns
+// ErrNotOrgMember when active names an organization the user no longer belongs to.
+func (s *GoalService) ActiveOrg(ctx context.Context, userID primitive.ObjectID, active string) (*primitive.ObjectID, error) {
+	if active == "" {
+		return nil, nil
+	}
+	orgID, err := primitive.ObjectIDFromHex(active)
+	if err != nil || s.orgs == nil {
+		return nil, ErrNotOrgMember
+	}
+	role, err := s.orgs(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, ErrNotOrgMember
+	}
+	return &orgID, nil
+}
+
+// DefaultCategory returns the category new goals of a user get when none is given: the
+// user's default_category setting, as long as it is still one of the user's categories.
+func (s *GoalService) DefaultCategory(ctx context.Context, userID primitive.ObjectID) string {
+	category := s.Settings(ctx, userID).DefaultCategory
+	if category == "" {
+		return ""
+	}
+	if valid, err := s.ValidCategory(ctx, userID, category); err != nil || !valid {
+		return ""
+	}
+	return category
+}
+
 // CreateGoal processes the goal creation logic and stores it in the database.
 func (s *GoalService) CreateGoal(ctx context.Context, goal *models.Goal) (*models.Goal, error) {
 	// Here you can add additional business logic,
@@ -32,9 +175,140 @@ func (s *GoalService) CreateGoal(ctx context.Context, goal *models.Goal) (*model
 	if err != nil {
 		return nil, fmt.Errorf("failed to create goal: %v", err)
 	}
+	s.emit(ctx, models.EventGoalCreated, nil, createdGoal, "", createdGoal.UserID)
 	return createdGoal, nil
 }
 
+// ErrInvalidParent is returned when a sub-goal would be placed below a goal of another
+// owner or organization.
+var ErrInvalidParent = errors.New("parent goal must belong to the same owner or organization")
+
+// ValidateParent checks that goal may be placed below parent: personal goals only go
+// below personal goals of the same owner, and team goals below goals of the same organization.
+func ValidateParent(goal, parent *models.Goal) error {
+	if goal.OrgID == nil && parent.OrgID == nil && goal.UserID == parent.UserID {
+		return nil
+	}
+	if goal.OrgID != nil && parent.OrgID != nil && *goal.OrgID == *parent.OrgID {
+		return nil
+	}
+	return ErrInvalidParent
+}
+
+// CloneOptions controls how CloneGoal copies a goal.
+type CloneOptions struct {
+	// Name of the copy; defaults to the source name with a " (copy)" suffix
+	Name string
+	// ResetProgress starts the copy with every step not done
+	ResetProgress bool
+	// ShiftDays moves the goal and step due dates by this many days
+	ShiftDays int
+	// CopyTags keeps the tags of the source goal
+	CopyTags bool
+	// CopyNotes copies the notes of the source goal
+	CopyNotes bool
+	// CopyAttachments copies the attachments of the source goal
+	CopyAttachments bool
+	// IncludeSubGoals clones the sub-goals of the source goal, and theirs, below the copy.
+	// They keep their names; every other option applies to them as well
+	IncludeSubGoals bool
+}
+
+// CloneGoal creates a new goal for the same owner, and below the same parent, from an
+// existing one. Clone hooks copy related data; a failing hook is logged and doesn't undo
+// the clone, and neither does a sub-goal that fails to clone.
+func (s *GoalService) CloneGoal(ctx context.Context, source *models.Goal, opts CloneOptions) (*models.Goal, error) {
+	return s.cloneGoal(ctx, source, BuildClone(source, opts), opts)
+}
+
+// cloneGoal stores the copy of source, then copies the data kept outside the goal
+// document and, when asked to, the sub-goals.
+func (s *GoalService) cloneGoal(ctx context.Context, source, copied *models.Goal, opts CloneOptions) (*models.Goal, error) {
+	clone, err := s.CreateGoal(ctx, copied)
+	if err != nil {
+		return nil, err
+	}
+	for _, hook := range s.cloneHooks {
+		if err := hook(ctx, source, clone, opts); err != nil {
+			log.Printf("Failed to copy data to cloned goal %s: %v", clone.ID.Hex(), err)
+		}
+	}
+	if opts.IncludeSubGoals {
+		s.cloneSubGoals(ctx, source, clone, opts)
+	}
+	return clone, nil
+}
+
+// cloneSubGoals clones the sub-goals of source below clone, recursively. A goal can only
+// be placed below a goal that already exists, so the walk always ends.
+func (s *GoalService) cloneSubGoals(ctx context.Context, source, clone *models.Goal, opts CloneOptions) {
+	subGoals, err := s.repo.GetSubGoals(ctx, source.ID)
+	if err != nil {
+		log.Printf("Failed to load sub-goals of goal %s: %v", source.ID.Hex(), err)
+		return
+	}
+	for i := range subGoals {
+		subOpts := opts
+		subOpts.Name = subGoals[i].Name
+		copied := BuildClone(&subGoals[i], subOpts)
+		copied.ParentID = &clone.ID
+		if _, err := s.cloneGoal(ctx, &subGoals[i], copied, subOpts); err != nil {
+			log.Printf("Failed to clone sub-goal %s: %v", subGoals[i].ID.Hex(), err)
+		}
+	}
+}
+
+// BuildClone returns the goal CloneGoal would create, without storing it.
+func BuildClone(source *models.Goal, opts CloneOptions) *models.Goal {
+	clone := &models.Goal{
+		UserID:      source.UserID,
+		OrgID:       source.OrgID,
+		ParentID:    source.ParentID,
+		Name:        opts.Name,
+		Description: source.Description,
+		Category:    source.Category,
+		Priority:    source.Priority,
+		Important:   source.Important,
+		Urgent:      source.Urgent,
+		Steps:       append([]string{}, source.Steps...),
+		Progress:    make(map[string]bool),
+		Status:      "pending",
+	}
+	if clone.Name == "" {
+		clone.Name = source.Name + " (copy)"
+	}
+	if opts.CopyTags {
+		clone.Tags = append([]string(nil), source.Tags...)
+	}
+
+	for _, step := range source.Steps {
+		clone.Progress[step] = !opts.ResetProgress && source.Progress[step]
+	}
+	if !opts.ResetProgress {
+		clone.Status = source.Status
+		clone.CompletedAt = completionTime(&models.Goal{}, clone.Status)
+	}
+
+	if !source.DueDate.IsZero() {
+		clone.DueDate = source.DueDate.AddDate(0, 0, opts.ShiftDays)
+	}
+	for step, meta := range source.StepMeta {
+		if clone.StepMeta == nil {
+			clone.StepMeta = make(map[string]models.StepMeta)
+		}
+		if meta.DueDate != nil {
+			dueDate := meta.DueDate.AddDate(0, 0, opts.ShiftDays)
+			meta.DueDate = &dueDate
+		}
+		// The copy's steps weren't checked or assigned by anyone, so they don't count as activity
+		meta.CompletedAt = nil
+		meta.CompletedBy = nil
+		meta.AssigneeID = nil
+		clone.StepMeta[step] = meta
+	}
+	return clone
+}
+
 // GetGoal retrieves a goal by its ID.
 func (s *GoalService) GetGoal(ctx context.Context, id string) (*models.Goal, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -48,31 +322,383 @@ func (s *GoalService) GetGoal(ctx context.Context, id string) (*models.Goal, err
 	return goal, nil
 }
 
-// UpdateGoal updates an existing goal.
-func (s *GoalService) UpdateGoal(ctx context.Context, id string, updatedGoal *models.Goal) (*models.Goal, error) {
+// GetLiveGoals retrieves the goals among ids that aren't in the trash, whoever owns them.
+// Callers must check access to each of them.
+func (s *GoalService) GetLiveGoals(ctx context.Context, ids []primitive.ObjectID) ([]models.Goal, error) {
+	goals, err := s.repo.GetLiveGoals(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals: %v", err)
+	}
+	return goals, nil
+}
+
+// CountGoals counts the live goals of a user, only the completed ones if completed is set
+// and only those of category if it isn't empty.
+func (s *GoalService) CountGoals(ctx context.Context, userID primitive.ObjectID, category string, completed bool) (int, error) {
+	return s.repo.CountGoals(ctx, userID, category, completed)
+}
+
+// CountCompletedSteps counts the steps checked across the live goals of a user.
+func (s *GoalService) CountCompletedSteps(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return s.repo.CountCompletedSteps(ctx, userID)
+}
+
+// CountOrgContributions counts the live goals of an organization each user created and
+// completed, and the steps of those goals each user checked.
+func (s *GoalService) CountOrgContributions(ctx context.Context, orgID primitive.ObjectID) ([]repository.GoalCounts, []repository.StepCount, error) {
+	goalCounts, err := s.repo.CountGoalsByCreator(ctx, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	stepCounts, err := s.repo.CountStepsByCompleter(ctx, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return goalCounts, stepCounts, nil
+}
+
+// ReleaseOrgGoals turns the goals of an organization, trashed ones included, into personal
+// goals of the users who created them.
+func (s *GoalService) ReleaseOrgGoals(ctx context.Context, orgID primitive.ObjectID) error {
+	return s.repo.ReleaseOrgGoals(ctx, orgID)
+}
+
+// ErrStepNotFound is returned when a progress update names a step the goal doesn't have.
+var ErrStepNotFound = errors.New("step not found in goal")
+
+// statusUpdateAttempts bounds the retries when the derived status races another write.
+const statusUpdateAttempts = 3
+
+// UpdateGoal replaces an existing goal on behalf of actor. updatedGoal.Version must hold the
+// version the caller read; repository.ErrVersionConflict is returned if the goal changed since.
+// Fields left empty are removed from the stored goal, so a full update can clear them.
+func (s *GoalService) UpdateGoal(ctx context.Context, id string, updatedGoal *models.Goal, actor primitive.ObjectID) (*models.Goal, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, fmt.Errorf("invalid goal ID: %v", err)
 	}
-	goal, err := s.repo.UpdateGoal(ctx, objID, updatedGoal)
+
+	// Keep the previous state so that completion transitions can be detected
+	previous, err := s.repo.GetGoalByID(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal: %v", err)
+	}
+	if updatedGoal.Version != previous.Version {
+		return nil, fmt.Errorf("failed to update goal: %w", repository.ErrVersionConflict)
+	}
+	return s.writeChanges(ctx, previous, updatedGoal, previous.Version, actor)
+}
+
+// PatchGoal writes only the fields that differ between the stored goal and patched,
+// provided the goal is still at expectedVersion, on behalf of actor.
+func (s *GoalService) PatchGoal(ctx context.Context, id string, patched *models.Goal, expectedVersion int64, actor primitive.ObjectID) (*models.Goal, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid goal ID: %v", err)
+	}
+	previous, err := s.repo.GetGoalByID(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal: %v", err)
+	}
+	return s.writeChanges(ctx, previous, patched, expectedVersion, actor)
+}
+
+// RestoreSnapshot overwrites a goal with a snapshot of one of its revisions on behalf of
+// actor, provided the goal is still at current.Version. Fields empty in the snapshot are
+// removed, so the goal matches the revision; see BuildRestored for what is kept.
+func (s *GoalService) RestoreSnapshot(ctx context.Context, current, snapshot *models.Goal, actor primitive.ObjectID) (*models.Goal, error) {
+	return s.write(ctx, current, BuildRestored(current, snapshot), current.Version, actor)
+}
+
+// writeChanges applies the service-managed fields to a goal sent by a client and writes it
+// over the stored goal previous.
+func (s *GoalService) writeChanges(ctx context.Context, previous, goal *models.Goal, expectedVersion int64, actor primitive.ObjectID) (*models.Goal, error) {
+	applyLifecycle(previous, goal)
+	syncStepMeta(previous, goal)
+	applyStepLifecycle(previous, goal, time.Now(), actor)
+	return s.write(ctx, previous, goal, expectedVersion, actor)
+}
+
+// write sets the fields of goal that differ from the stored goal previous and unsets the
+// ones goal leaves empty, provided the stored goal is still at expectedVersion. Nothing is
+// written when nothing changed.
+func (s *GoalService) write(ctx context.Context, previous, goal *models.Goal, expectedVersion int64, actor primitive.ObjectID) (*models.Goal, error) {
+	set, unset, err := changedFields(previous, goal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff goal: %v", err)
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		return previous, nil
+	}
+
+	updated, err := s.repo.UpdateGoalFields(ctx, previous.ID, set, unset, expectedVersion)
+	if errors.Is(err, repository.ErrNoMatch) {
+		return nil, fmt.Errorf("failed to update goal: %w", repository.ErrVersionConflict)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update goal: %v", err)
 	}
+	s.emitUpdate(ctx, previous, updated, actor)
+	return updated, nil
+}
+
+// UpdateGoalProgress marks a single step as done or not done on behalf of actor and
+// recomputes the goal status. The step is toggled with an atomic field update, so concurrent
+// toggles of different steps never overwrite each other. expectedVersion may be
+// repository.AnyVersion.
+func (s *GoalService) UpdateGoalProgress(ctx context.Context, id string, step string, done bool, expectedVersion int64, actor primitive.ObjectID) (*models.Goal, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid goal ID: %v", err)
+	}
+
+	// Steps that can't be addressed as a field path fall back to a versioned rewrite
+	if strings.Contains(step, ".") || strings.HasPrefix(step, "$") {
+		return s.updateProgressDocument(ctx, id, step, done, expectedVersion, actor)
+	}
+
+	previous, err := s.repo.SetStepProgress(ctx, objID, step, done, actor, expectedVersion)
+	if errors.Is(err, repository.ErrNoMatch) {
+		return nil, s.explainMismatch(ctx, objID, step)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update progress: %v", err)
+	}
+
+	// Bring the derived status in line with the new progress, retrying if another
+	// write slipped in between
+	var goal *models.Goal
+	for attempt := 0; attempt < statusUpdateAttempts; attempt++ {
+		goal, err = s.repo.GetGoalByID(ctx, objID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get goal: %v", err)
+		}
+		status := progressStatus(goal.Progress)
+		if status == goal.Status {
+			break
+		}
+		completedAt := completionTime(goal, status)
+		updated, err := s.repo.SetStatus(ctx, objID, status, completedAt, goal.Version)
+		if err == nil {
+			goal = updated
+			break
+		}
+		if !errors.Is(err, repository.ErrNoMatch) {
+			return nil, fmt.Errorf("failed to update goal status: %v", err)
+		}
+	}
+
+	s.emitUpdate(ctx, previous, goal, actor)
 	return goal, nil
 }
 
-// DeleteGoal removes a goal from the database.
-func (s *GoalService) DeleteGoal(ctx context.Context, id string) error {
+func (s *GoalService) updateProgressDocument(ctx context.Context, id string, step string, done bool, expectedVersion int64, actor primitive.ObjectID) (*models.Goal, error) {
+	goal, err := s.GetGoal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != repository.AnyVersion && goal.Version != expectedVersion {
+		return nil, fmt.Errorf("failed to update progress: %w", repository.ErrVersionConflict)
+	}
+	if _, exists := goal.Progress[step]; !exists {
+		return nil, ErrStepNotFound
+	}
+	goal.Progress[step] = done
+	goal.Status = progressStatus(goal.Progress)
+	return s.UpdateGoal(ctx, id, goal, actor)
+}
+
+// explainMismatch works out why a conditional progress update matched nothing.
+func (s *GoalService) explainMismatch(ctx context.Context, id primitive.ObjectID, step string) error {
+	goal, err := s.repo.GetGoalByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get goal: %v", err)
+	}
+	if _, exists := goal.Progress[step]; !exists {
+		return ErrStepNotFound
+	}
+	return fmt.Errorf("failed to update progress: %w", repository.ErrVersionConflict)
+}
+
+// DeleteGoal moves a goal to the trash, from where it can be restored until it is purged.
+func (s *GoalService) DeleteGoal(ctx context.Context, id string, actor primitive.ObjectID) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return fmt.Errorf("invalid goal ID: %v", err)
 	}
-	if err := s.repo.DeleteGoal(ctx, objID); err != nil {
+	goal, err := s.repo.SoftDeleteGoal(ctx, objID)
+	if err != nil {
 		return fmt.Errorf("failed to delete goal: %v", err)
 	}
+	s.emit(ctx, models.EventGoalDeleted, goal, goal, "", actor)
+	return nil
+}
+
+// ArchiveGoal moves a goal out of the main list into the archive.
+func (s *GoalService) ArchiveGoal(ctx context.Context, id string, actor primitive.ObjectID) (*models.Goal, error) {
+	return s.setArchived(ctx, id, true, actor)
+}
+
+// UnarchiveGoal moves an archived goal back to the main list.
+func (s *GoalService) UnarchiveGoal(ctx context.Context, id string, actor primitive.ObjectID) (*models.Goal, error) {
+	return s.setArchived(ctx, id, false, actor)
+}
+
+// SetArchivedBulk archives or unarchives every listed personal goal owned by userID and
+// returns the IDs that were changed. Unknown, foreign and team goals are skipped.
+func (s *GoalService) SetArchivedBulk(ctx context.Context, userID primitive.ObjectID, ids []string, archived bool) ([]string, error) {
+	changed := []string{}
+	for _, id := range ids {
+		goal, err := s.GetGoal(ctx, id)
+		if err != nil || goal.UserID != userID || goal.OrgID != nil || (goal.ArchivedAt != nil) == archived {
+			continue
+		}
+		if _, err := s.setArchived(ctx, id, archived, userID); err != nil {
+			return changed, err
+		}
+		changed = append(changed, id)
+	}
+	return changed, nil
+}
+
+// AutoArchiveCompleted archives goals that have been completed for longer than their
+// owner's auto_archive_days setting. Owners with the setting at zero keep them.
+func (s *GoalService) AutoArchiveCompleted(ctx context.Context) (int, error) {
+	// A day is the shortest period users can choose
+	now := time.Now()
+	goals, err := s.repo.GetGoalsToAutoArchive(ctx, now.AddDate(0, 0, -1))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch goals to archive: %v", err)
+	}
+
+	archived := 0
+	days := make(map[primitive.ObjectID]int)
+	for _, goal := range goals {
+		if _, ok := days[goal.UserID]; !ok {
+			days[goal.UserID] = s.Settings(ctx, goal.UserID).AutoArchiveDays
+		}
+		completedAt := goal.UpdatedAt
+		if goal.CompletedAt != nil {
+			completedAt = *goal.CompletedAt
+		}
+		if days[goal.UserID] <= 0 || completedAt.After(now.AddDate(0, 0, -days[goal.UserID])) {
+			continue
+		}
+
+		if _, err := s.setArchived(ctx, goal.ID.Hex(), true, primitive.NilObjectID); err != nil {
+			return archived, err
+		}
+		archived++
+	}
+	return archived, nil
+}
+
+// RunAutoArchiver archives long-completed goals every interval until ctx is cancelled.
+func (s *GoalService) RunAutoArchiver(ctx context.Context, interval time.Duration) {
+	runEvery(ctx, interval, "Auto-archive", s.AutoArchiveCompleted)
+}
+
+func (s *GoalService) setArchived(ctx context.Context, id string, archived bool, actor primitive.ObjectID) (*models.Goal, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid goal ID: %v", err)
+	}
+	previous, err := s.repo.GetGoalByID(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal: %v", err)
+	}
+
+	var goal *models.Goal
+	if archived {
+		goal, err = s.repo.ArchiveGoal(ctx, objID)
+	} else {
+		goal, err = s.repo.UnarchiveGoal(ctx, objID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive goal: %v", err)
+	}
+	s.emitUpdate(ctx, previous, goal, actor)
+	return goal, nil
+}
+
+// GetDeletedGoal retrieves a goal from the trash by its ID.
+func (s *GoalService) GetDeletedGoal(ctx context.Context, id string) (*models.Goal, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid goal ID: %v", err)
+	}
+	goal, err := s.repo.GetDeletedGoalByID(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted goal: %v", err)
+	}
+	return goal, nil
+}
+
+// GetTrash retrieves the goals a user has deleted but not yet purged.
+func (s *GoalService) GetTrash(ctx context.Context, userID primitive.ObjectID) ([]models.Goal, error) {
+	goals, err := s.repo.GetDeletedGoals(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trash: %v", err)
+	}
+	return goals, nil
+}
+
+// RestoreGoal takes a goal out of the trash.
+func (s *GoalService) RestoreGoal(ctx context.Context, id string, actor primitive.ObjectID) (*models.Goal, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid goal ID: %v", err)
+	}
+	previous, err := s.repo.GetDeletedGoalByID(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted goal: %v", err)
+	}
+	goal, err := s.repo.RestoreGoal(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore goal: %v", err)
+	}
+	s.emit(ctx, models.EventGoalRestored, previous, goal, "", actor)
+	return goal, nil
+}
+
+// PurgeGoal permanently removes a goal that is in the trash.
+func (s *GoalService) PurgeGoal(ctx context.Context, id string, actor primitive.ObjectID) error {
+	goal, err := s.GetDeletedGoal(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteGoal(ctx, goal.ID); err != nil {
+		return fmt.Errorf("failed to purge goal: %v", err)
+	}
+	s.emit(ctx, models.EventGoalPurged, goal, goal, "", actor)
 	return nil
 }
 
+// PurgeExpiredTrash permanently removes goals that have been in the trash longer than retention.
+func (s *GoalService) PurgeExpiredTrash(ctx context.Context, retention time.Duration) (int, error) {
+	goals, err := s.repo.GetGoalsDeletedBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch expired trash: %v", err)
+	}
+
+	purged := 0
+	for i := range goals {
+		if err := s.repo.DeleteGoal(ctx, goals[i].ID); err != nil {
+			return purged, fmt.Errorf("failed to purge goal: %v", err)
+		}
+		s.emit(ctx, models.EventGoalPurged, &goals[i], &goals[i], "", primitive.NilObjectID)
+		purged++
+	}
+	return purged, nil
+}
+
+// RunTrashPurger purges expired trash every interval until ctx is cancelled.
+func (s *GoalService) RunTrashPurger(ctx context.Context, retention, interval time.Duration) {
+	runEvery(ctx, interval, "Trash purge", func(ctx context.Context) (int, error) {
+		return s.PurgeExpiredTrash(ctx, retention)
+	})
+}
+
 // GetAllGoals retrieves a list of goals with an optional limit.
 func (s *GoalService) GetAllGoals(ctx context.Context, limit int64) ([]models.Goal, error) {
 	goals, err := s.repo.GetAllGoals(ctx, limit)
@@ -82,6 +708,210 @@ func (s *GoalService) GetAllGoals(ctx context.Context, limit int64) ([]models.Go
 	return goals, nil
 }
 
-func (s *GoalService) GetGoals(ctx context.Context, userID primitive.ObjectID, category string) ([]models.Goal, error) {
-	return s.repo.GetGoals(ctx, userID, category)
+func (s *GoalService) GetGoals(ctx context.Context, filter repository.GoalFilter) ([]models.Goal, error) {
+	goals, err := s.repo.GetGoals(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Forecast != "" {
+		goals = s.filterByForecast(ctx, goals, filter.Forecast)
+	}
+	if filter.Sort == repository.SortPriority {
+		SortByPriority(goals)
+	}
+	return goals, nil
+}
+
+// applyLifecycle carries the service-managed timestamps, the owning organization and the
+// parent goal over from the stored goal, so that a client can neither clear nor forge
+// them with a full update.
+func applyLifecycle(previous, goal *models.Goal) {
+	goal.OrgID = previous.OrgID
+	goal.ParentID = previous.ParentID
+	goal.CreatedAt = previous.CreatedAt
+	goal.ArchivedAt = previous.ArchivedAt
+	goal.DeletedAt = nil
+	goal.CompletedAt = completionTime(previous, goal.Status)
+}
+
+// syncStepMeta keeps step details in line with the goal's steps. Details omitted from a
+// full update are carried over, and details of removed steps are dropped.
+func syncStepMeta(previous, goal *models.Goal) {
+	if goal.StepMeta == nil {
+		goal.StepMeta = previous.StepMeta
+	}
+	if goal.StepMeta == nil {
+		return
+	}
+
+	meta := make(map[string]models.StepMeta)
+	for _, step := range goal.Steps {
+		if details, exists := goal.StepMeta[step]; exists {
+			meta[step] = details
+		}
+	}
+	goal.StepMeta = meta
+}
+
+// applyStepLifecycle sets the step details the service manages. Steps that were already
+// done keep when and by whom they were completed, newly done steps get now and by, which
+// is zero when unknown, and steps that aren't done have neither. Assignees are carried
+// over, as only AssignStep changes them. Values sent by the client are ignored.
+func applyStepLifecycle(previous, goal *models.Goal, now time.Time, by primitive.ObjectID) {
+	meta := make(map[string]models.StepMeta, len(goal.StepMeta))
+	for step, details := range goal.StepMeta {
+		meta[step] = details
+	}
+	for _, step := range goal.Steps {
+		details := meta[step]
+		details.CompletedAt, details.CompletedBy = nil, nil
+		details.AssigneeID = previous.StepMeta[step].AssigneeID
+		if goal.Progress[step] {
+			details.CompletedAt = &now
+			if !by.IsZero() {
+				details.CompletedBy = &by
+			}
+			if previous.Progress[step] {
+				details.CompletedAt = previous.StepMeta[step].CompletedAt
+				details.CompletedBy = previous.StepMeta[step].CompletedBy
+			}
+		}
+		if details == (models.StepMeta{}) {
+			delete(meta, step)
+		} else {
+			meta[step] = details
+		}
+	}
+	if len(meta) == 0 {
+		meta = nil
+	}
+	goal.StepMeta = meta
+}
+
+// protectedFields are never written by a patch; the service or repository manages them.
+var protectedFields = map[string]bool{
+	"_id":        true,
+	"user_id":    true,
+	"created_at": true,
+	"updated_at": true,
+	"version":    true,
+	"deleted_at": true,
+}
+
+// changedFields compares the stored representation of two goals and returns the
+// fields to $set and the fields to $unset to turn previous into current.
+func changedFields(previous, current *models.Goal) (bson.M, []string, error) {
+	var before, after bson.M
+	for _, pair := range []struct {
+		goal     *models.Goal
+		document *bson.M
+	}{{previous, &before}, {current, &after}} {
+		data, err := bson.Marshal(pair.goal)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Decode nested documents as maps so that key order doesn't count as a change
+		decoder, err := bson.NewDecoder(bsonrw.NewBSONDocumentReader(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		decoder.DefaultDocumentM()
+		if err := decoder.Decode(pair.document); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	set := bson.M{}
+	for field, value := range after {
+		if !protectedFields[field] && !reflect.DeepEqual(before[field], value) {
+			set[field] = value
+		}
+	}
+	var unset []string
+	for field := range before {
+		if _, exists := after[field]; !exists && !protectedFields[field] {
+			unset = append(unset, field)
+		}
+	}
+	return set, unset, nil
+}
+
+// completionTime returns when a goal moving to status was completed: the stored time
+// if it was already completed, now if it just got completed, and nil otherwise.
+func completionTime(previous *models.Goal, status string) *time.Time {
+	switch {
+	case status != "completed":
+		return nil
+	case previous.Status == "completed" && previous.CompletedAt != nil:
+		return previous.CompletedAt
+	default:
+		now := time.Now()
+		return &now
+	}
+}
+
+// progressStatus derives the goal status from its step progress.
+func progressStatus(progress map[string]bool) string {
+	for _, done := range progress {
+		if !done {
+			return "in_progress"
+		}
+	}
+	return "completed"
+}
+
+// emitUpdate emits goal.updated followed by goal.progress, step.completed and
+// goal.completed for every transition between the previous and the current state.
+func (s *GoalService) emitUpdate(ctx context.Context, previous, goal *models.Goal, actor primitive.ObjectID) {
+	s.emit(ctx, models.EventGoalUpdated, previous, goal, "", actor)
+
+	for _, step := range goal.Steps {
+		if goal.Progress[step] == previous.Progress[step] {
+			continue
+		}
+		s.emit(ctx, models.EventGoalProgress, previous, goal, step, actor)
+		if goal.Progress[step] {
+			s.emit(ctx, models.EventStepCompleted, previous, goal, step, actor)
+		}
+	}
+
+	if goal.Status == "completed" && previous.Status != "completed" {
+		s.emit(ctx, models.EventGoalCompleted, previous, goal, "", actor)
+	}
+}
+
+// EmitNoteEvent notifies the listeners that actor added, edited or deleted a note of the goal.
+func (s *GoalService) EmitNoteEvent(ctx context.Context, eventType string, goal *models.Goal, previous, note *models.GoalNote, actor primitive.ObjectID) {
+	s.dispatch(ctx, models.GoalEvent{
+		Type:         eventType,
+		GoalID:       goal.ID,
+		UserID:       goal.UserID,
+		ActorID:      actor,
+		Goal:         goal,
+		Note:         note,
+		PreviousNote: previous,
+	})
+}
+
+// emit dispatches an event about goal; actor is the zero ID for background jobs, which
+// have no actor.
+func (s *GoalService) emit(ctx context.Context, eventType string, previous, goal *models.Goal, step string, actor primitive.ObjectID) {
+	s.dispatch(ctx, models.GoalEvent{
+		Type:     eventType,
+		UserID:   goal.UserID,
+		ActorID:  actor,
+		GoalID:   goal.ID,
+		Goal:     goal,
+		Previous: previous,
+		Step:     step,
+	})
+}
+
+// dispatch stamps the event and hands it to every listener.
+func (s *GoalService) dispatch(ctx context.Context, event models.GoalEvent) {
+	event.ID = primitive.NewObjectID()
+	event.OccurredAt = time.Now()
+	for _, listener := range s.listeners {
+		listener(ctx, event)
+	}
 }
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestChangedFields tests that only modified fields are written, regardless of map order.
+func TestChangedFields(t *testing.T) {
+	previous := &models.Goal{
+		Name:     "Run a marathon",
+		Category: "Health",
+		Progress: map[string]bool{"5K": true, "10K": false, "21K": false},
+	}
+	patched := *previous
+	patched.Name = "Run a half marathon"
+	patched.Category = ""
+	patched.Progress = map[string]bool{"21K": false, "10K": false, "5K": true}
+
+	set, unset, err := changedFields(previous, &patched)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"name": "Run a half marathon"}, set)
+	assert.Equal(t, []string{"category"}, unset)
+}
+
+// TestChangedFieldsClearsFields tests that a full update leaving fields empty unsets them,
+// as omitempty keeps them out of the stored document.
+func TestChangedFieldsClearsFields(t *testing.T) {
+	completedAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	previous := &models.Goal{
+		Name:      "Learn Go",
+		Category:  "Education",
+		Tags:      []string{"go"},
+		Priority:  models.PriorityHighest,
+		Important: true,
+		Urgent:    true,
+		DueDate:   time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+		DueAllDay: true,
+		Steps:     []string{"Tour"},
+		Progress:  map[string]bool{"Tour": true},
+		StepMeta:  map[string]models.StepMeta{"Tour": {CompletedAt: &completedAt}},
+	}
+	cleared := &models.Goal{
+		Name:     "Learn Go",
+		DueDate:  time.Date(2026, 12, 31, 18, 0, 0, 0, time.UTC),
+		Steps:    []string{},
+		Progress: map[string]bool{},
+	}
+
+	set, unset, err := changedFields(previous, cleared)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"category", "tags", "priority", "important", "urgent", "due_all_day", "step_meta"}, unset)
+	assert.Contains(t, set, "due_date")
+	assert.Contains(t, set, "steps")
+}
+
+// TestStepMetaUnsetOnUpdate tests that unchecking the last completed step removes its
+// completion time from the stored goal instead of leaving it behind.
+func TestStepMetaUnsetOnUpdate(t *testing.T) {
+	completedAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	previous := &models.Goal{
+		Name:     "Read",
+		Steps:    []string{"Book 1"},
+		Progress: map[string]bool{"Book 1": true},
+		StepMeta: map[string]models.StepMeta{"Book 1": {CompletedAt: &completedAt}},
+	}
+	unchecked := &models.Goal{Name: "Read", Steps: []string{"Book 1"}, Progress: map[string]bool{"Book 1": false}}
+	syncStepMeta(previous, unchecked)
+	applyStepLifecycle(previous, unchecked, time.Now(), primitive.NilObjectID)
+
+	_, unset, err := changedFields(previous, unchecked)
+	assert.NoError(t, err)
+	assert.Contains(t, unset, "step_meta")
+}
+
+// TestDueAllDayClearedOnUpdate tests that replacing an all-day due date with a timestamp
+// unsets due_all_day, so the goal stops being treated as date-only.
+func TestDueAllDayClearedOnUpdate(t *testing.T) {
+	var previous, updated models.Goal
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"Ship","due_date":"2026-12-31"}`), &previous))
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"Ship","due_date":"2026-12-31T15:00:00Z"}`), &updated))
+	assert.True(t, previous.DueAllDay)
+	assert.False(t, updated.DueAllDay)
+
+	set, unset, err := changedFields(&previous, &updated)
+	assert.NoError(t, err)
+	assert.Contains(t, unset, "due_all_day")
+	assert.Contains(t, set, "due_date")
+}
+
+// TestApplyBulkOperation tests the in-memory effect of bulk operations.
+func TestApplyBulkOperation(t *testing.T) {
+	now := time.Now()
+	goal := &models.Goal{
+		Steps:    []string{"Plan", "Do"},
+		Progress: map[string]bool{"Plan": false, "Do": false},
+		Status:   "in_progress",
+	}
+
+	assert.NoError(t, applyBulkOperation(goal, BulkOperation{Op: BulkAddTag, Value: "q3"}, models.AllowedCategories, now))
+	assert.NoError(t, applyBulkOperation(goal, BulkOperation{Op: BulkAddTag, Value: "q3"}, models.AllowedCategories, now))
+	assert.Equal(t, []string{"q3"}, goal.Tags)
+
+	assert.NoError(t, applyBulkOperation(goal, BulkOperation{Op: BulkCompleteSteps, Steps: []string{"Plan"}}, models.AllowedCategories, now))
+	assert.Equal(t, "in_progress", goal.Status)
+	assert.NoError(t, applyBulkOperation(goal, BulkOperation{Op: BulkCompleteSteps}, models.AllowedCategories, now))
+	assert.Equal(t, "completed", goal.Status)
+	assert.NotNil(t, goal.CompletedAt)
+
+	assert.Error(t, applyBulkOperation(goal, BulkOperation{Op: BulkCompleteSteps, Steps: []string{"Missing"}}, models.AllowedCategories, now))
+	assert.Error(t, applyBulkOperation(goal, BulkOperation{Op: BulkSetCategory, Value: "Nope"}, models.AllowedCategories, now))
+	assert.Error(t, applyBulkOperation(goal, BulkOperation{Op: "explode"}, models.AllowedCategories, now))
+}
+
+// TestBuildClone tests renaming, progress reset and due date shifting.
+func TestBuildClone(t *testing.T) {
+	dueDate := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	stepDue := time.Date(2026, 8, 15, 0, 0, 0, 0, time.UTC)
+	source := &models.Goal{
+		Name:     "Q3 reading plan",
+		Tags:     []string{"reading"},
+		Steps:    []string{"Book 1", "Book 2"},
+		Progress: map[string]bool{"Book 1": true, "Book 2": false},
+		StepMeta: map[string]models.StepMeta{"Book 1": {DueDate: &stepDue}},
+		Status:   "in_progress",
+		DueDate:  dueDate,
+	}
+
+	clone := BuildClone(source, CloneOptions{Name: "Q4 reading plan", ResetProgress: true, ShiftDays: 92})
+	assert.Equal(t, "Q4 reading plan", clone.Name)
+	assert.Equal(t, "pending", clone.Status)
+	assert.Equal(t, map[string]bool{"Book 1": false, "Book 2": false}, clone.Progress)
+	assert.Equal(t, time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), clone.DueDate)
+	assert.Equal(t, time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC), *clone.StepMeta["Book 1"].DueDate)
+	assert.Empty(t, clone.Tags)
+	assert.Equal(t, stepDue, *source.StepMeta["Book 1"].DueDate)
+	assert.Nil(t, clone.StepMeta["Book 1"].CompletedAt)
+
+	kept := BuildClone(source, CloneOptions{CopyTags: true})
+	assert.Equal(t, "Q3 reading plan (copy)", kept.Name)
+	assert.True(t, kept.Progress["Book 1"])
+	assert.Equal(t, []string{"reading"}, kept.Tags)
+}
+
+// TestValidateParent tests that sub-goals stay with the owner or organization of their parent.
+func TestValidateParent(t *testing.T) {
+	owner, other := primitive.NewObjectID(), primitive.NewObjectID()
+	orgID, otherOrgID := primitive.NewObjectID(), primitive.NewObjectID()
+	personal := &models.Goal{UserID: owner}
+	team := &models.Goal{UserID: other, OrgID: &orgID}
+
+	assert.NoError(t, ValidateParent(&models.Goal{UserID: owner}, personal))
+	assert.ErrorIs(t, ValidateParent(&models.Goal{UserID: other}, personal), ErrInvalidParent)
+	assert.ErrorIs(t, ValidateParent(&models.Goal{UserID: owner, OrgID: &orgID}, personal), ErrInvalidParent)
+	assert.NoError(t, ValidateParent(&models.Goal{UserID: owner, OrgID: &orgID}, team))
+	assert.ErrorIs(t, ValidateParent(&models.Goal{UserID: owner, OrgID: &otherOrgID}, team), ErrInvalidParent)
+	assert.ErrorIs(t, ValidateParent(&models.Goal{UserID: other}, team), ErrInvalidParent)
+}
+
+// TestGoalParentKept tests that clones stay below the parent of their source and that
+// updates can't move a goal to another parent.
+func TestGoalParentKept(t *testing.T) {
+	parentID, otherID := primitive.NewObjectID(), primitive.NewObjectID()
+	source := &models.Goal{Name: "Chapter 1", ParentID: &parentID}
+
+	clone := BuildClone(source, CloneOptions{IncludeSubGoals: true})
+	assert.Equal(t, &parentID, clone.ParentID)
+
+	updated := &models.Goal{Name: "Chapter 1", ParentID: &otherID}
+	applyLifecycle(source, updated)
+	assert.Equal(t, &parentID, updated.ParentID)
+}
+
+// TestApplyStepLifecycle tests that completion times and completers are kept, set and
+// cleared as steps change, and that assignees are kept, whatever the client sent.
+func TestApplyStepLifecycle(t *testing.T) {
+	earlier := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	stepDue := time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+	assignee, other, actor := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	previous := &models.Goal{
+		Steps:    []string{"Read", "Write", "Review"},
+		Progress: map[string]bool{"Read": true, "Write": false, "Review": true},
+		StepMeta: map[string]models.StepMeta{
+			"Read":   {CompletedAt: &earlier, CompletedBy: &assignee, AssigneeID: &assignee},
+			"Review": {DueDate: &stepDue, CompletedAt: &earlier},
+		},
+	}
+	forged := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	goal := &models.Goal{
+		Steps:    previous.Steps,
+		Progress: map[string]bool{"Read": true, "Write": true, "Review": false},
+		StepMeta: map[string]models.StepMeta{
+			"Read":   {CompletedAt: &forged},
+			"Write":  {AssigneeID: &other, CompletedBy: &other},
+			"Review": previous.StepMeta["Review"],
+		},
+	}
+
+	applyStepLifecycle(previous, goal, now, actor)
+	assert.Equal(t, earlier, *goal.StepMeta["Read"].CompletedAt)
+	assert.Equal(t, assignee, *goal.StepMeta["Read"].CompletedBy)
+	assert.Equal(t, assignee, *goal.StepMeta["Read"].AssigneeID)
+	assert.Equal(t, now, *goal.StepMeta["Write"].CompletedAt)
+	assert.Equal(t, actor, *goal.StepMeta["Write"].CompletedBy)
+	assert.Nil(t, goal.StepMeta["Write"].AssigneeID)
+	assert.Nil(t, goal.StepMeta["Review"].CompletedAt)
+	assert.Equal(t, stepDue, *goal.StepMeta["Review"].DueDate)
+	assert.Equal(t, earlier, *previous.StepMeta["Review"].CompletedAt)
+
+	goal.Progress = map[string]bool{"Read": false, "Write": false, "Review": false}
+	goal.StepMeta = map[string]models.StepMeta{"Write": {CompletedAt: &earlier}}
+	applyStepLifecycle(previous, goal, now, actor)
+	assert.Equal(t, map[string]models.StepMeta{"Read": {AssigneeID: &assignee}}, goal.StepMeta)
+
+	// Without a logged-in user, completions aren't attributed
+	goal.Progress = map[string]bool{"Read": false, "Write": true, "Review": false}
+	applyStepLifecycle(previous, goal, now, primitive.NilObjectID)
+	assert.Equal(t, now, *goal.StepMeta["Write"].CompletedAt)
+	assert.Nil(t, goal.StepMeta["Write"].CompletedBy)
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Real-time stream settings.
+const (
+	streamReplayBufferSize = 100
+	streamSubscriberBuffer = 16
+	// streamIdleTTL is how long a user's replay buffer outlives their last subscriber
+	streamIdleTTL = 5 * time.Minute
+)
+
+// StreamEvent is a goal event as delivered to real-time subscribers.
+type StreamEvent struct {
+	ID   string
+	Type string
+	Data []byte
+}
+
+// GoalSubscription is a live feed of goal events for a single user.
+type GoalSubscription struct {
+	// Events delivers new events; it is closed when the subscriber falls too far behind.
+	Events <-chan StreamEvent
+	// Replay holds the events missed since the Last-Event-ID given on subscribe.
+	Replay []StreamEvent
+	// Reset is set when the requested Last-Event-ID is no longer available,
+	// meaning the client has to refetch its goals instead of resuming.
+	Reset bool
+	// Close unregisters the subscription.
+	Close func()
+}
+
+// GoalEventBroker fans goal events out to real-time subscribers. The in-process
+// MemoryGoalBroker is the default; an implementation backed by MongoDB change
+// streams can satisfy the same interface by using resume tokens as event IDs.
+type GoalEventBroker interface {
+	Publish(ctx context.Context, event models.GoalEvent)
+	Subscribe(userID primitive.ObjectID, lastEventID string) *GoalSubscription
+}
+
+// GoalViewers returns the users allowed to view a goal.
+type GoalViewers func(ctx context.Context, goal *models.Goal) ([]primitive.ObjectID, error)
+
+// userStream is the replay buffer and the live subscribers of one user.
+type userStream struct {
+	events []StreamEvent
+	// since is the last event ID the buffer may be missing; every later event of the
+	// user is buffered until it is evicted
+	since       uint64
+	subscribers map[chan StreamEvent]struct{}
+	// idleSince is when the last subscriber left, or zero while any is connected
+	idleSince time.Time
+}
+
+// MemoryGoalBroker is an in-process pub/sub hub that keeps a bounded replay buffer per
+// user. A user's buffer is dropped once they have had no subscriber for streamIdleTTL.
+type MemoryGoalBroker struct {
+	viewers GoalViewers
+	now     func() time.Time
+
+	mu      sync.Mutex
+	seq     uint64
+	streams map[primitive.ObjectID]*userStream
+	swept   time.Time
+}
+
+// NewMemoryGoalBroker creates a new instance of MemoryGoalBroker that sends each event
+// to every viewer of its goal. Without viewers, events only reach the goal owner.
+func NewMemoryGoalBroker(viewers GoalViewers) *MemoryGoalBroker {
+	return &MemoryGoalBroker{
+		viewers: viewers,
+		now:     time.Now,
+		streams: make(map[primitive.ObjectID]*userStream),
+	}
+}
+
+// Publish is a GoalEventListener that forwards the event to the subscribers of every
+// user who can view the goal.
+func (b *MemoryGoalBroker) Publish(ctx context.Context, event models.GoalEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode stream event: %v", err)
+		return
+	}
+	recipients := []primitive.ObjectID{event.UserID}
+	if b.viewers != nil && event.Goal != nil {
+		viewers, err := b.viewers(ctx, event.Goal)
+		if err != nil {
+			log.Printf("Failed to find viewers of goal %s: %v", event.GoalID.Hex(), err)
+		} else {
+			recipients = viewers
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sweep()
+
+	b.seq++
+	streamEvent := StreamEvent{
+		ID:   strconv.FormatUint(b.seq, 10),
+		Type: event.Type,
+		Data: data,
+	}
+
+	// Only users who are or were recently connected keep a buffer
+	for _, userID := range recipients {
+		stream := b.streams[userID]
+		if stream == nil {
+			continue
+		}
+		stream.events = append(stream.events, streamEvent)
+		if len(stream.events) > streamReplayBufferSize {
+			evicted := len(stream.events) - streamReplayBufferSize
+			stream.since, _ = strconv.ParseUint(stream.events[evicted-1].ID, 10, 64)
+			stream.events = stream.events[evicted:]
+		}
+
+		for ch := range stream.subscribers {
+			select {
+			case ch <- streamEvent:
+			default:
+				// The subscriber is too slow; drop it so it reconnects and replays
+				b.unsubscribe(stream, ch)
+			}
+		}
+	}
+}
+
+// Subscribe registers a new subscriber for the user's goal events, replaying
+// buffered events published after lastEventID when it is given.
+func (b *MemoryGoalBroker) Subscribe(userID primitive.ObjectID, lastEventID string) *GoalSubscription {
+	ch := make(chan StreamEvent, streamSubscriberBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sweep()
+
+	stream := b.streams[userID]
+	if stream == nil {
+		stream = &userStream{since: b.seq, subscribers: make(map[chan StreamEvent]struct{})}
+		b.streams[userID] = stream
+	}
+
+	subscription := &GoalSubscription{Events: ch}
+	if lastEventID != "" {
+		subscription.Replay, subscription.Reset = b.replay(stream, lastEventID)
+	}
+
+	stream.subscribers[ch] = struct{}{}
+	stream.idleSince = time.Time{}
+
+	subscription.Close = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := stream.subscribers[ch]; ok {
+			b.unsubscribe(stream, ch)
+		}
+	}
+
+	return subscription
+}
+
+// unsubscribe removes a subscriber, starting the idle period of its user when it was
+// the last one.
+func (b *MemoryGoalBroker) unsubscribe(stream *userStream, ch chan StreamEvent) {
+	delete(stream.subscribers, ch)
+	close(ch)
+	if len(stream.subscribers) == 0 {
+		stream.idleSince = b.now()
+	}
+}
+
+// sweep drops the buffers of users who have had no subscriber for streamIdleTTL.
+func (b *MemoryGoalBroker) sweep() {
+	now := b.now()
+	if now.Sub(b.swept) < streamIdleTTL {
+		return
+	}
+	for userID, stream := range b.streams {
+		if len(stream.subscribers) == 0 && now.Sub(stream.idleSince) >= streamIdleTTL {
+			delete(b.streams, userID)
+		}
+	}
+	b.swept = now
+}
+
+// replay returns the buffered events newer than lastEventID and whether the client
+// must reset because events after the ID may be missing from the buffer.
+func (b *MemoryGoalBroker) replay(stream *userStream, lastEventID string) ([]StreamEvent, bool) {
+	last, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil || last > b.seq || last < stream.since {
+		return nil, true
+	}
+
+	var missed []StreamEvent
+	for _, event := range stream.events {
+		id, _ := strconv.ParseUint(event.ID, 10, 64)
+		if id > last {
+			missed = append(missed, event)
+		}
+	}
+	return missed, false
+}
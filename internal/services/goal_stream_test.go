@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestMemoryGoalBrokerReplay tests live delivery and Last-Event-ID resume.
+func TestMemoryGoalBrokerReplay(t *testing.T) {
+	broker := NewMemoryGoalBroker(nil)
+	userID := primitive.NewObjectID()
+	otherUserID := primitive.NewObjectID()
+
+	live := broker.Subscribe(userID, "")
+	defer live.Close()
+
+	broker.Publish(context.Background(), models.GoalEvent{Type: models.EventGoalCreated, UserID: userID})
+	broker.Publish(context.Background(), models.GoalEvent{Type: models.EventGoalCreated, UserID: otherUserID})
+	broker.Publish(context.Background(), models.GoalEvent{Type: models.EventGoalUpdated, UserID: userID})
+
+	first := <-live.Events
+	assert.Equal(t, "1", first.ID)
+	second := <-live.Events
+	assert.Equal(t, models.EventGoalUpdated, second.Type)
+	assert.Empty(t, live.Events)
+
+	resumed := broker.Subscribe(userID, first.ID)
+	defer resumed.Close()
+	assert.False(t, resumed.Reset)
+	assert.Len(t, resumed.Replay, 1)
+	assert.Equal(t, second.ID, resumed.Replay[0].ID)
+
+	unknown := broker.Subscribe(userID, "not-an-id")
+	defer unknown.Close()
+	assert.True(t, unknown.Reset)
+}
+
+// TestMemoryGoalBrokerReplayBoundary tests resuming from the oldest buffered event of a
+// full buffer, and from an evicted one.
+func TestMemoryGoalBrokerReplayBoundary(t *testing.T) {
+	broker := NewMemoryGoalBroker(nil)
+	userID := primitive.NewObjectID()
+	live := broker.Subscribe(userID, "")
+	defer live.Close()
+
+	for i := 0; i < streamReplayBufferSize+streamSubscriberBuffer; i++ {
+		broker.Publish(context.Background(), models.GoalEvent{Type: models.EventGoalUpdated, UserID: userID})
+	}
+	oldest := strconv.Itoa(streamSubscriberBuffer + 1)
+
+	resumed := broker.Subscribe(userID, oldest)
+	defer resumed.Close()
+	assert.False(t, resumed.Reset)
+	assert.Len(t, resumed.Replay, streamReplayBufferSize-1)
+
+	// The event just before the oldest buffered one was evicted, but the client saw it
+	justBefore := broker.Subscribe(userID, strconv.Itoa(streamSubscriberBuffer))
+	defer justBefore.Close()
+	assert.False(t, justBefore.Reset)
+	assert.Len(t, justBefore.Replay, streamReplayBufferSize)
+
+	evicted := broker.Subscribe(userID, strconv.Itoa(streamSubscriberBuffer-1))
+	defer evicted.Close()
+	assert.True(t, evicted.Reset)
+}
+
+// TestMemoryGoalBrokerViewers tests that events reach every viewer of the goal.
+func TestMemoryGoalBrokerViewers(t *testing.T) {
+	ownerID, viewerID, strangerID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	broker := NewMemoryGoalBroker(func(ctx context.Context, goal *models.Goal) ([]primitive.ObjectID, error) {
+		return []primitive.ObjectID{ownerID, viewerID}, nil
+	})
+
+	owner := broker.Subscribe(ownerID, "")
+	defer owner.Close()
+	viewer := broker.Subscribe(viewerID, "")
+	defer viewer.Close()
+	stranger := broker.Subscribe(strangerID, "")
+	defer stranger.Close()
+
+	broker.Publish(context.Background(), models.GoalEvent{Type: models.EventGoalUpdated, UserID: ownerID, Goal: &models.Goal{UserID: ownerID}})
+
+	assert.Len(t, owner.Events, 1)
+	assert.Len(t, viewer.Events, 1)
+	assert.Empty(t, stranger.Events)
+}
+
+// TestMemoryGoalBrokerIdleBuffers tests that a user's buffer survives short disconnects
+// and is dropped after the idle TTL.
+func TestMemoryGoalBrokerIdleBuffers(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	broker := NewMemoryGoalBroker(nil)
+	broker.now = func() time.Time { return now }
+	userID := primitive.NewObjectID()
+
+	first := broker.Subscribe(userID, "")
+	broker.Publish(context.Background(), models.GoalEvent{Type: models.EventGoalCreated, UserID: userID})
+	first.Close()
+
+	now = now.Add(time.Minute)
+	broker.Publish(context.Background(), models.GoalEvent{Type: models.EventGoalUpdated, UserID: userID})
+	resumed := broker.Subscribe(userID, "1")
+	assert.False(t, resumed.Reset)
+	assert.Len(t, resumed.Replay, 1)
+	resumed.Close()
+
+	now = now.Add(streamIdleTTL)
+	broker.Publish(context.Background(), models.GoalEvent{Type: models.EventGoalUpdated, UserID: primitive.NewObjectID()})
+	assert.NotContains(t, broker.streams, userID)
+
+	late := broker.Subscribe(userID, "2")
+	defer late.Close()
+	assert.True(t, late.Reset)
+}
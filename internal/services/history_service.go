@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNoSnapshot is returned when restoring a revision that recorded no snapshot of the goal.
+var ErrNoSnapshot = errors.New("revision has no snapshot to restore")
+
+// historyIgnoredFields are bookkeeping fields that never show up in a diff.
+var historyIgnoredFields = map[string]bool{
+	"id":         true,
+	"updated_at": true,
+	"version":    true,
+}
+
+// HistoryService records and serves the activity history of goals.
+type HistoryService struct {
+	repo  *repository.HistoryRepository
+	goals *GoalService
+}
+
+// NewHistoryService creates a new instance of HistoryService.
+func NewHistoryService(repo *repository.HistoryRepository, goals *GoalService) *HistoryService {
+	return &HistoryService{
+		repo:  repo,
+		goals: goals,
+	}
+}
+
+// RecordGoalEvent is a GoalEventListener that appends every goal mutation to the activity log.
+func (s *HistoryService) RecordGoalEvent(ctx context.Context, event models.GoalEvent) {
+	entry := &models.GoalHistoryEntry{
+		GoalID:  event.GoalID,
+		UserID:  event.UserID,
+		ActorID: event.ActorID,
+		Event:   event.Type,
+		Changes: []models.FieldChange{},
+	}
+
+	// Derived events are already covered by the goal.updated entry they accompany
+	switch event.Type {
+	case models.EventGoalCreated, models.EventGoalUpdated, models.EventGoalRestored:
+		entry.Changes = DiffGoals(event.Previous, event.Goal)
+		entry.Snapshot = event.Goal
+	case models.EventGoalDeleted:
+	case models.EventNoteAdded, models.EventNoteUpdated, models.EventNoteDeleted:
+		// Notes live outside the goal, so there is no goal snapshot to restore
+		entry.Changes = NoteChanges(event)
+	default:
+		return
+	}
+
+	// Updates that didn't change anything are not worth a revision
+	if (event.Type == models.EventGoalUpdated || event.Type == models.EventNoteUpdated) && len(entry.Changes) == 0 {
+		return
+	}
+
+	if _, err := s.repo.CreateEntry(ctx, entry); err != nil {
+		log.Printf("Failed to record goal history: %v", err)
+	}
+}
+
+// GetHistory retrieves a page of a goal's activity log, newest first.
+func (s *HistoryService) GetHistory(ctx context.Context, goalID primitive.ObjectID, page, limit int64) ([]models.GoalHistoryEntry, int64, error) {
+	entries, total, err := s.repo.GetEntries(ctx, goalID, page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch goal history: %v", err)
+	}
+	return entries, total, nil
+}
+
+// RestoreRevision overwrites a goal with the snapshot stored for the given revision.
+// The restore itself is recorded as a new revision, made by actor. It fails with
+// repository.ErrVersionConflict when the goal changed since it was read.
+func (s *HistoryService) RestoreRevision(ctx context.Context, goal *models.Goal, revision int, actor primitive.ObjectID) (*models.Goal, error) {
+	entry, err := s.repo.GetEntryByRevision(ctx, goal.ID, revision)
+	if err != nil {
+		if errors.Is(err, repository.ErrRevisionNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get revision: %v", err)
+	}
+	if entry.Snapshot == nil {
+		return nil, ErrNoSnapshot
+	}
+
+	return s.goals.RestoreSnapshot(ctx, goal, entry.Snapshot, actor)
+}
+
+// BuildRestored returns the goal current becomes when a snapshot of it is restored. The
+// snapshot's content, including its step completion times, is taken as is; identity,
+// ownership, placement and the archive and trash state stay as they are now, and so do
+// step assignees, which only AssignStep changes.
+func BuildRestored(current, snapshot *models.Goal) *models.Goal {
+	restored := *snapshot
+	restored.ID = current.ID
+	restored.UserID = current.UserID
+	restored.OrgID = current.OrgID
+	restored.ParentID = current.ParentID
+	restored.CreatedAt = current.CreatedAt
+	restored.ArchivedAt = current.ArchivedAt
+	restored.DeletedAt = nil
+	restored.Version = current.Version
+	if restored.Status != "completed" {
+		restored.CompletedAt = nil
+	}
+
+	var meta map[string]models.StepMeta
+	for _, step := range restored.Steps {
+		details := snapshot.StepMeta[step]
+		details.AssigneeID = current.StepMeta[step].AssigneeID
+		if details == (models.StepMeta{}) {
+			continue
+		}
+		if meta == nil {
+			meta = make(map[string]models.StepMeta)
+		}
+		meta[step] = details
+	}
+	restored.StepMeta = meta
+	return &restored
+}
+
+// NoteChanges describes a note event as changes of the fields notes.<note id>.body,
+// .step and .mood.
+func NoteChanges(event models.GoalEvent) []models.FieldChange {
+	var from, to map[string]interface{}
+	var id primitive.ObjectID
+	if event.Type != models.EventNoteAdded && event.PreviousNote != nil {
+		from, id = noteDocument(event.PreviousNote), event.PreviousNote.ID
+	}
+	if event.Type != models.EventNoteDeleted && event.Note != nil {
+		to, id = noteDocument(event.Note), event.Note.ID
+	}
+
+	changes := []models.FieldChange{}
+	diffValues("notes."+id.Hex()+".", from, to, &changes)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// DiffGoals returns the field-level changes between two versions of a goal.
+// Nested objects such as progress are compared key by key.
+func DiffGoals(previous, current *models.Goal) []models.FieldChange {
+	changes := []models.FieldChange{}
+	diffValues("", toDocument(previous), toDocument(current), &changes)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+func diffValues(prefix string, from, to map[string]interface{}, changes *[]models.FieldChange) {
+	fields := make(map[string]bool)
+	for field := range from {
+		fields[field] = true
+	}
+	for field := range to {
+		fields[field] = true
+	}
+
+	for field := range fields {
+		if prefix == "" && historyIgnoredFields[field] {
+			continue
+		}
+		oldValue, newValue := from[field], to[field]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			diffValues(prefix+field+".", oldMap, newMap, changes)
+			continue
+		}
+
+		*changes = append(*changes, models.FieldChange{Field: prefix + field, From: oldValue, To: newValue})
+	}
+}
+
+// toDocument converts a goal to its generic JSON representation.
+func toDocument(goal *models.Goal) map[string]interface{} {
+	document := make(map[string]interface{})
+	if goal == nil {
+		return document
+	}
+	data, err := json.Marshal(goal)
+	if err != nil {
+		return document
+	}
+	_ = json.Unmarshal(data, &document)
+	return document
+}
+
+// noteDocument converts the user-written fields of a note to their generic JSON representation.
+func noteDocument(note *models.GoalNote) map[string]interface{} {
+	document := map[string]interface{}{"body": note.Body}
+	if note.Step != "" {
+		document["step"] = note.Step
+	}
+	if note.Mood != 0 {
+		document["mood"] = note.Mood
+	}
+	return document
+}
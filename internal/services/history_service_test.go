@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestDiffGoals tests field-level diffs, including nested progress changes.
+func TestDiffGoals(t *testing.T) {
+	previous := &models.Goal{
+		Name:        "Read books",
+		Description: "Ten books",
+		Steps:       []string{"Book 1", "Book 2"},
+		Progress:    map[string]bool{"Book 1": false, "Book 2": false},
+		Status:      "in_progress",
+	}
+	current := *previous
+	current.Description = "Twelve books"
+	current.Progress = map[string]bool{"Book 1": true, "Book 2": false}
+
+	changes := DiffGoals(previous, &current)
+	assert.Equal(t, []models.FieldChange{
+		{Field: "description", From: "Ten books", To: "Twelve books"},
+		{Field: "progress.Book 1", From: false, To: true},
+	}, changes)
+
+	assert.Empty(t, DiffGoals(previous, previous))
+}
+
+// TestNoteChanges tests how note events are described in the goal history.
+func TestNoteChanges(t *testing.T) {
+	previous := &models.GoalNote{ID: primitive.NewObjectID(), Body: "Tired", Mood: 2}
+	note := *previous
+	note.Body = "Tired but done"
+	prefix := "notes." + previous.ID.Hex() + "."
+
+	changes := NoteChanges(models.GoalEvent{Type: models.EventNoteUpdated, PreviousNote: previous, Note: &note})
+	assert.Equal(t, []models.FieldChange{{Field: prefix + "body", From: "Tired", To: "Tired but done"}}, changes)
+
+	changes = NoteChanges(models.GoalEvent{Type: models.EventNoteDeleted, PreviousNote: previous, Note: previous})
+	assert.Len(t, changes, 2)
+	assert.Equal(t, prefix+"body", changes[0].Field)
+	assert.Nil(t, changes[0].To)
+}
+
+// TestBuildRestored tests that restoring a revision brings back its empty fields and
+// step completion times while keeping the goal's identity and assignees.
+func TestBuildRestored(t *testing.T) {
+	completedAt := time.Date(2026, 9, 1, 9, 0, 0, 0, time.UTC)
+	assignee := primitive.NewObjectID()
+	orgID := primitive.NewObjectID()
+	current := &models.Goal{
+		ID:        primitive.NewObjectID(),
+		UserID:    primitive.NewObjectID(),
+		OrgID:     &orgID,
+		Name:      "Learn Go (renamed)",
+		Tags:      []string{"go", "study"},
+		Important: true,
+		Steps:     []string{"Tour", "Project"},
+		Progress:  map[string]bool{"Tour": true, "Project": true},
+		StepMeta: map[string]models.StepMeta{
+			"Tour":    {CompletedAt: &completedAt},
+			"Project": {AssigneeID: &assignee},
+		},
+		Version: 4,
+	}
+	snapshot := &models.Goal{
+		ID:       current.ID,
+		UserID:   current.UserID,
+		Name:     "Learn Go",
+		Steps:    []string{"Tour", "Project"},
+		Progress: map[string]bool{"Tour": true, "Project": false},
+		StepMeta: map[string]models.StepMeta{"Tour": {CompletedAt: &completedAt}},
+		Version:  2,
+	}
+
+	restored := BuildRestored(current, snapshot)
+	assert.Equal(t, "Learn Go", restored.Name)
+	assert.Equal(t, &orgID, restored.OrgID)
+	assert.Equal(t, int64(4), restored.Version)
+	assert.Equal(t, map[string]models.StepMeta{
+		"Tour":    {CompletedAt: &completedAt},
+		"Project": {AssigneeID: &assignee},
+	}, restored.StepMeta)
+
+	set, unset, err := changedFields(current, restored)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"tags", "important"}, unset)
+	assert.Equal(t, "Learn Go", set["name"])
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runEvery runs job immediately and then every interval until ctx is cancelled,
+// logging failures and the number of items the job processed.
+func runEvery(ctx context.Context, interval time.Duration, name string, job func(ctx context.Context) (int, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := job(ctx)
+		if err != nil {
+			log.Printf("%s failed: %v", name, err)
+		} else if processed > 0 {
+			log.Printf("%s processed %d items", name, processed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
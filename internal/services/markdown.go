@@ -0,0 +1,292 @@
+package services
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// referenceLinkPattern matches a link reference definition: [label]: destination. The
+	// destination may follow on the next line.
+	referenceLinkPattern = regexp.MustCompile(`(?m)^(\s{0,3}\[[^\]]+\]:\s*)(\S+)(.*)$`)
+	// unsafeSchemes are URL schemes that can run code when a rendered link is followed
+	unsafeSchemes = []string{"javascript:", "vbscript:", "data:", "file:"}
+)
+
+// SanitizeMarkdown makes user-written Markdown safe to store and render. Raw HTML outside
+// code is escaped so that it shows up as text, links and images pointing to script or data
+// URLs are replaced with "#", and control characters other than tabs and newlines are removed.
+//
+// Code blocks and spans follow the CommonMark rules. Wherever a renderer could disagree,
+// the text is treated as regular text and escaped.
+func SanitizeMarkdown(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = stripControl(body)
+
+	var out, text []string
+	flush := func() {
+		if len(text) > 0 {
+			out = append(out, sanitizeMarkdownText(strings.Join(text, "\n")))
+			text = nil
+		}
+	}
+
+	var fence *codeFence
+	for _, line := range strings.Split(body, "\n") {
+		// Fenced code blocks are rendered as text and stay untouched
+		if fence != nil {
+			if fence.closedBy(line) {
+				fence = nil
+				out = append(out, line)
+				continue
+			}
+			// A fence inside a list item ends with the item
+			if indent, _ := indentation(line); strings.TrimSpace(line) == "" || indent >= fence.indent {
+				out = append(out, line)
+				continue
+			}
+			fence = nil
+		}
+		if opened, ok := openingFence(line); ok {
+			flush()
+			fence = &opened
+			out = append(out, line)
+			continue
+		}
+		text = append(text, line)
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+// codeFence is an open fenced code block.
+type codeFence struct {
+	char   byte
+	length int
+	indent int
+}
+
+// openingFence parses a line opening a fenced code block: up to three spaces of indentation
+// and at least three backticks or tildes. A backtick fence's info string has no backticks.
+func openingFence(line string) (codeFence, bool) {
+	indent, offset := indentation(line)
+	if indent > 3 || offset >= len(line) || (line[offset] != '`' && line[offset] != '~') {
+		return codeFence{}, false
+	}
+	length := runLength(line, offset)
+	if length < 3 || (line[offset] == '`' && strings.Contains(line[offset+length:], "`")) {
+		return codeFence{}, false
+	}
+	return codeFence{char: line[offset], length: length, indent: indent}, true
+}
+
+// closedBy reports whether a line closes the fence: the same character repeated at least as
+// many times, with up to three spaces of indentation and nothing but whitespace after.
+func (f codeFence) closedBy(line string) bool {
+	indent, offset := indentation(line)
+	if indent > 3 || offset >= len(line) || line[offset] != f.char {
+		return false
+	}
+	length := runLength(line, offset)
+	return length >= f.length && strings.Trim(line[offset+length:], " \t") == ""
+}
+
+// sanitizeMarkdownText sanitizes text outside code blocks, skipping inline code spans.
+func sanitizeMarkdownText(text string) string {
+	text = referenceLinkPattern.ReplaceAllStringFunc(text, func(definition string) string {
+		match := referenceLinkPattern.FindStringSubmatch(definition)
+		if safeURL(match[2]) {
+			return definition
+		}
+		return match[1] + "#" + match[3]
+	})
+
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		switch {
+		case text[i] == '\\' && i+1 < len(text) && isASCIIPunct(text[i+1]):
+			// An escaped character never opens a code span or a tag
+			out.WriteString(text[i : i+2])
+			i += 2
+		case text[i] == '`':
+			// A code span ends at the next run of exactly as many backticks on the line,
+			// otherwise the backticks are literal
+			length := runLength(text, i)
+			end := codeSpanEnd(text, i+length, length)
+			if end < 0 {
+				end = i + length
+			}
+			out.WriteString(text[i:end])
+			i = end
+		case text[i] == '<':
+			out.WriteString("&lt;")
+			i++
+		case strings.HasPrefix(text[i:], "]("):
+			end, destStart, destEnd, ok := inlineLinkTail(text, i+2)
+			if !ok {
+				out.WriteString("](")
+				i += 2
+				continue
+			}
+			tail := text[i:end]
+			if !safeURL(text[destStart:destEnd]) {
+				tail = text[i:destStart] + "#" + text[destEnd:end]
+			}
+			out.WriteString(strings.ReplaceAll(tail, "<", "&lt;"))
+			i = end
+		default:
+			out.WriteByte(text[i])
+			i++
+		}
+	}
+	return out.String()
+}
+
+// codeSpanEnd returns the offset after the run of exactly length backticks closing a code
+// span opened before from, or -1 when the line has none.
+func codeSpanEnd(text string, from, length int) int {
+	for i := from; i < len(text) && text[i] != '\n'; {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+		run := runLength(text, i)
+		if run == length {
+			return i + run
+		}
+		i += run
+	}
+	return -1
+}
+
+// inlineLinkTail parses the part of an inline link or image after "](": a destination with
+// balanced parentheses or in angle brackets, an optional title and the closing parenthesis.
+// It returns the offset after the link and the bounds of the destination.
+func inlineLinkTail(text string, start int) (end, destStart, destEnd int, ok bool) {
+	i := skipMarkdownSpace(text, start)
+	destStart = i
+	if i < len(text) && text[i] == '<' {
+		for i++; i < len(text) && text[i] != '>'; i++ {
+			if text[i] == '\n' || text[i] == '<' {
+				return 0, 0, 0, false
+			}
+			if text[i] == '\\' && i+1 < len(text) {
+				i++
+			}
+		}
+		if i >= len(text) {
+			return 0, 0, 0, false
+		}
+		i++
+	} else {
+		depth := 0
+		for ; i < len(text); i++ {
+			c := text[i]
+			if c == '\\' && i+1 < len(text) {
+				i++
+				continue
+			}
+			if c <= ' ' || (c == ')' && depth == 0) {
+				break
+			}
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+			}
+		}
+	}
+	destEnd = i
+
+	i = skipMarkdownSpace(text, i)
+	if i < len(text) && (text[i] == '"' || text[i] == '\'' || text[i] == '(') {
+		closer := text[i]
+		if closer == '(' {
+			closer = ')'
+		}
+		for i++; i < len(text) && text[i] != closer; i++ {
+			if text[i] == '\\' && i+1 < len(text) {
+				i++
+			}
+		}
+		if i >= len(text) {
+			return 0, 0, 0, false
+		}
+		i = skipMarkdownSpace(text, i+1)
+	}
+	if i >= len(text) || text[i] != ')' {
+		return 0, 0, 0, false
+	}
+	return i + 1, destStart, destEnd, true
+}
+
+// safeURL reports whether a link destination uses none of the unsafeSchemes, after undoing
+// the entity encoding, escapes and whitespace tricks renderers would also undo.
+func safeURL(destination string) bool {
+	url := strings.ToLower(html.UnescapeString(destination))
+	url = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || r == '\\' {
+			return -1
+		}
+		return r
+	}, url)
+	url = strings.TrimPrefix(url, "<")
+	for _, scheme := range unsafeSchemes {
+		if strings.HasPrefix(url, scheme) {
+			return false
+		}
+	}
+	return true
+}
+
+// indentation returns the width of the leading whitespace of a line, with tabs advancing to
+// the next multiple of four columns, and the offset of the first other character.
+func indentation(line string) (width, offset int) {
+	for ; offset < len(line); offset++ {
+		switch line[offset] {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width, offset
+		}
+	}
+	return width, offset
+}
+
+// runLength counts how many times the byte at offset i repeats from there.
+func runLength(text string, i int) int {
+	n := 1
+	for i+n < len(text) && text[i+n] == text[i] {
+		n++
+	}
+	return n
+}
+
+// skipMarkdownSpace returns the offset of the first character from i that is not a space,
+// a tab or a line break.
+func skipMarkdownSpace(text string, i int) int {
+	for i < len(text) && (text[i] == ' ' || text[i] == '\t' || text[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// isASCIIPunct reports whether c is an ASCII punctuation character, which Markdown lets a
+// backslash escape.
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// stripControl removes control characters other than tabs and newlines.
+func stripControl(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, text)
+}
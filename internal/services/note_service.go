@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxNoteLength limits the length of a note body, in bytes.
+const MaxNoteLength = 20000
+
+// NoteService encapsulates the business logic for goal notes and journal entries.
+type NoteService struct {
+	repo  *repository.NoteRepository
+	goals *GoalService
+}
+
+// NewNoteService creates a new instance of NoteService.
+func NewNoteService(repo *repository.NoteRepository, goals *GoalService) *NoteService {
+	return &NoteService{
+		repo:  repo,
+		goals: goals,
+	}
+}
+
+// CreateNote sanitizes and stores a new note actor wrote on the goal.
+func (s *NoteService) CreateNote(ctx context.Context, goal *models.Goal, note *models.GoalNote, actor primitive.ObjectID) (*models.GoalNote, error) {
+	note.ID = primitive.NilObjectID
+	note.GoalID = goal.ID
+	note.UserID = goal.UserID
+	note.Body = SanitizeMarkdown(note.Body)
+
+	createdNote, err := s.repo.CreateNote(ctx, note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create note: %v", err)
+	}
+
+	s.goals.EmitNoteEvent(ctx, models.EventNoteAdded, goal, nil, createdNote, actor)
+	return createdNote, nil
+}
+
+// GetNote retrieves a note by its ID.
+func (s *NoteService) GetNote(ctx context.Context, id string) (*models.GoalNote, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid note ID: %v", err)
+	}
+	note, err := s.repo.GetNoteByID(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %v", err)
+	}
+	return note, nil
+}
+
+// GetNotes retrieves a page of a goal's notes, newest first. A non-empty step limits the
+// result to the notes on that step.
+func (s *NoteService) GetNotes(ctx context.Context, goalID primitive.ObjectID, step string, page, limit int64) ([]models.GoalNote, int64, error) {
+	return s.repo.GetNotes(ctx, goalID, step, page, limit)
+}
+
+// UpdateNote sanitizes and stores a new body and mood for a note. The step a note is on
+// can't be changed.
+func (s *NoteService) UpdateNote(ctx context.Context, goal *models.Goal, existing *models.GoalNote, note *models.GoalNote, actor primitive.ObjectID) (*models.GoalNote, error) {
+	note.ID = existing.ID
+	note.GoalID = existing.GoalID
+	note.UserID = existing.UserID
+	note.Step = existing.Step
+	note.CreatedAt = existing.CreatedAt
+	note.Body = SanitizeMarkdown(note.Body)
+
+	updatedNote, err := s.repo.UpdateNote(ctx, existing.ID, note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update note: %v", err)
+	}
+
+	s.goals.EmitNoteEvent(ctx, models.EventNoteUpdated, goal, existing, updatedNote, actor)
+	return updatedNote, nil
+}
+
+// DeleteNote removes a note from the goal.
+func (s *NoteService) DeleteNote(ctx context.Context, goal *models.Goal, note *models.GoalNote, actor primitive.ObjectID) error {
+	if err := s.repo.DeleteNote(ctx, note.ID); err != nil {
+		return err
+	}
+
+	s.goals.EmitNoteEvent(ctx, models.EventNoteDeleted, goal, note, note, actor)
+	return nil
+}
+
+// CopyNotes is a CloneHook that copies the notes of the source goal to the clone when
+// the clone options ask for it. Notes on steps the clone doesn't have are skipped.
+func (s *NoteService) CopyNotes(ctx context.Context, source, clone *models.Goal, opts CloneOptions) error {
+	if !opts.CopyNotes {
+		return nil
+	}
+	notes, err := s.repo.GetAllNotes(ctx, source.ID)
+	if err != nil {
+		return err
+	}
+
+	steps := make(map[string]bool)
+	for _, step := range clone.Steps {
+		steps[step] = true
+	}
+	copied := make([]models.GoalNote, 0, len(notes))
+	for _, note := range notes {
+		if note.Step != "" && !steps[note.Step] {
+			continue
+		}
+		note.ID = primitive.NewObjectID()
+		note.GoalID = clone.ID
+		note.UserID = clone.UserID
+		copied = append(copied, note)
+	}
+	return s.repo.CreateNotes(ctx, copied)
+}
+
+// HandleGoalEvent is a GoalEventListener that removes the notes of purged goals.
+func (s *NoteService) HandleGoalEvent(ctx context.Context, event models.GoalEvent) {
+	if event.Type != models.EventGoalPurged {
+		return
+	}
+	if err := s.repo.DeleteNotesByGoal(ctx, event.GoalID); err != nil {
+		log.Printf("Failed to delete notes of purged goal %s: %v", event.GoalID.Hex(), err)
+	}
+}
+
+// ValidateNote checks the body, mood and step of a note against its goal.
+func ValidateNote(goal *models.Goal, note *models.GoalNote) error {
+	if strings.TrimSpace(note.Body) == "" {
+		return fmt.Errorf("note body is required")
+	}
+	if len(note.Body) > MaxNoteLength {
+		return fmt.Errorf("note is longer than %d characters", MaxNoteLength)
+	}
+	if note.Mood != 0 && (note.Mood < 1 || note.Mood > 5) {
+		return fmt.Errorf("mood must be between 1 and 5")
+	}
+	if note.Step != "" {
+		if _, exists := goal.Progress[note.Step]; !exists {
+			return fmt.Errorf("step not found in goal: %s", note.Step)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSanitizeMarkdown tests that HTML and unsafe links are neutralised while code is kept.
+func TestSanitizeMarkdown(t *testing.T) {
+	cases := map[string]string{
+		"**Ran 5k** today":                      "**Ran 5k** today",
+		"<script>alert(1)</script>":             "&lt;script>alert(1)&lt;/script>",
+		"[site](https://example.com)":           "[site](https://example.com)",
+		"[click](javascript:alert(1))":          "[click](#)",
+		"[click](JaVa&#115;cript:alert)":        "[click](#)",
+		"![img](data:text/html;base64,xx)":      "![img](#)",
+		"[ref]: javascript:alert(1) \"title\"":  "[ref]: # \"title\"",
+		"use `<div>` here":                      "use `<div>` here",
+		"a ` b <i>":                             "a ` b &lt;i>",
+		"```\n<b>kept</b>\n```\n<b>escaped</b>": "```\n<b>kept</b>\n```\n&lt;b>escaped&lt;/b>",
+		"line\r\nbreak\x00":                     "line\nbreak",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, SanitizeMarkdown(input), input)
+	}
+}
+
+// TestSanitizeMarkdownBypasses tests that code spans, code fences and link destinations are
+// recognised the way a CommonMark renderer does, so they cannot hide HTML or script links.
+func TestSanitizeMarkdownBypasses(t *testing.T) {
+	cases := map[string]string{
+		"[l](javascript:a((1)))":                 "[l](#)",
+		"[l](javascript:alert`1`)":               "[l](#)",
+		"[l](\njavascript:alert(1))":             "[l](\n#)",
+		"[l](<javascript:alert(1)> \"t\")":       "[l](# \"t\")",
+		"[l](java\\script:alert(1))":             "[l](#)",
+		"[l](https://example.com \"`\") <b>`":    "[l](https://example.com \"`\") &lt;b>`",
+		"` [l](javascript:alert(1)) ``":          "` [l](#) ``",
+		"` <script>alert(1)</script> ``":         "` &lt;script>alert(1)&lt;/script> ``",
+		"``a`<b>`` <i>":                          "``a`<b>`` &lt;i>",
+		"\\` <b>x</b> `":                         "\\` &lt;b>x&lt;/b> `",
+		"[ref]:\njavascript:alert(1)":            "[ref]:\n#",
+		"```\n```x\n<b>kept</b>\n```\n<b>x</b>":  "```\n```x\n<b>kept</b>\n```\n&lt;b>x&lt;/b>",
+		"````\n```\n<b>kept</b>\n````\n<b>x</b>": "````\n```\n<b>kept</b>\n````\n&lt;b>x&lt;/b>",
+		"~~~\n```\n<b>kept</b>\n~~~\n<b>x</b>":   "~~~\n```\n<b>kept</b>\n~~~\n&lt;b>x&lt;/b>",
+		"```a`b\n<b>x</b>":                       "```a`b\n&lt;b>x&lt;/b>",
+		"    ```\n<b>x</b>":                      "    ```\n&lt;b>x&lt;/b>",
+		"- a\n  ```\n  <b>kept</b>\nb <b>x</b>":  "- a\n  ```\n  <b>kept</b>\nb &lt;b>x&lt;/b>",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, SanitizeMarkdown(input), input)
+	}
+}
+
+// TestValidateNote tests body, mood and step validation of notes.
+func TestValidateNote(t *testing.T) {
+	goal := &models.Goal{Progress: map[string]bool{"Week 1": false}}
+
+	assert.NoError(t, ValidateNote(goal, &models.GoalNote{Body: "Felt good", Mood: 4, Step: "Week 1"}))
+	assert.Error(t, ValidateNote(goal, &models.GoalNote{Body: "  "}))
+	assert.Error(t, ValidateNote(goal, &models.GoalNote{Body: "ok", Mood: 6}))
+	assert.Error(t, ValidateNote(goal, &models.GoalNote{Body: "ok", Step: "Week 9"}))
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxNotifications bounds the number of notifications listed at once.
+const MaxNotifications = 100
+
+// NotificationService delivers notifications to users through the channels they chose
+// in their settings. Only in-app delivery exists so far; users who turned it off get
+// nothing stored.
+type NotificationService struct {
+	repo  *repository.NotificationRepository
+	goals *GoalService
+}
+
+// NewNotificationService creates a new instance of NotificationService.
+func NewNotificationService(repo *repository.NotificationRepository, goals *GoalService) *NotificationService {
+	return &NotificationService{
+		repo:  repo,
+		goals: goals,
+	}
+}
+
+// Notify delivers a notification to its user. It returns nil without storing anything
+// when the user doesn't receive in-app notifications.
+func (s *NotificationService) Notify(ctx context.Context, notification *models.Notification) (*models.Notification, error) {
+	inApp := false
+	for _, channel := range s.goals.Settings(ctx, notification.UserID).NotificationChannels {
+		inApp = inApp || channel == models.ChannelInApp
+	}
+	if !inApp {
+		return nil, nil
+	}
+	return s.repo.CreateNotification(ctx, notification)
+}
+
+// GetNotifications lists a user's latest notifications, newest first.
+func (s *NotificationService) GetNotifications(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, limit int64) ([]models.Notification, error) {
+	if limit <= 0 || limit > MaxNotifications {
+		limit = MaxNotifications
+	}
+	return s.repo.GetNotifications(ctx, userID, unreadOnly, limit)
+}
+
+// MarkRead marks notifications of a user as read, all of them when ids is empty.
+func (s *NotificationService) MarkRead(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) (int64, error) {
+	return s.repo.MarkRead(ctx, userID, ids)
+}
@@ -0,0 +1,405 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxOrgNameLength bounds the length of organization names.
+const MaxOrgNameLength = 100
+
+var (
+	// ErrNotOrgMember is returned when a user acts within an organization they don't belong to
+	ErrNotOrgMember = errors.New("not a member of the organization")
+	// ErrAlreadyMember is returned when an email address is already invited to an organization
+	ErrAlreadyMember = errors.New("user is already a member of the organization")
+	// ErrLastAdmin is returned when a change would leave an organization without an admin
+	ErrLastAdmin = errors.New("organization needs at least one admin")
+	// ErrMemberNotFound is returned when a member or invitation doesn't exist or isn't addressed to the user
+	ErrMemberNotFound = errors.New("member not found")
+)
+
+// OrgService encapsulates the business logic for organizations. Members reach the goals
+// of their organizations through the goal service's access policy: admins as owners and
+// members as editors. Personal goals never belong to an organization.
+type OrgService struct {
+	repo          *repository.OrgRepository
+	goals         *GoalService
+	users         *UserService
+	notifications *NotificationService
+}
+
+// NewOrgService creates a new instance of OrgService.
+func NewOrgService(repo *repository.OrgRepository, goals *GoalService, users *UserService, notifications *NotificationService) *OrgService {
+	return &OrgService{
+		repo:          repo,
+		goals:         goals,
+		users:         users,
+		notifications: notifications,
+	}
+}
+
+// ValidateOrgName checks the name of an organization and returns it trimmed.
+func ValidateOrgName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("organization name is required")
+	}
+	if len([]rune(name)) > MaxOrgNameLength {
+		return "", fmt.Errorf("organization name must be at most %d characters", MaxOrgNameLength)
+	}
+	return name, nil
+}
+
+// ValidateOrgRole checks that a member can have a role.
+func ValidateOrgRole(role string) error {
+	if !models.OrgRoles[role] {
+		return fmt.Errorf("role must be admin or member")
+	}
+	return nil
+}
+
+// CreateOrg creates an organization with the user as its first admin.
+func (s *OrgService) CreateOrg(ctx context.Context, userID primitive.ObjectID, name string) (*models.Organization, error) {
+	user, err := s.users.GetUser(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.repo.CreateOrg(ctx, &models.Organization{Name: name, CreatedBy: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = s.repo.CreateMember(ctx, &models.OrgMember{
+		OrgID:       org.ID,
+		Email:       strings.ToLower(user.Email),
+		UserID:      userID,
+		Role:        models.OrgRoleAdmin,
+		Status:      models.ShareAccepted,
+		RespondedAt: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// GetOrg fetches an organization by its ID.
+func (s *OrgService) GetOrg(ctx context.Context, id string) (*models.Organization, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid organization ID: %v", err)
+	}
+	return s.repo.GetOrgByID(ctx, objID)
+}
+
+// GetUserOrgs lists the organizations a user belongs to, with the user's role in each.
+func (s *OrgService) GetUserOrgs(ctx context.Context, userID primitive.ObjectID) ([]models.UserOrg, error) {
+	memberships, err := s.repo.GetUserMemberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	userOrgs := []models.UserOrg{}
+	if len(memberships) == 0 {
+		return userOrgs, nil
+	}
+
+	roles := make(map[primitive.ObjectID]string, len(memberships))
+	ids := make([]primitive.ObjectID, 0, len(memberships))
+	for _, membership := range memberships {
+		roles[membership.OrgID] = membership.Role
+		ids = append(ids, membership.OrgID)
+	}
+	orgs, err := s.repo.GetOrgsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range orgs {
+		userOrgs = append(userOrgs, models.UserOrg{Organization: org, Role: roles[org.ID]})
+	}
+	return userOrgs, nil
+}
+
+// RenameOrg changes the name of an organization.
+func (s *OrgService) RenameOrg(ctx context.Context, org *models.Organization, name string) (*models.Organization, error) {
+	return s.repo.RenameOrg(ctx, org.ID, name)
+}
+
+// DeleteOrg removes an organization. Its goals become personal goals of their creators.
+func (s *OrgService) DeleteOrg(ctx context.Context, org *models.Organization) error {
+	if err := s.goals.ReleaseOrgGoals(ctx, org.ID); err != nil {
+		return err
+	}
+	return s.repo.DeleteOrg(ctx, org.ID)
+}
+
+// Role returns the role of a user in an organization, or "" if they aren't a member.
+// It is the GoalService's OrgLookup.
+func (s *OrgService) Role(ctx context.Context, orgID, userID primitive.ObjectID) (string, error) {
+	membership, err := s.repo.GetMembership(ctx, orgID, userID)
+	if err != nil || membership == nil {
+		return "", err
+	}
+	return membership.Role, nil
+}
+
+// Audience is an AudienceLookup listing the members of the organization a team goal
+// belongs to.
+func (s *OrgService) Audience(ctx context.Context, goal *models.Goal) ([]primitive.ObjectID, error) {
+	if goal.OrgID == nil {
+		return nil, nil
+	}
+	members, err := s.repo.GetMembers(ctx, *goal.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	users := []primitive.ObjectID{}
+	for _, member := range members {
+		if member.Status == models.ShareAccepted {
+			users = append(users, member.UserID)
+		}
+	}
+	return users, nil
+}
+
+// Invite invites the user of an email address, who may not have an account yet, to an
+// organization. A declined invitation can be sent again.
+func (s *OrgService) Invite(ctx context.Context, org *models.Organization, inviterID primitive.ObjectID, email, role string) (*models.OrgMember, error) {
+	member, err := s.repo.GetMemberByEmail(ctx, org.ID, email)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil && member.Status != models.ShareDeclined {
+		return nil, ErrAlreadyMember
+	}
+
+	invitee := s.users.FindUserByEmail(ctx, email)
+	if member == nil {
+		member = &models.OrgMember{OrgID: org.ID, Email: email}
+	}
+	member.Role = role
+	member.Status = models.SharePending
+	member.InvitedBy = inviterID
+	member.RespondedAt = nil
+	if invitee != nil {
+		member.UserID = invitee.ID
+	}
+
+	if member.ID.IsZero() {
+		member, err = s.repo.CreateMember(ctx, member)
+	} else {
+		member, err = s.repo.UpdateMember(ctx, member)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if invitee != nil {
+		s.notify(ctx, &models.Notification{
+			UserID: invitee.ID,
+			Type:   models.NotificationOrgInvitation,
+			Title:  fmt.Sprintf("You were invited to join %s", org.Name),
+			Data:   map[string]string{"member_id": member.ID.Hex(), "org_id": org.ID.Hex(), "role": role},
+		})
+	}
+	return member, nil
+}
+
+// GetMembers lists the members and invitations of an organization.
+func (s *OrgService) GetMembers(ctx context.Context, org *models.Organization) ([]models.OrgMember, error) {
+	return s.repo.GetMembers(ctx, org.ID)
+}
+
+// GetMember fetches a member or invitation of an organization.
+func (s *OrgService) GetMember(ctx context.Context, org *models.Organization, id string) (*models.OrgMember, error) {
+	member, err := s.getMember(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if member.OrgID != org.ID {
+		return nil, ErrMemberNotFound
+	}
+	return member, nil
+}
+
+// UpdateMemberRole changes the role of a member, keeping at least one admin.
+func (s *OrgService) UpdateMemberRole(ctx context.Context, member *models.OrgMember, role string) (*models.OrgMember, error) {
+	if member.Role == models.OrgRoleAdmin && role != models.OrgRoleAdmin {
+		if err := s.checkOtherAdmins(ctx, member); err != nil {
+			return nil, err
+		}
+	}
+	member.Role = role
+	return s.repo.UpdateMember(ctx, member)
+}
+
+// RemoveMember removes a member, who loses access to the organization's goals and their
+// step assignments on them, or withdraws an invitation; actor is the user removing them.
+// The last admin can't be removed.
+func (s *OrgService) RemoveMember(ctx context.Context, org *models.Organization, member *models.OrgMember, actor primitive.ObjectID) error {
+	if member.Role == models.OrgRoleAdmin {
+		if err := s.checkOtherAdmins(ctx, member); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.DeleteMember(ctx, member.ID); err != nil {
+		return err
+	}
+	if member.Status != models.ShareAccepted {
+		return nil
+	}
+
+	goals, err := s.goals.GetGoals(ctx, repository.GoalFilter{OrgID: &org.ID, Archived: repository.ArchivedAll})
+	if err != nil {
+		log.Printf("Failed to unassign user %s from organization %s: %v", member.UserID.Hex(), org.ID.Hex(), err)
+		return nil
+	}
+	for i := range goals {
+		if err := s.goals.UnassignUser(ctx, &goals[i], member.UserID, actor); err != nil {
+			log.Printf("Failed to unassign user %s from goal %s: %v", member.UserID.Hex(), goals[i].ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// GetInvitations lists the pending invitations of a user, including those sent to their
+// email address before they had an account.
+func (s *OrgService) GetInvitations(ctx context.Context, userID primitive.ObjectID) ([]models.OrgMember, error) {
+	user, err := s.users.GetUser(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPendingInvitations(ctx, user.ID, strings.ToLower(user.Email))
+}
+
+// Respond accepts or declines an invitation addressed to a user.
+func (s *OrgService) Respond(ctx context.Context, id string, userID primitive.ObjectID, accept bool) (*models.OrgMember, error) {
+	member, err := s.getMember(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetUser(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	addressed := member.UserID == user.ID || strings.EqualFold(member.Email, user.Email)
+	if !addressed || member.Status != models.SharePending {
+		return nil, ErrMemberNotFound
+	}
+
+	now := time.Now()
+	member.UserID = user.ID
+	member.RespondedAt = &now
+	member.Status = models.ShareDeclined
+	if accept {
+		member.Status = models.ShareAccepted
+	}
+	return s.repo.UpdateMember(ctx, member)
+}
+
+// GetContributions sums up, for every member of an organization, the goals they created
+// and completed and the steps they checked on the organization's goals.
+func (s *OrgService) GetContributions(ctx context.Context, org *models.Organization) ([]models.MemberContribution, error) {
+	members, err := s.repo.GetMembers(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+	goalCounts, stepCounts, err := s.goals.CountOrgContributions(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[primitive.ObjectID]string, len(members))
+	for _, member := range members {
+		if member.Status != models.ShareAccepted {
+			continue
+		}
+		if user, err := s.users.GetUser(ctx, member.UserID.Hex()); err == nil {
+			names[member.UserID] = user.Username
+		}
+	}
+	return BuildContributions(members, goalCounts, stepCounts, names), nil
+}
+
+// BuildContributions combines the counts of the accepted members of an organization.
+// Members who left are left out, even if they contributed.
+func BuildContributions(members []models.OrgMember, goalCounts []repository.GoalCounts, stepCounts []repository.StepCount, names map[primitive.ObjectID]string) []models.MemberContribution {
+	goals := make(map[primitive.ObjectID]repository.GoalCounts, len(goalCounts))
+	for _, count := range goalCounts {
+		goals[count.UserID] = count
+	}
+	steps := make(map[primitive.ObjectID]int, len(stepCounts))
+	for _, count := range stepCounts {
+		steps[count.UserID] = count.Count
+	}
+
+	contributions := []models.MemberContribution{}
+	for _, member := range members {
+		if member.Status != models.ShareAccepted {
+			continue
+		}
+		contributions = append(contributions, models.MemberContribution{
+			UserID:         member.UserID,
+			Username:       names[member.UserID],
+			Role:           member.Role,
+			GoalsCreated:   goals[member.UserID].Created,
+			GoalsCompleted: goals[member.UserID].Completed,
+			StepsCompleted: steps[member.UserID],
+		})
+	}
+	return contributions
+}
+
+// GoalRoleForOrgRole returns the role members with an organization role have on the
+// organization's goals.
+func GoalRoleForOrgRole(role string) string {
+	switch role {
+	case models.OrgRoleAdmin:
+		return models.RoleOwner
+	case models.OrgRoleMember:
+		return models.RoleEditor
+	default:
+		return ""
+	}
+}
+
+func (s *OrgService) checkOtherAdmins(ctx context.Context, member *models.OrgMember) error {
+	if member.Status != models.ShareAccepted {
+		return nil
+	}
+	admins, err := s.repo.CountAdmins(ctx, member.OrgID)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func (s *OrgService) getMember(ctx context.Context, id string) (*models.OrgMember, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrMemberNotFound
+	}
+	member, err := s.repo.GetMemberByID(ctx, objID)
+	if err != nil {
+		return nil, ErrMemberNotFound
+	}
+	return member, nil
+}
+
+func (s *OrgService) notify(ctx context.Context, notification *models.Notification) {
+	if _, err := s.notifications.Notify(ctx, notification); err != nil {
+		log.Printf("Failed to notify user %s: %v", notification.UserID.Hex(), err)
+	}
+}
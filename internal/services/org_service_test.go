@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestValidateOrgName tests that names are trimmed, required and bounded.
+func TestValidateOrgName(t *testing.T) {
+	name, err := ValidateOrgName("  Platform team ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Platform team", name)
+
+	_, err = ValidateOrgName("   ")
+	assert.Error(t, err)
+	_, err = ValidateOrgName(strings.Repeat("a", MaxOrgNameLength+1))
+	assert.Error(t, err)
+
+	assert.NoError(t, ValidateOrgRole(models.OrgRoleAdmin))
+	assert.NoError(t, ValidateOrgRole(models.OrgRoleMember))
+	assert.Error(t, ValidateOrgRole(models.RoleOwner))
+}
+
+// TestGoalRoleForOrgRole tests the access organization roles grant to team goals.
+func TestGoalRoleForOrgRole(t *testing.T) {
+	assert.Equal(t, models.RoleOwner, GoalRoleForOrgRole(models.OrgRoleAdmin))
+	assert.Equal(t, models.RoleEditor, GoalRoleForOrgRole(models.OrgRoleMember))
+	assert.Equal(t, "", GoalRoleForOrgRole(""))
+}
+
+// TestBuildContributions tests that counts are combined per accepted member.
+func TestBuildContributions(t *testing.T) {
+	admin, member, invited, former := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	members := []models.OrgMember{
+		{UserID: admin, Role: models.OrgRoleAdmin, Status: models.ShareAccepted},
+		{UserID: member, Role: models.OrgRoleMember, Status: models.ShareAccepted},
+		{UserID: invited, Role: models.OrgRoleMember, Status: models.SharePending},
+	}
+	goalCounts := []repository.GoalCounts{
+		{UserID: admin, Created: 3, Completed: 1},
+		{UserID: former, Created: 2},
+	}
+	stepCounts := []repository.StepCount{
+		{UserID: admin, Count: 4},
+		{UserID: member, Count: 7},
+	}
+
+	contributions := BuildContributions(members, goalCounts, stepCounts, map[primitive.ObjectID]string{admin: "ada"})
+	assert.Equal(t, []models.MemberContribution{
+		{UserID: admin, Username: "ada", Role: models.OrgRoleAdmin, GoalsCreated: 3, GoalsCompleted: 1, StepsCompleted: 4},
+		{UserID: member, Role: models.OrgRoleMember, StepsCompleted: 7},
+	}, contributions)
+}
+
+// TestActiveOrg tests that the organization in the token only applies while the user
+// belongs to it.
+func TestActiveOrg(t *testing.T) {
+	ctx := context.Background()
+	userID, orgID, otherOrg := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	service := &GoalService{}
+	service.SetOrgLookup(func(_ context.Context, org, user primitive.ObjectID) (string, error) {
+		if org == orgID && user == userID {
+			return models.OrgRoleMember, nil
+		}
+		return "", nil
+	})
+
+	active, err := service.ActiveOrg(ctx, userID, "")
+	assert.NoError(t, err)
+	assert.Nil(t, active)
+
+	active, err = service.ActiveOrg(ctx, userID, orgID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, orgID, *active)
+
+	_, err = service.ActiveOrg(ctx, userID, otherOrg.Hex())
+	assert.ErrorIs(t, err, ErrNotOrgMember)
+	_, err = service.ActiveOrg(ctx, userID, "not-an-id")
+	assert.ErrorIs(t, err, ErrNotOrgMember)
+}
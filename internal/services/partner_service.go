@@ -0,0 +1,449 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// NudgeCooldown is how long a user waits before nudging the same partner again
+	NudgeCooldown = 24 * time.Hour
+	// MaxNudgeMessageLength bounds the message sent with a nudge
+	MaxNudgeMessageLength = 280
+	// PartnerSummaryPeriod is the period partner summaries cover and how often they are sent
+	PartnerSummaryPeriod = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrPartnerWithSelf is returned when a user asks themselves to be their partner
+	ErrPartnerWithSelf = errors.New("you can't be your own partner")
+	// ErrAlreadyPartners is returned when two users are already partners or one asked the other
+	ErrAlreadyPartners = errors.New("a partner request already exists")
+	// ErrPartnerNotFound is returned when a user or a request isn't linked with the user
+	ErrPartnerNotFound = errors.New("partner not found")
+	// ErrPartnerGoalNotFound is returned when a nudge is about a goal the partner doesn't show
+	ErrPartnerGoalNotFound = errors.New("goal not found")
+	// ErrNudgeTooSoon is returned when a user nudges the same partner within NudgeCooldown
+	ErrNudgeTooSoon = errors.New("partner was nudged recently")
+)
+
+// PartnerService encapsulates the business logic for accountability partners. Partners
+// can view each other's personal goals, except those hidden from partners, through the
+// goal service's policy.
+type PartnerService struct {
+	repo          *repository.PartnerRepository
+	goals         *GoalService
+	users         *UserService
+	notifications *NotificationService
+}
+
+// NewPartnerService creates a new instance of PartnerService.
+func NewPartnerService(repo *repository.PartnerRepository, goals *GoalService, users *UserService, notifications *NotificationService) *PartnerService {
+	return &PartnerService{
+		repo:          repo,
+		goals:         goals,
+		users:         users,
+		notifications: notifications,
+	}
+}
+
+// ValidateNudgeMessage checks the optional message sent with a nudge.
+func ValidateNudgeMessage(message string) error {
+	if len([]rune(message)) > MaxNudgeMessageLength {
+		return fmt.Errorf("message must be at most %d characters", MaxNudgeMessageLength)
+	}
+	return nil
+}
+
+// Request asks the user of an email address to become the partner of a user. Unlike goal
+// invitations, the partner must already have an account. A declined request can be sent
+// again, by either user.
+func (s *PartnerService) Request(ctx context.Context, requesterID primitive.ObjectID, email string) (*models.PartnerLink, error) {
+	partner := s.users.FindUserByEmail(ctx, email)
+	if partner == nil {
+		return nil, ErrPartnerNotFound
+	}
+	if partner.ID == requesterID {
+		return nil, ErrPartnerWithSelf
+	}
+	requester, err := s.users.GetUser(ctx, requesterID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := s.repo.GetLinkBetween(ctx, requesterID, partner.ID)
+	if err != nil {
+		return nil, err
+	}
+	if link != nil && link.Status != models.ShareDeclined {
+		return nil, ErrAlreadyPartners
+	}
+
+	if link == nil {
+		link, err = s.repo.CreateLink(ctx, &models.PartnerLink{
+			RequesterID: requesterID,
+			PartnerID:   partner.ID,
+			Status:      models.SharePending,
+		})
+	} else {
+		link.RequesterID = requesterID
+		link.PartnerID = partner.ID
+		link.Status = models.SharePending
+		link.CreatedAt = time.Now()
+		link.RespondedAt = nil
+		link, err = s.repo.UpdateLink(ctx, link)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, &models.Notification{
+		UserID: partner.ID,
+		Type:   models.NotificationPartnerRequest,
+		Title:  fmt.Sprintf("%s wants you as an accountability partner", requester.Username),
+		Data:   map[string]string{"link_id": link.ID.Hex(), "user_id": requester.ID.Hex()},
+	})
+	return link, nil
+}
+
+// GetRequests lists the pending requests other users sent to a user.
+func (s *PartnerService) GetRequests(ctx context.Context, userID primitive.ObjectID) ([]models.PartnerLink, error) {
+	return s.repo.GetIncomingRequests(ctx, userID)
+}
+
+// Respond accepts or declines a request sent to a user. The requester is told when it
+// is accepted, and the first weekly summary follows a week later.
+func (s *PartnerService) Respond(ctx context.Context, id string, userID primitive.ObjectID, accept bool) (*models.PartnerLink, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrPartnerNotFound
+	}
+	link, err := s.repo.GetLinkByID(ctx, objID)
+	if err != nil || link.PartnerID != userID || link.Status != models.SharePending {
+		return nil, ErrPartnerNotFound
+	}
+	user, err := s.users.GetUser(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	link.RespondedAt = &now
+	link.Status = models.ShareDeclined
+	if accept {
+		link.Status = models.ShareAccepted
+		link.SummarizedAt = &now
+	}
+	link, err = s.repo.UpdateLink(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	if accept {
+		s.notify(ctx, &models.Notification{
+			UserID: link.RequesterID,
+			Type:   models.NotificationPartnerAccepted,
+			Title:  fmt.Sprintf("%s is now your accountability partner", user.Username),
+			Data:   map[string]string{"link_id": link.ID.Hex(), "user_id": user.ID.Hex()},
+		})
+	}
+	return link, nil
+}
+
+// GetPartners lists the users a user is partnered with.
+func (s *PartnerService) GetPartners(ctx context.Context, userID primitive.ObjectID) ([]models.Partner, error) {
+	links, err := s.repo.GetLinks(ctx, userID, models.ShareAccepted)
+	if err != nil {
+		return nil, err
+	}
+
+	partners := []models.Partner{}
+	for _, link := range links {
+		partner := models.Partner{LinkID: link.ID, UserID: link.Other(userID), Since: link.RespondedAt}
+		if user, err := s.users.GetUser(ctx, partner.UserID.Hex()); err == nil {
+			partner.Username = user.Username
+		}
+		partners = append(partners, partner)
+	}
+	return partners, nil
+}
+
+// RemovePartner ends a partnership, or withdraws a pending request either user sent.
+func (s *PartnerService) RemovePartner(ctx context.Context, userID primitive.ObjectID, partnerID string) error {
+	objID, err := primitive.ObjectIDFromHex(partnerID)
+	if err != nil {
+		return ErrPartnerNotFound
+	}
+	link, err := s.repo.GetLinkBetween(ctx, userID, objID)
+	if err != nil {
+		return err
+	}
+	if link == nil || link.Status == models.ShareDeclined {
+		return ErrPartnerNotFound
+	}
+	return s.repo.DeleteLink(ctx, link.ID)
+}
+
+// GetPartnerGoals lists the unarchived personal goals of a partner that aren't hidden
+// from partners.
+func (s *PartnerService) GetPartnerGoals(ctx context.Context, userID primitive.ObjectID, partnerID string) ([]models.Goal, error) {
+	link, err := s.partnerLink(ctx, userID, partnerID)
+	if err != nil {
+		return nil, err
+	}
+	goals, hidden, err := s.visibleGoals(ctx, link.Other(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	visible := []models.Goal{}
+	for _, goal := range goals {
+		if !hidden[goal.ID] {
+			visible = append(visible, goal)
+		}
+	}
+	return visible, nil
+}
+
+// GetSummary sums up what a partner did over the last PartnerSummaryPeriod.
+func (s *PartnerService) GetSummary(ctx context.Context, userID primitive.ObjectID, partnerID string) (*models.PartnerSummary, error) {
+	link, err := s.partnerLink(ctx, userID, partnerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.summarize(ctx, link.Other(userID), time.Now())
+}
+
+// Nudge sends a partner a notification reminding them of their goals, or of one of their
+// visible goals. A user can nudge each partner once per NudgeCooldown.
+func (s *PartnerService) Nudge(ctx context.Context, userID primitive.ObjectID, partnerID, goalID, message string) error {
+	link, err := s.partnerLink(ctx, userID, partnerID)
+	if err != nil {
+		return err
+	}
+	user, err := s.users.GetUser(ctx, userID.Hex())
+	if err != nil {
+		return err
+	}
+
+	data := map[string]string{"user_id": user.ID.Hex()}
+	title := fmt.Sprintf("%s nudged you to keep going", user.Username)
+	if goalID != "" {
+		goal, err := s.goals.GetGoal(ctx, goalID)
+		if err != nil {
+			return ErrPartnerGoalNotFound
+		}
+		if role, err := s.AccessRole(ctx, goal, userID); err != nil || role == "" {
+			return ErrPartnerGoalNotFound
+		}
+		data["goal_id"] = goal.ID.Hex()
+		title = fmt.Sprintf("%s nudged you about %q", user.Username, goal.Name)
+	}
+
+	recorded, err := s.repo.MarkNudged(ctx, link.ID, userID, time.Now().Add(-NudgeCooldown))
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return ErrNudgeTooSoon
+	}
+
+	s.notify(ctx, &models.Notification{
+		UserID: link.Other(userID),
+		Type:   models.NotificationPartnerNudge,
+		Title:  title,
+		Body:   strings.TrimSpace(message),
+		Data:   data,
+	})
+	return nil
+}
+
+// SetHidden hides a goal from the owner's partners, or shows it to them again.
+func (s *PartnerService) SetHidden(ctx context.Context, goal *models.Goal, hidden bool) error {
+	return s.repo.SetHidden(ctx, goal.UserID, goal.ID, hidden)
+}
+
+// SendWeeklySummaries sends both partners of every link a summary of what the other did
+// during the last PartnerSummaryPeriod. Each link is claimed before its summaries are
+// sent, so concurrent runs don't send them twice.
+func (s *PartnerService) SendWeeklySummaries(ctx context.Context) (int, error) {
+	now := time.Now()
+	links, err := s.repo.GetLinksToSummarize(ctx, now.Add(-PartnerSummaryPeriod))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch partners to summarize: %v", err)
+	}
+
+	sent := 0
+	for _, link := range links {
+		claimed, err := s.repo.MarkSummarized(ctx, link.ID, now.Add(-PartnerSummaryPeriod))
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+		for _, pair := range [][2]primitive.ObjectID{{link.RequesterID, link.PartnerID}, {link.PartnerID, link.RequesterID}} {
+			summary, err := s.summarize(ctx, pair[1], now)
+			if err != nil {
+				log.Printf("Failed to summarize partner %s: %v", pair[1].Hex(), err)
+				continue
+			}
+			s.notify(ctx, &models.Notification{
+				UserID: pair[0],
+				Type:   models.NotificationPartnerSummary,
+				Title:  fmt.Sprintf("Your weekly summary of %s", summary.Username),
+				Body: fmt.Sprintf("%s completed %d steps and %d goals this week, with %d goals in progress.",
+					summary.Username, summary.StepsCompleted, summary.GoalsCompleted, summary.ActiveGoals),
+				Data: map[string]string{"user_id": pair[1].Hex()},
+			})
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// RunWeeklySummaries sends the partner summaries that are due every interval until ctx
+// is cancelled.
+func (s *PartnerService) RunWeeklySummaries(ctx context.Context, interval time.Duration) {
+	runEvery(ctx, interval, "Partner summaries", s.SendWeeklySummaries)
+}
+
+// BuildPartnerSummary sums up the steps and goals completed between from and to on the
+// goals that aren't hidden. Completed goals are listed only if they were completed
+// during the period.
+func BuildPartnerSummary(goals []models.Goal, hidden map[primitive.ObjectID]bool, from, to time.Time) models.PartnerSummary {
+	within := func(t *time.Time) bool {
+		return t != nil && !t.Before(from) && t.Before(to)
+	}
+
+	summary := models.PartnerSummary{From: from, To: to, Goals: []models.PartnerGoalProgress{}}
+	for _, goal := range goals {
+		if hidden[goal.ID] || goal.OrgID != nil {
+			continue
+		}
+
+		progress := models.PartnerGoalProgress{GoalID: goal.ID, Name: goal.Name, Status: goal.Status, Steps: len(goal.Steps)}
+		for _, step := range goal.Steps {
+			if !goal.Progress[step] {
+				continue
+			}
+			progress.CompletedSteps++
+			if within(goal.StepMeta[step].CompletedAt) {
+				progress.StepsInPeriod++
+			}
+		}
+		summary.StepsCompleted += progress.StepsInPeriod
+
+		if goal.Status == "completed" {
+			if !within(goal.CompletedAt) {
+				continue
+			}
+			summary.GoalsCompleted++
+		} else {
+			summary.ActiveGoals++
+		}
+		summary.Goals = append(summary.Goals, progress)
+	}
+	return summary
+}
+
+// Audience is an AudienceLookup listing the partners of the owner of a personal goal.
+func (s *PartnerService) Audience(ctx context.Context, goal *models.Goal) ([]primitive.ObjectID, error) {
+	if goal.OrgID != nil {
+		return nil, nil
+	}
+	links, err := s.repo.GetLinks(ctx, goal.UserID, models.ShareAccepted)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]primitive.ObjectID, 0, len(links))
+	for _, link := range links {
+		users = append(users, link.Other(goal.UserID))
+	}
+	return users, nil
+}
+
+// AccessRole is an AccessLookup letting partners view each other's personal goals,
+// unless the owner hid them.
+func (s *PartnerService) AccessRole(ctx context.Context, goal *models.Goal, userID primitive.ObjectID) (string, error) {
+	if goal.OrgID != nil {
+		return "", nil
+	}
+	link, err := s.repo.GetLinkBetween(ctx, goal.UserID, userID)
+	if err != nil || link == nil || link.Status != models.ShareAccepted {
+		return "", err
+	}
+	hidden, err := s.repo.IsHidden(ctx, goal.ID)
+	if err != nil || hidden {
+		return "", err
+	}
+	return models.RoleViewer, nil
+}
+
+// HandleGoalEvent is a GoalEventListener that forgets the visibility of purged goals.
+func (s *PartnerService) HandleGoalEvent(ctx context.Context, event models.GoalEvent) {
+	if event.Type != models.EventGoalPurged {
+		return
+	}
+	if err := s.repo.DeleteHiddenGoal(ctx, event.GoalID); err != nil {
+		log.Printf("Failed to delete visibility of goal %s: %v", event.GoalID.Hex(), err)
+	}
+}
+
+// partnerLink fetches the accepted link between a user and a partner.
+func (s *PartnerService) partnerLink(ctx context.Context, userID primitive.ObjectID, partnerID string) (*models.PartnerLink, error) {
+	objID, err := primitive.ObjectIDFromHex(partnerID)
+	if err != nil {
+		return nil, ErrPartnerNotFound
+	}
+	link, err := s.repo.GetLinkBetween(ctx, userID, objID)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || link.Status != models.ShareAccepted {
+		return nil, ErrPartnerNotFound
+	}
+	return link, nil
+}
+
+// visibleGoals fetches the personal goals of a user along with those they hid.
+func (s *PartnerService) visibleGoals(ctx context.Context, userID primitive.ObjectID) ([]models.Goal, map[primitive.ObjectID]bool, error) {
+	goals, err := s.goals.GetGoals(ctx, repository.GoalFilter{UserID: userID})
+	if err != nil {
+		return nil, nil, err
+	}
+	hidden, err := s.repo.GetHiddenGoals(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return goals, hidden, nil
+}
+
+func (s *PartnerService) summarize(ctx context.Context, userID primitive.ObjectID, now time.Time) (*models.PartnerSummary, error) {
+	user, err := s.users.GetUser(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	goals, hidden, err := s.visibleGoals(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	summary := BuildPartnerSummary(goals, hidden, now.Add(-PartnerSummaryPeriod), now)
+	summary.UserID = user.ID
+	summary.Username = user.Username
+	return &summary, nil
+}
+
+func (s *PartnerService) notify(ctx context.Context, notification *models.Notification) {
+	if _, err := s.notifications.Notify(ctx, notification); err != nil {
+		log.Printf("Failed to notify user %s: %v", notification.UserID.Hex(), err)
+	}
+}
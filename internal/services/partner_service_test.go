@@ -0,0 +1,79 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestValidateNudgeMessage tests that nudge messages are optional and bounded.
+func TestValidateNudgeMessage(t *testing.T) {
+	assert.NoError(t, ValidateNudgeMessage(""))
+	assert.NoError(t, ValidateNudgeMessage(strings.Repeat("é", MaxNudgeMessageLength)))
+	assert.Error(t, ValidateNudgeMessage(strings.Repeat("a", MaxNudgeMessageLength+1)))
+}
+
+// TestPartnerPair tests that two users are paired the same way whoever asked.
+func TestPartnerPair(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	assert.Equal(t, repository.PartnerPair(a, b), repository.PartnerPair(b, a))
+	assert.NotEqual(t, repository.PartnerPair(a, b), repository.PartnerPair(a, primitive.NewObjectID()))
+
+	link := models.PartnerLink{RequesterID: a, PartnerID: b}
+	assert.Equal(t, b, link.Other(a))
+	assert.Equal(t, a, link.Other(b))
+}
+
+// TestBuildPartnerSummary tests that only the visible activity of the period is counted.
+func TestBuildPartnerSummary(t *testing.T) {
+	to := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -7)
+	inPeriod, before := to.AddDate(0, 0, -2), from.AddDate(0, 0, -1)
+	orgID := primitive.NewObjectID()
+
+	active := models.Goal{
+		ID:       primitive.NewObjectID(),
+		Name:     "Run a marathon",
+		Status:   "in-progress",
+		Steps:    []string{"5k", "10k", "21k"},
+		Progress: map[string]bool{"5k": true, "10k": true},
+		StepMeta: map[string]models.StepMeta{"5k": {CompletedAt: &before}, "10k": {CompletedAt: &inPeriod}},
+	}
+	completed := models.Goal{
+		ID:          primitive.NewObjectID(),
+		Name:        "Read a book",
+		Status:      "completed",
+		Steps:       []string{"read"},
+		Progress:    map[string]bool{"read": true},
+		StepMeta:    map[string]models.StepMeta{"read": {CompletedAt: &inPeriod}},
+		CompletedAt: &inPeriod,
+	}
+	oldCompleted := models.Goal{ID: primitive.NewObjectID(), Status: "completed", CompletedAt: &before}
+	hidden := models.Goal{
+		ID:       primitive.NewObjectID(),
+		Steps:    []string{"secret"},
+		Progress: map[string]bool{"secret": true},
+		StepMeta: map[string]models.StepMeta{"secret": {CompletedAt: &inPeriod}},
+	}
+	team := models.Goal{ID: primitive.NewObjectID(), OrgID: &orgID, Status: "pending"}
+
+	summary := BuildPartnerSummary(
+		[]models.Goal{active, completed, oldCompleted, hidden, team},
+		map[primitive.ObjectID]bool{hidden.ID: true},
+		from, to,
+	)
+	assert.Equal(t, from, summary.From)
+	assert.Equal(t, to, summary.To)
+	assert.Equal(t, 2, summary.StepsCompleted)
+	assert.Equal(t, 1, summary.GoalsCompleted)
+	assert.Equal(t, 1, summary.ActiveGoals)
+	assert.Equal(t, []models.PartnerGoalProgress{
+		{GoalID: active.ID, Name: "Run a marathon", Status: "in-progress", Steps: 3, CompletedSteps: 2, StepsInPeriod: 1},
+		{GoalID: completed.ID, Name: "Read a book", Status: "completed", Steps: 1, CompletedSteps: 1, StepsInPeriod: 1},
+	}, summary.Goals)
+}
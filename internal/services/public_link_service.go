@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MinLinkPasswordLength is the shortest password a public link accepts
+	MinLinkPasswordLength = 6
+	// MaxLinkPasswordLength is the longest password bcrypt can hash, in bytes
+	MaxLinkPasswordLength = 72
+	// linkTokenBytes is how many random bytes make up a link token
+	linkTokenBytes = 32
+)
+
+var (
+	// ErrLinkNotFound is returned when a token or a link ID matches no active link of a goal
+	ErrLinkNotFound = errors.New("link not found")
+	// ErrLinkExpired is returned when a link is viewed after its expiry
+	ErrLinkExpired = errors.New("link has expired")
+	// ErrLinkPassword is returned when a protected link is viewed without its password
+	ErrLinkPassword = errors.New("wrong or missing link password")
+)
+
+// PublicLinkService encapsulates the business logic for public, read-only goal links.
+type PublicLinkService struct {
+	repo  *repository.PublicLinkRepository
+	goals *GoalService
+	users *UserService
+}
+
+// NewPublicLinkService creates a new instance of PublicLinkService.
+func NewPublicLinkService(repo *repository.PublicLinkRepository, goals *GoalService, users *UserService) *PublicLinkService {
+	return &PublicLinkService{
+		repo:  repo,
+		goals: goals,
+		users: users,
+	}
+}
+
+// ValidateLinkOptions checks the optional expiry and password of a new link.
+func ValidateLinkOptions(expiresAt *time.Time, password string, now time.Time) error {
+	if expiresAt != nil && !expiresAt.After(now) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	if password != "" && (len([]rune(password)) < MinLinkPasswordLength || len(password) > MaxLinkPasswordLength) {
+		return fmt.Errorf("password must be %d to %d characters long", MinLinkPasswordLength, MaxLinkPasswordLength)
+	}
+	return nil
+}
+
+// CreateLink creates a public link to a goal with an unguessable token.
+func (s *PublicLinkService) CreateLink(ctx context.Context, goal *models.Goal, createdBy primitive.ObjectID, expiresAt *time.Time, password string) (*models.PublicLink, error) {
+	token, err := generateLinkToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate link token: %v", err)
+	}
+
+	link := &models.PublicLink{
+		GoalID:    goal.ID,
+		CreatedBy: createdBy,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %v", err)
+		}
+		link.PasswordHash = string(hash)
+		link.Protected = true
+	}
+	return s.repo.CreateLink(ctx, link)
+}
+
+// GetLinks lists the public links of a goal, including revoked and expired ones.
+func (s *PublicLinkService) GetLinks(ctx context.Context, goal *models.Goal) ([]models.PublicLink, error) {
+	return s.repo.GetLinksByGoal(ctx, goal.ID)
+}
+
+// RevokeLink stops a link of a goal from working. Revoked links stay listed with their views.
+func (s *PublicLinkService) RevokeLink(ctx context.Context, goal *models.Goal, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrLinkNotFound
+	}
+	link, err := s.repo.GetLinkByID(ctx, objID)
+	if err != nil || link.GoalID != goal.ID {
+		return ErrLinkNotFound
+	}
+	revoked, err := s.repo.RevokeLink(ctx, link.ID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// View returns the public view of the goal a token links to and counts the view.
+// Revoked links and links to goals in the trash are reported as not found.
+func (s *PublicLinkService) View(ctx context.Context, token, password string) (*models.PublicGoal, error) {
+	link, err := s.repo.GetLinkByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || link.RevokedAt != nil {
+		return nil, ErrLinkNotFound
+	}
+	if link.Expired(time.Now()) {
+		return nil, ErrLinkExpired
+	}
+	if link.Protected && bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+		return nil, ErrLinkPassword
+	}
+
+	goal, err := s.goals.GetGoal(ctx, link.GoalID.Hex())
+	if err != nil {
+		return nil, ErrLinkNotFound
+	}
+	owner := ""
+	if user, err := s.users.GetUser(ctx, goal.UserID.Hex()); err == nil {
+		owner = user.Username
+		if user.DisplayName != "" {
+			owner = user.DisplayName
+		}
+	}
+
+	if err := s.repo.RecordView(ctx, link.ID); err != nil {
+		log.Printf("Failed to count view of link %s: %v", link.ID.Hex(), err)
+	}
+	view := BuildPublicGoal(goal, owner)
+	return &view, nil
+}
+
+// BuildPublicGoal returns the view of a goal shown through public links.
+func BuildPublicGoal(goal *models.Goal, owner string) models.PublicGoal {
+	view := models.PublicGoal{
+		Name:        goal.Name,
+		Description: goal.Description,
+		Category:    goal.Category,
+		Tags:        goal.Tags,
+		Status:      goal.Status,
+		Owner:       owner,
+		Steps:       make([]models.PublicStep, 0, len(goal.Steps)),
+		CreatedAt:   goal.CreatedAt,
+		CompletedAt: goal.CompletedAt,
+	}
+	if !goal.DueDate.IsZero() {
+		dueDate := goal.DueDate
+		view.DueDate = &dueDate
+	}
+
+	for _, name := range goal.Steps {
+		step := models.PublicStep{Name: name, Done: goal.Progress[name]}
+		if step.Done {
+			step.CompletedAt = goal.StepMeta[name].CompletedAt
+			view.CompletedSteps++
+		}
+		view.Steps = append(view.Steps, step)
+	}
+	if len(goal.Steps) > 0 {
+		view.Percent = roundTo(float64(view.CompletedSteps)*100/float64(len(goal.Steps)), 1)
+	}
+	return view
+}
+
+// HandleGoalEvent is a GoalEventListener that removes the public links of purged goals.
+func (s *PublicLinkService) HandleGoalEvent(ctx context.Context, event models.GoalEvent) {
+	if event.Type != models.EventGoalPurged {
+		return
+	}
+	if err := s.repo.DeleteLinksByGoal(ctx, event.GoalID); err != nil {
+		log.Printf("Failed to delete public links of goal %s: %v", event.GoalID.Hex(), err)
+	}
+}
+
+func generateLinkToken() (string, error) {
+	buf := make([]byte, linkTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestValidateLinkOptions tests that expiries lie ahead and passwords are bounded.
+func TestValidateLinkOptions(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	future, past := now.Add(time.Hour), now.Add(-time.Hour)
+
+	assert.NoError(t, ValidateLinkOptions(nil, "", now))
+	assert.NoError(t, ValidateLinkOptions(&future, "secret", now))
+	assert.Error(t, ValidateLinkOptions(&past, "", now))
+	assert.Error(t, ValidateLinkOptions(&now, "", now))
+	assert.Error(t, ValidateLinkOptions(nil, "short", now))
+	assert.Error(t, ValidateLinkOptions(nil, strings.Repeat("a", MaxLinkPasswordLength+1), now))
+}
+
+// TestGenerateLinkToken tests that tokens are long, URL-safe and distinct.
+func TestGenerateLinkToken(t *testing.T) {
+	first, err := generateLinkToken()
+	assert.NoError(t, err)
+	second, err := generateLinkToken()
+	assert.NoError(t, err)
+
+	assert.Len(t, first, 43)
+	assert.NotEqual(t, first, second)
+	assert.NotContains(t, first, "/")
+	assert.NotContains(t, first, "+")
+}
+
+// TestPublicLinkExpired tests that a link expires at its expiry time.
+func TestPublicLinkExpired(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	link := models.PublicLink{}
+	assert.False(t, link.Expired(now))
+
+	link.ExpiresAt = &now
+	assert.True(t, link.Expired(now))
+	assert.False(t, link.Expired(now.Add(-time.Second)))
+}
+
+// TestBuildPublicGoal tests that the public view carries progress without any IDs.
+func TestBuildPublicGoal(t *testing.T) {
+	completedAt := time.Date(2026, 4, 2, 9, 0, 0, 0, time.UTC)
+	userID, assignee := primitive.NewObjectID(), primitive.NewObjectID()
+	goal := &models.Goal{
+		ID:       primitive.NewObjectID(),
+		UserID:   userID,
+		Name:     "Learn Go",
+		Status:   "in-progress",
+		Steps:    []string{"Tour", "Project", "Tests"},
+		Progress: map[string]bool{"Tour": true},
+		StepMeta: map[string]models.StepMeta{
+			"Tour":    {CompletedAt: &completedAt, CompletedBy: &userID},
+			"Project": {AssigneeID: &assignee},
+		},
+		DueDate: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	view := BuildPublicGoal(goal, "Ada")
+	assert.Equal(t, "Ada", view.Owner)
+	assert.Equal(t, 1, view.CompletedSteps)
+	assert.Equal(t, 33.3, view.Percent)
+	assert.Equal(t, []models.PublicStep{
+		{Name: "Tour", Done: true, CompletedAt: &completedAt},
+		{Name: "Project"},
+		{Name: "Tests"},
+	}, view.Steps)
+	assert.Equal(t, goal.DueDate, *view.DueDate)
+
+	body, err := json.Marshal(view)
+	assert.NoError(t, err)
+	for _, id := range []primitive.ObjectID{goal.ID, userID, assignee} {
+		assert.NotContains(t, string(body), id.Hex())
+	}
+}
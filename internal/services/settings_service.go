@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Settings limits
+const (
+	MaxReminderLeadHours = 30 * 24
+	MaxAutoArchiveDays   = 365
+)
+
+// SettingsService encapsulates the business logic for user settings. Other services read
+// settings through GetSettings, which always returns a complete set.
+type SettingsService struct {
+	repo     *repository.SettingsRepository
+	defaults models.UserSettings
+}
+
+// NewSettingsService creates a new instance of SettingsService. Users who haven't
+// changed a setting get its value from defaults.
+func NewSettingsService(repo *repository.SettingsRepository, defaults models.UserSettings) *SettingsService {
+	return &SettingsService{
+		repo:     repo,
+		defaults: defaults,
+	}
+}
+
+// Defaults returns the settings of a user who hasn't changed any.
+func (s *SettingsService) Defaults() models.UserSettings {
+	settings := s.defaults
+	settings.NotificationChannels = append([]string(nil), s.defaults.NotificationChannels...)
+	return settings
+}
+
+// GetSettings returns the settings of a user, with defaults for those never changed.
+func (s *SettingsService) GetSettings(ctx context.Context, userID primitive.ObjectID) (*models.UserSettings, error) {
+	settings := s.Defaults()
+	if _, err := s.repo.GetSettings(ctx, userID, &settings); err != nil {
+		return nil, err
+	}
+	settings.UserID = userID
+	return &settings, nil
+}
+
+// UpdateSettings stores the complete settings of a user. They must have been validated
+// with ValidateSettings.
+func (s *SettingsService) UpdateSettings(ctx context.Context, userID primitive.ObjectID, settings *models.UserSettings) (*models.UserSettings, error) {
+	settings.UserID = userID
+	return s.repo.SaveSettings(ctx, settings)
+}
+
+// ValidateSettings checks settings against their allowed values and removes duplicate
+// notification channels. The default category is checked against the user's categories
+// separately.
+func ValidateSettings(settings *models.UserSettings) error {
+	if settings.DefaultSort != "" && settings.DefaultSort != repository.SortPriority {
+		return fmt.Errorf("default_sort must be empty or %q", repository.SortPriority)
+	}
+	switch settings.WeekStart {
+	case models.WeekStartMonday, models.WeekStartSunday, models.WeekStartSaturday:
+	default:
+		return fmt.Errorf("week_start must be monday, sunday or saturday")
+	}
+	if settings.ReminderLeadHours < 0 || settings.ReminderLeadHours > MaxReminderLeadHours {
+		return fmt.Errorf("reminder_lead_hours must be between 0 and %d", MaxReminderLeadHours)
+	}
+	if settings.AutoArchiveDays < 0 || settings.AutoArchiveDays > MaxAutoArchiveDays {
+		return fmt.Errorf("auto_archive_days must be between 0 and %d", MaxAutoArchiveDays)
+	}
+	switch settings.Theme {
+	case models.ThemeSystem, models.ThemeLight, models.ThemeDark:
+	default:
+		return fmt.Errorf("theme must be system, light or dark")
+	}
+
+	channels := []string{}
+	seen := make(map[string]bool)
+	for _, channel := range settings.NotificationChannels {
+		switch channel {
+		case models.ChannelInApp, models.ChannelEmail, models.ChannelPush:
+		default:
+			return fmt.Errorf("unknown notification channel %q", channel)
+		}
+		if !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+	settings.NotificationChannels = channels
+	return nil
+}
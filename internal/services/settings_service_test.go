@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestValidateSettings tests the allowed values of each setting.
+func TestValidateSettings(t *testing.T) {
+	settings := models.DefaultSettings()
+	settings.NotificationChannels = []string{"email", "in_app", "email"}
+	assert.NoError(t, ValidateSettings(&settings))
+	assert.Equal(t, []string{"email", "in_app"}, settings.NotificationChannels)
+
+	invalid := []func(*models.UserSettings){
+		func(s *models.UserSettings) { s.DefaultSort = "name" },
+		func(s *models.UserSettings) { s.WeekStart = "friday" },
+		func(s *models.UserSettings) { s.ReminderLeadHours = -1 },
+		func(s *models.UserSettings) { s.ReminderLeadHours = MaxReminderLeadHours + 1 },
+		func(s *models.UserSettings) { s.AutoArchiveDays = MaxAutoArchiveDays + 1 },
+		func(s *models.UserSettings) { s.Theme = "sepia" },
+		func(s *models.UserSettings) { s.NotificationChannels = []string{"pager"} },
+	}
+	for i, change := range invalid {
+		settings := models.DefaultSettings()
+		change(&settings)
+		assert.Error(t, ValidateSettings(&settings), "case %d", i)
+	}
+}
+
+// TestSettingsDefaults tests that settings fall back to defaults and that the default
+// category is only used while it is valid.
+func TestSettingsDefaults(t *testing.T) {
+	ctx := context.Background()
+	userID := primitive.NewObjectID()
+	goalService := NewGoalService(nil)
+
+	settings := goalService.Settings(ctx, userID)
+	assert.Equal(t, models.DefaultSettings(), settings)
+	assert.Equal(t, time.Monday, settings.FirstWeekday())
+	assert.Equal(t, "", goalService.DefaultCategory(ctx, userID))
+
+	category := "Health"
+	goalService.SetSettingsLookup(func(ctx context.Context, userID primitive.ObjectID) (*models.UserSettings, error) {
+		settings := models.DefaultSettings()
+		settings.DefaultCategory = category
+		settings.WeekStart = models.WeekStartSunday
+		return &settings, nil
+	})
+	assert.Equal(t, "Health", goalService.DefaultCategory(ctx, userID))
+	assert.Equal(t, time.Sunday, goalService.Settings(ctx, userID).FirstWeekday())
+
+	category = "Deleted"
+	assert.Equal(t, "", goalService.DefaultCategory(ctx, userID))
+}
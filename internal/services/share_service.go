@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrAlreadyShared is returned when a goal is already shared with an email address
+	ErrAlreadyShared = errors.New("goal is already shared with this user")
+	// ErrShareWithOwner is returned when the owner of a goal invites themselves
+	ErrShareWithOwner = errors.New("goal can't be shared with its owner")
+	// ErrShareNotFound is returned when a share doesn't exist or isn't addressed to the user
+	ErrShareNotFound = errors.New("share not found")
+)
+
+// ShareService encapsulates the business logic for sharing goals with other users.
+// Access granted by accepted shares is enforced through the goal service's policy.
+type ShareService struct {
+	repo          *repository.ShareRepository
+	goals         *GoalService
+	users         *UserService
+	notifications *NotificationService
+}
+
+// NewShareService creates a new instance of ShareService.
+func NewShareService(repo *repository.ShareRepository, goals *GoalService, users *UserService, notifications *NotificationService) *ShareService {
+	return &ShareService{
+		repo:          repo,
+		goals:         goals,
+		users:         users,
+		notifications: notifications,
+	}
+}
+
+// ValidateShareRole checks that a goal can be shared with a role.
+func ValidateShareRole(role string) error {
+	if !models.ShareRoles[role] {
+		return fmt.Errorf("role must be viewer, editor or owner")
+	}
+	return nil
+}
+
+// NormalizeEmail validates an email address and returns it lowercased.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return "", fmt.Errorf("invalid email")
+	}
+	return strings.ToLower(email), nil
+}
+
+// InviteUser shares a goal with the user of an email address, who may not have an account
+// yet. A declined invitation can be sent again; a pending or accepted one can't.
+func (s *ShareService) InviteUser(ctx context.Context, goal *models.Goal, inviterID primitive.ObjectID, email, role string) (*models.GoalShare, error) {
+	owner, err := s.users.GetUser(ctx, goal.UserID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(owner.Email, email) {
+		return nil, ErrShareWithOwner
+	}
+
+	share, err := s.repo.GetShareByEmail(ctx, goal.ID, email)
+	if err != nil {
+		return nil, err
+	}
+	if share != nil && share.Status != models.ShareDeclined {
+		return nil, ErrAlreadyShared
+	}
+
+	invitee := s.users.FindUserByEmail(ctx, email)
+	if share == nil {
+		share = &models.GoalShare{GoalID: goal.ID, OwnerID: goal.UserID, Email: email}
+	}
+	share.Role = role
+	share.Status = models.SharePending
+	share.InvitedBy = inviterID
+	share.RespondedAt = nil
+	if invitee != nil {
+		share.UserID = invitee.ID
+	}
+
+	if share.ID.IsZero() {
+		share, err = s.repo.CreateShare(ctx, share)
+	} else {
+		share, err = s.repo.UpdateShare(ctx, share)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if invitee != nil {
+		s.notify(ctx, &models.Notification{
+			UserID: invitee.ID,
+			Type:   models.NotificationShareInvitation,
+			Title:  fmt.Sprintf("You were invited to collaborate on %q", goal.Name),
+			Body:   fmt.Sprintf("%s shared a goal with you as %s.", owner.Username, role),
+			Data:   map[string]string{"share_id": share.ID.Hex(), "goal_id": goal.ID.Hex(), "role": role},
+		})
+	}
+	return share, nil
+}
+
+// GetShares lists the shares of a goal, oldest first.
+func (s *ShareService) GetShares(ctx context.Context, goal *models.Goal) ([]models.GoalShare, error) {
+	return s.repo.GetSharesByGoal(ctx, goal.ID)
+}
+
+// GetShare fetches a share of a goal.
+func (s *ShareService) GetShare(ctx context.Context, goal *models.Goal, id string) (*models.GoalShare, error) {
+	share, err := s.getShare(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if share.GoalID != goal.ID {
+		return nil, ErrShareNotFound
+	}
+	return share, nil
+}
+
+// UpdateRole changes the role a share grants. Invitees who can no longer edit the goal
+// lose their step assignments. actor is the user making the change.
+func (s *ShareService) UpdateRole(ctx context.Context, goal *models.Goal, share *models.GoalShare, role string, actor primitive.ObjectID) (*models.GoalShare, error) {
+	share.Role = role
+	share, err := s.repo.UpdateShare(ctx, share)
+	if err != nil {
+		return nil, err
+	}
+	if !share.UserID.IsZero() && !RoleAllows(role, ActionEdit) {
+		s.unassign(ctx, goal, share.UserID, actor)
+	}
+	return share, nil
+}
+
+// RemoveShare revokes a share, or lets the invitee leave the goal. The steps assigned
+// to the invitee are unassigned, as they can no longer work on them.
+func (s *ShareService) RemoveShare(ctx context.Context, goal *models.Goal, share *models.GoalShare, actor primitive.ObjectID) error {
+	if err := s.repo.DeleteShare(ctx, share.ID); err != nil {
+		return err
+	}
+	if !share.UserID.IsZero() {
+		s.unassign(ctx, goal, share.UserID, actor)
+	}
+	return nil
+}
+
+// GetInvitations lists the pending invitations of a user, including those sent to their
+// email address before they had an account.
+func (s *ShareService) GetInvitations(ctx context.Context, userID primitive.ObjectID) ([]models.GoalShare, error) {
+	user, err := s.users.GetUser(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPendingInvitations(ctx, user.ID, strings.ToLower(user.Email))
+}
+
+// Respond accepts or declines an invitation addressed to a user. The user who sent the
+// invitation is told when it is accepted.
+func (s *ShareService) Respond(ctx context.Context, id string, userID primitive.ObjectID, accept bool) (*models.GoalShare, error) {
+	share, err := s.getShare(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetUser(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	addressed := share.UserID == user.ID || strings.EqualFold(share.Email, user.Email)
+	if !addressed || share.Status != models.SharePending {
+		return nil, ErrShareNotFound
+	}
+
+	now := time.Now()
+	share.UserID = user.ID
+	share.RespondedAt = &now
+	share.Status = models.ShareDeclined
+	if accept {
+		share.Status = models.ShareAccepted
+	}
+	share, err = s.repo.UpdateShare(ctx, share)
+	if err != nil {
+		return nil, err
+	}
+
+	if accept {
+		data := map[string]string{"share_id": share.ID.Hex(), "goal_id": share.GoalID.Hex(), "user_id": user.ID.Hex()}
+		s.notify(ctx, &models.Notification{
+			UserID: share.InvitedBy,
+			Type:   models.NotificationShareAccepted,
+			Title:  fmt.Sprintf("%s accepted your invitation", user.Username),
+			Data:   data,
+		})
+	}
+	return share, nil
+}
+
+// GetSharedWithMe lists the live goals other users shared with a user, with the user's role.
+func (s *ShareService) GetSharedWithMe(ctx context.Context, userID primitive.ObjectID) ([]models.SharedGoal, error) {
+	shares, err := s.repo.GetAcceptedShares(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	shared := []models.SharedGoal{}
+	if len(shares) == 0 {
+		return shared, nil
+	}
+
+	roles := make(map[primitive.ObjectID]string, len(shares))
+	ids := make([]primitive.ObjectID, 0, len(shares))
+	for _, share := range shares {
+		roles[share.GoalID] = share.Role
+		ids = append(ids, share.GoalID)
+	}
+	goals, err := s.goals.GetLiveGoals(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, goal := range goals {
+		shared = append(shared, models.SharedGoal{Goal: goal, Role: roles[goal.ID]})
+	}
+	return shared, nil
+}
+
+// AccessRole is an AccessLookup granting the role of the share a user accepted.
+func (s *ShareService) AccessRole(ctx context.Context, goal *models.Goal, userID primitive.ObjectID) (string, error) {
+	share, err := s.repo.GetAcceptedShare(ctx, goal.ID, userID)
+	if err != nil || share == nil {
+		return "", err
+	}
+	return share.Role, nil
+}
+
+// Audience is an AudienceLookup listing the users who accepted a share of the goal.
+func (s *ShareService) Audience(ctx context.Context, goal *models.Goal) ([]primitive.ObjectID, error) {
+	shares, err := s.repo.GetSharesByGoal(ctx, goal.ID)
+	if err != nil {
+		return nil, err
+	}
+	users := []primitive.ObjectID{}
+	for _, share := range shares {
+		if share.Status == models.ShareAccepted {
+			users = append(users, share.UserID)
+		}
+	}
+	return users, nil
+}
+
+// HandleGoalEvent is a GoalEventListener that removes the shares of purged goals.
+func (s *ShareService) HandleGoalEvent(ctx context.Context, event models.GoalEvent) {
+	if event.Type != models.EventGoalPurged {
+		return
+	}
+	if err := s.repo.DeleteSharesByGoal(ctx, event.GoalID); err != nil {
+		log.Printf("Failed to delete shares of goal %s: %v", event.GoalID.Hex(), err)
+	}
+}
+
+func (s *ShareService) getShare(ctx context.Context, id string) (*models.GoalShare, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrShareNotFound
+	}
+	share, err := s.repo.GetShareByID(ctx, objID)
+	if err != nil {
+		return nil, ErrShareNotFound
+	}
+	return share, nil
+}
+
+func (s *ShareService) unassign(ctx context.Context, goal *models.Goal, userID, actor primitive.ObjectID) {
+	if err := s.goals.UnassignUser(ctx, goal, userID, actor); err != nil {
+		log.Printf("Failed to unassign user %s from goal %s: %v", userID.Hex(), goal.ID.Hex(), err)
+	}
+}
+
+func (s *ShareService) notify(ctx context.Context, notification *models.Notification) {
+	if _, err := s.notifications.Notify(ctx, notification); err != nil {
+		log.Printf("Failed to notify user %s: %v", notification.UserID.Hex(), err)
+	}
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateShareRole tests that goals can only be shared with the known roles.
+func TestValidateShareRole(t *testing.T) {
+	for _, role := range []string{"viewer", "editor", "owner"} {
+		assert.NoError(t, ValidateShareRole(role))
+	}
+	assert.Error(t, ValidateShareRole(""))
+	assert.Error(t, ValidateShareRole("admin"))
+}
+
+// TestNormalizeEmail tests that invitation addresses are validated and lowercased.
+func TestNormalizeEmail(t *testing.T) {
+	email, err := NormalizeEmail("  Jane.Doe@Example.com ")
+	assert.NoError(t, err)
+	assert.Equal(t, "jane.doe@example.com", email)
+
+	for _, invalid := range []string{"", "jane", "Jane <jane@example.com>"} {
+		_, err := NormalizeEmail(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
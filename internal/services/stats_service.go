@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// StatsCacheTTL is how long computed statistics are reused while the user's goals don't change
+	StatsCacheTTL = 5 * time.Minute
+	// MaxStatsDays bounds the range statistics are computed over
+	MaxStatsDays = 731
+
+	// maxStatsCacheEntries bounds the memory the statistics cache can take
+	maxStatsCacheEntries = 1000
+)
+
+// StatsService computes statistics over a user's goals and caches them until the
+// user's goals change.
+type StatsService struct {
+	repo  *repository.StatsRepository
+	goals *GoalService
+
+	mu    sync.Mutex
+	cache map[string]statsCacheEntry
+}
+
+type statsCacheEntry struct {
+	userID  primitive.ObjectID
+	stats   *models.GoalStats
+	expires time.Time
+}
+
+// NewStatsService creates a new instance of StatsService.
+func NewStatsService(repo *repository.StatsRepository, goals *GoalService) *StatsService {
+	return &StatsService{
+		repo:  repo,
+		goals: goals,
+		cache: make(map[string]statsCacheEntry),
+	}
+}
+
+// GetStats returns the statistics of a user's goals from from up to, but excluding, to.
+// Weeks and weekdays follow the user's timezone and first day of the week.
+func (s *StatsService) GetStats(ctx context.Context, userID primitive.ObjectID, from, to time.Time) (*models.GoalStats, error) {
+	loc := s.goals.Location(ctx, userID)
+	firstDay := s.goals.Settings(ctx, userID).FirstWeekday()
+
+	key := fmt.Sprintf("%s|%d|%d|%s|%d", userID.Hex(), from.Unix(), to.Unix(), loc, firstDay)
+	if stats := s.cached(key); stats != nil {
+		return stats, nil
+	}
+
+	now := time.Now()
+	query := repository.StatsQuery{
+		UserID:    userID,
+		From:      from,
+		To:        to,
+		Now:       now,
+		Timezone:  loc.String(),
+		WeekStart: strings.ToLower(firstDay.String()),
+	}
+	goals, err := s.repo.GoalAggregates(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := s.repo.StepAggregates(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := BuildStats(from, to, loc, firstDay, goals, steps)
+	stats.GeneratedAt = now
+	s.store(key, userID, stats)
+	return stats, nil
+}
+
+// GetActivity returns the daily activity of a user over the last days days, today
+// included, with days following the user's timezone.
+func (s *StatsService) GetActivity(ctx context.Context, userID primitive.ObjectID, days int) (*models.ActivityCalendar, error) {
+	loc := s.goals.Location(ctx, userID)
+	year, month, day := time.Now().In(loc).Date()
+	first := time.Date(year, month, day-days+1, 0, 0, 0, 0, loc)
+
+	query := repository.StatsQuery{
+		UserID:   userID,
+		From:     first,
+		To:       time.Date(year, month, day+1, 0, 0, 0, 0, loc),
+		Timezone: loc.String(),
+	}
+	steps, err := s.repo.StepsByDay(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.repo.NotesByDay(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return BuildActivity(first, days, steps, notes), nil
+}
+
+// HandleGoalEvent is a GoalEventListener that drops the cached statistics of the owner
+// of a changed goal.
+func (s *StatsService) HandleGoalEvent(ctx context.Context, event models.GoalEvent) {
+	switch event.Type {
+	case models.EventNoteAdded, models.EventNoteUpdated, models.EventNoteDeleted:
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, entry := range s.cache {
+		if entry.userID == event.UserID {
+			delete(s.cache, key)
+		}
+	}
+}
+
+func (s *StatsService) cached(key string) *models.GoalStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil
+	}
+	return entry.stats
+}
+
+func (s *StatsService) store(key string, userID primitive.ObjectID, stats *models.GoalStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.cache) >= maxStatsCacheEntries {
+		for key, entry := range s.cache {
+			if now.After(entry.expires) {
+				delete(s.cache, key)
+			}
+		}
+		// Still full of live entries: start over rather than grow without bound
+		if len(s.cache) >= maxStatsCacheEntries {
+			s.cache = make(map[string]statsCacheEntry)
+		}
+	}
+	s.cache[key] = statsCacheEntry{userID: userID, stats: stats, expires: now.Add(StatsCacheTTL)}
+}
+
+// BuildStats turns raw aggregates into statistics, filling in the weeks and weekdays
+// without any activity.
+func BuildStats(from, to time.Time, loc *time.Location, firstDay time.Weekday, goals *repository.GoalAggregates, steps *repository.StepAggregates) *models.GoalStats {
+	stats := &models.GoalStats{
+		From:       from,
+		To:         to,
+		ByStatus:   make(map[string]int),
+		ByCategory: make(map[string]int),
+		Weeks:      []models.WeekStats{},
+		Weekdays:   []models.WeekdayStats{},
+	}
+
+	for _, bucket := range goals.ByStatus {
+		stats.ByStatus[bucket.Key] = bucket.Count
+		stats.Goals += bucket.Count
+	}
+	for _, bucket := range goals.ByCategory {
+		stats.ByCategory[bucket.Key] = bucket.Count
+	}
+	if stats.Goals > 0 {
+		stats.CompletionRate = roundTo(float64(stats.ByStatus["completed"])/float64(stats.Goals), 3)
+	}
+	if len(goals.Completion) > 0 {
+		stats.AverageDaysToComplete = roundTo(goals.Completion[0].AvgMillis/float64(24*time.Hour/time.Millisecond), 2)
+	}
+	if len(goals.Overdue) > 0 && goals.Overdue[0].Due > 0 {
+		stats.OverdueRate = roundTo(float64(goals.Overdue[0].Missed)/float64(goals.Overdue[0].Due), 3)
+	}
+
+	// Weeks are matched by the instant they start at, which is how the pipelines return them
+	created, completed, checked := countsByWeek(goals.Created), countsByWeek(goals.Completed), countsByWeek(steps.Weeks)
+	for start := WeekStart(from.In(loc), firstDay); start.Before(to); start = start.AddDate(0, 0, 7) {
+		stats.Weeks = append(stats.Weeks, models.WeekStats{
+			Start:          start,
+			GoalsCreated:   created[start.Unix()],
+			GoalsCompleted: completed[start.Unix()],
+			StepsCompleted: checked[start.Unix()],
+		})
+	}
+
+	goalsByDay, stepsByDay := countsByWeekday(goals.Weekdays), countsByWeekday(steps.Weekdays)
+	for i := 0; i < 7; i++ {
+		day := (firstDay + time.Weekday(i)) % 7
+		stats.Weekdays = append(stats.Weekdays, models.WeekdayStats{
+			Weekday:        strings.ToLower(day.String()),
+			GoalsCompleted: goalsByDay[day],
+			StepsCompleted: stepsByDay[day],
+		})
+	}
+	sort.SliceStable(stats.Weekdays, func(i, j int) bool {
+		a, b := stats.Weekdays[i], stats.Weekdays[j]
+		return a.GoalsCompleted+a.StepsCompleted > b.GoalsCompleted+b.StepsCompleted
+	})
+
+	return stats
+}
+
+// BuildActivity lays out days days of activity from first, local midnight of the first
+// day, and works out the streaks. The last day is taken to be today.
+func BuildActivity(first time.Time, days int, steps, notes []repository.CountByDay) *models.ActivityCalendar {
+	stepsByDay, notesByDay := countsByDay(steps), countsByDay(notes)
+	calendar := &models.ActivityCalendar{
+		From:     first.Format(models.DateLayout),
+		To:       first.AddDate(0, 0, days-1).Format(models.DateLayout),
+		Timezone: first.Location().String(),
+		Days:     make([]models.ActivityDay, 0, days),
+	}
+
+	streak := 0
+	for i := 0; i < days; i++ {
+		date := first.AddDate(0, 0, i).Format(models.DateLayout)
+		day := models.ActivityDay{
+			Date:           date,
+			StepsCompleted: stepsByDay[date],
+			CheckIns:       notesByDay[date],
+		}
+		day.Total = day.StepsCompleted + day.CheckIns
+		calendar.Days = append(calendar.Days, day)
+
+		if day.Total == 0 {
+			streak = 0
+			continue
+		}
+		streak++
+		calendar.ActiveDays++
+		calendar.LongestStreak = max(calendar.LongestStreak, streak)
+	}
+
+	// Today still counts towards the streak until it is over
+	for i := len(calendar.Days) - 1; i >= 0; i-- {
+		if calendar.Days[i].Total > 0 {
+			calendar.CurrentStreak++
+		} else if i < len(calendar.Days)-1 {
+			break
+		}
+	}
+	return calendar
+}
+
+func countsByDay(buckets []repository.CountByDay) map[string]int {
+	counts := make(map[string]int, len(buckets))
+	for _, bucket := range buckets {
+		counts[bucket.Day] += bucket.Count
+	}
+	return counts
+}
+
+// WeekStart returns local midnight of the first day of the week t falls in.
+func WeekStart(t time.Time, firstDay time.Weekday) time.Time {
+	offset := (int(t.Weekday()) - int(firstDay) + 7) % 7
+	year, month, day := t.Date()
+	return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+}
+
+func countsByWeek(buckets []repository.CountByDate) map[int64]int {
+	counts := make(map[int64]int, len(buckets))
+	for _, bucket := range buckets {
+		counts[bucket.Date.Unix()] += bucket.Count
+	}
+	return counts
+}
+
+// countsByWeekday keys ISO weekday buckets, where Sunday is 7, by time.Weekday.
+func countsByWeekday(buckets []repository.CountByWeekday) map[time.Weekday]int {
+	counts := make(map[time.Weekday]int, len(buckets))
+	for _, bucket := range buckets {
+		counts[time.Weekday(bucket.Weekday%7)] += bucket.Count
+	}
+	return counts
+}
+
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
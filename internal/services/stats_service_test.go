@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWeekStart tests that weeks start at local midnight of the chosen first day.
+func TestWeekStart(t *testing.T) {
+	almaty, err := time.LoadLocation("Asia/Almaty")
+	assert.NoError(t, err)
+
+	// Thursday 15 October 2026
+	thursday := time.Date(2026, 10, 15, 18, 30, 0, 0, almaty)
+	assert.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, almaty), WeekStart(thursday, time.Monday))
+	assert.Equal(t, time.Date(2026, 10, 11, 0, 0, 0, 0, almaty), WeekStart(thursday, time.Sunday))
+	assert.Equal(t, time.Date(2026, 10, 10, 0, 0, 0, 0, almaty), WeekStart(thursday, time.Saturday))
+
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, almaty)
+	assert.Equal(t, monday, WeekStart(monday, time.Monday))
+}
+
+// TestBuildStats tests that aggregates are turned into rates and complete week and
+// weekday series.
+func TestBuildStats(t *testing.T) {
+	loc := time.UTC
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, loc)
+	to := time.Date(2026, 10, 22, 0, 0, 0, 0, loc)
+	week := time.Date(2026, 10, 5, 0, 0, 0, 0, loc)
+
+	goals := &repository.GoalAggregates{
+		ByStatus:   []repository.CountByKey{{Key: "completed", Count: 3}, {Key: "pending", Count: 1}},
+		ByCategory: []repository.CountByKey{{Key: "Health", Count: 4}},
+		Created:    []repository.CountByDate{{Date: week, Count: 4}},
+		Completed:  []repository.CountByDate{{Date: week, Count: 3}},
+		Weekdays:   []repository.CountByWeekday{{Weekday: 3, Count: 3}},
+	}
+	goals.Completion = append(goals.Completion, struct {
+		Count     int     `bson:"count"`
+		AvgMillis float64 `bson:"avg_millis"`
+	}{Count: 3, AvgMillis: float64(36 * time.Hour / time.Millisecond)})
+	goals.Overdue = append(goals.Overdue, struct {
+		Due    int `bson:"due"`
+		Missed int `bson:"missed"`
+	}{Due: 4, Missed: 1})
+	steps := &repository.StepAggregates{
+		Weeks:    []repository.CountByDate{{Date: week, Count: 7}},
+		Weekdays: []repository.CountByWeekday{{Weekday: 7, Count: 6}, {Weekday: 3, Count: 2}},
+	}
+
+	stats := BuildStats(from, to, loc, time.Monday, goals, steps)
+	assert.Equal(t, 4, stats.Goals)
+	assert.Equal(t, 0.75, stats.CompletionRate)
+	assert.Equal(t, 1.5, stats.AverageDaysToComplete)
+	assert.Equal(t, 0.25, stats.OverdueRate)
+
+	// 1 October 2026 is a Thursday, so the first week starts on Monday 28 September
+	assert.Len(t, stats.Weeks, 4)
+	assert.Equal(t, time.Date(2026, 9, 28, 0, 0, 0, 0, loc), stats.Weeks[0].Start)
+	assert.Equal(t, 4, stats.Weeks[1].GoalsCreated)
+	assert.Equal(t, 3, stats.Weeks[1].GoalsCompleted)
+	assert.Equal(t, 7, stats.Weeks[1].StepsCompleted)
+	assert.Equal(t, 0, stats.Weeks[2].StepsCompleted)
+
+	assert.Len(t, stats.Weekdays, 7)
+	assert.Equal(t, "sunday", stats.Weekdays[0].Weekday)
+	assert.Equal(t, "wednesday", stats.Weekdays[1].Weekday)
+	assert.Equal(t, 3, stats.Weekdays[1].GoalsCompleted)
+	assert.Equal(t, "monday", stats.Weekdays[2].Weekday)
+
+	empty := BuildStats(from, to, loc, time.Monday, &repository.GoalAggregates{}, &repository.StepAggregates{})
+	assert.Equal(t, 0.0, empty.CompletionRate)
+	assert.Len(t, empty.Weeks, 4)
+}
+
+// TestBuildActivity tests the daily series and the current and longest streaks.
+func TestBuildActivity(t *testing.T) {
+	almaty, err := time.LoadLocation("Asia/Almaty")
+	assert.NoError(t, err)
+	first := time.Date(2026, 10, 9, 0, 0, 0, 0, almaty)
+
+	steps := []repository.CountByDay{
+		{Day: "2026-10-09", Count: 1}, {Day: "2026-10-10", Count: 2}, {Day: "2026-10-11", Count: 1},
+		{Day: "2026-10-16", Count: 3},
+	}
+	notes := []repository.CountByDay{{Day: "2026-10-12", Count: 1}, {Day: "2026-10-17", Count: 2}}
+
+	// Today, 18 October, has no activity yet, so the streak still runs up to yesterday
+	calendar := BuildActivity(first, 10, steps, notes)
+	assert.Equal(t, "2026-10-09", calendar.From)
+	assert.Equal(t, "2026-10-18", calendar.To)
+	assert.Equal(t, "Asia/Almaty", calendar.Timezone)
+	assert.Len(t, calendar.Days, 10)
+	assert.Equal(t, 0, calendar.Days[9].Total)
+	assert.Equal(t, 3, calendar.Days[7].StepsCompleted)
+	assert.Equal(t, 2, calendar.Days[8].Total)
+	assert.Equal(t, 6, calendar.ActiveDays)
+	assert.Equal(t, 4, calendar.LongestStreak)
+	assert.Equal(t, 2, calendar.CurrentStreak)
+
+	// A day without activity before today ends the streak
+	calendar = BuildActivity(first, 11, steps, notes)
+	assert.Equal(t, 0, calendar.CurrentStreak)
+
+	calendar = BuildActivity(first, 8, steps, notes)
+	assert.Equal(t, 1, calendar.CurrentStreak)
+}
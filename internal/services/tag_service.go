@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxTagLength limits the length of a tag name.
+const MaxTagLength = 50
+
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// TagService encapsulates the business logic for tags.
+type TagService struct {
+	repo  *repository.TagRepository
+	goals *GoalService
+}
+
+// NewTagService creates a new instance of TagService.
+func NewTagService(repo *repository.TagRepository, goals *GoalService) *TagService {
+	return &TagService{
+		repo:  repo,
+		goals: goals,
+	}
+}
+
+// GetTags lists the tags of a user with their colors and usage counts. Tags that have a
+// color but are no longer used by any goal are listed with a count of zero.
+func (s *TagService) GetTags(ctx context.Context, userID primitive.ObjectID) ([]models.TagUsage, error) {
+	usage, err := s.goals.CountTags(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.repo.GetTags(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	colors := make(map[string]string)
+	for _, tag := range tags {
+		colors[tag.Name] = tag.Color
+	}
+	for i := range usage {
+		usage[i].Color = colors[usage[i].Name]
+		delete(colors, usage[i].Name)
+	}
+
+	var unused []models.TagUsage
+	for name, color := range colors {
+		unused = append(unused, models.TagUsage{Name: name, Color: color})
+	}
+	sort.Slice(unused, func(i, j int) bool { return unused[i].Name < unused[j].Name })
+	return append(usage, unused...), nil
+}
+
+// SetTagColor sets the display color of a tag.
+func (s *TagService) SetTagColor(ctx context.Context, userID primitive.ObjectID, name, color string) (*models.Tag, error) {
+	return s.repo.SetColor(ctx, userID, name, color)
+}
+
+// RenameTag renames a tag on every goal of the user. Renaming to a tag that already
+// exists merges the two. It returns the number of goals changed.
+func (s *TagService) RenameTag(ctx context.Context, userID primitive.ObjectID, from, to string) (int, error) {
+	return s.MergeTags(ctx, userID, []string{from}, to)
+}
+
+// MergeTags replaces the source tags with target on every goal of the user. The target
+// keeps its color, or takes the color of the first source that has one.
+// It returns the number of goals changed.
+func (s *TagService) MergeTags(ctx context.Context, userID primitive.ObjectID, sources []string, target string) (int, error) {
+	var merged []string
+	for _, source := range sources {
+		if source != target {
+			merged = append(merged, source)
+		}
+	}
+	if len(merged) == 0 {
+		return 0, nil
+	}
+
+	changed, err := s.goals.ReplaceTags(ctx, userID, merged, target)
+	if err != nil {
+		return changed, fmt.Errorf("failed to replace tags: %v", err)
+	}
+
+	if err := s.carryColor(ctx, userID, merged, target); err != nil {
+		return changed, err
+	}
+	return changed, s.repo.DeleteTags(ctx, userID, merged)
+}
+
+// DeleteTag removes a tag from every goal of the user. It returns the number of goals changed.
+func (s *TagService) DeleteTag(ctx context.Context, userID primitive.ObjectID, name string) (int, error) {
+	changed, err := s.goals.ReplaceTags(ctx, userID, []string{name}, "")
+	if err != nil {
+		return changed, fmt.Errorf("failed to remove tag: %v", err)
+	}
+	return changed, s.repo.DeleteTags(ctx, userID, []string{name})
+}
+
+func (s *TagService) carryColor(ctx context.Context, userID primitive.ObjectID, sources []string, target string) error {
+	existing, err := s.repo.GetTag(ctx, userID, target)
+	if err != nil || (existing != nil && existing.Color != "") {
+		return err
+	}
+	for _, source := range sources {
+		tag, err := s.repo.GetTag(ctx, userID, source)
+		if err != nil {
+			return err
+		}
+		if tag != nil && tag.Color != "" {
+			_, err := s.repo.SetColor(ctx, userID, target, tag.Color)
+			return err
+		}
+	}
+	return nil
+}
+
+// NormalizeTags trims the tags of a goal and drops empty and duplicate ones.
+func NormalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if err := ValidateTagName(tag); err != nil {
+			return nil, err
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// ValidateTagName checks the name of a tag.
+func ValidateTagName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("tag name is required")
+	}
+	if len(name) > MaxTagLength {
+		return fmt.Errorf("tag is longer than %d characters: %s", MaxTagLength, name)
+	}
+	return nil
+}
+
+// ValidateColor checks that color is a hex color such as #1e90ff or #f80.
+func ValidateColor(color string) error {
+	if !colorPattern.MatchString(color) {
+		return fmt.Errorf("invalid color: use a hex color such as #1e90ff")
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReplaceTags tests renaming, merging and removing tags on a goal.
+func TestReplaceTags(t *testing.T) {
+	goal := &models.Goal{Tags: []string{"q3", "books", "reading"}}
+	assert.True(t, replaceTags(goal, []string{"books"}, "reading"))
+	assert.Equal(t, []string{"q3", "reading"}, goal.Tags)
+
+	assert.False(t, replaceTags(goal, []string{"missing"}, "other"))
+	assert.Equal(t, []string{"q3", "reading"}, goal.Tags)
+
+	assert.True(t, replaceTags(goal, []string{"q3"}, ""))
+	assert.Equal(t, []string{"reading"}, goal.Tags)
+}
+
+// TestNormalizeTags tests trimming, de-duplication and validation of goal tags.
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" q3 ", "q3", "", "reading"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"q3", "reading"}, tags)
+
+	_, err = NormalizeTags([]string{strings.Repeat("x", MaxTagLength+1)})
+	assert.Error(t, err)
+}
+
+// TestValidateColor tests the accepted tag and category colors.
+func TestValidateColor(t *testing.T) {
+	assert.NoError(t, ValidateColor("#1e90ff"))
+	assert.NoError(t, ValidateColor("#F80"))
+	assert.Error(t, ValidateColor("blue"))
+	assert.Error(t, ValidateColor("#12345"))
+}
@@ -0,0 +1,70 @@
+package services
+
+import "github.com/czeful/diplom_back/internal/models"
+
+// systemTemplates are the built-in templates seeded at startup, identified by slug.
+var systemTemplates = []models.GoalTemplate{
+	{
+		Slug:          "couch-to-5k",
+		Name:          "Couch to 5K",
+		Category:      "Health",
+		Description:   "Go from no running at all to running 5 kilometres without stopping in nine weeks.",
+		DueOffsetDays: 63,
+		Steps: []models.TemplateStep{
+			{Name: "Week 1: alternate 60s running and 90s walking", DueOffsetDays: 7},
+			{Name: "Week 2: alternate 90s running and 2 min walking", DueOffsetDays: 14},
+			{Name: "Week 3: run 3 min intervals", DueOffsetDays: 21},
+			{Name: "Week 4: run 5 min intervals", DueOffsetDays: 28},
+			{Name: "Week 5: run 20 minutes without walking", DueOffsetDays: 35},
+			{Name: "Week 6: run 25 minutes", DueOffsetDays: 42},
+			{Name: "Week 7: run 25 minutes three times", DueOffsetDays: 49},
+			{Name: "Week 8: run 28 minutes", DueOffsetDays: 56},
+			{Name: "Week 9: run 5K", DueOffsetDays: 63},
+		},
+	},
+	{
+		Slug:          "language-a1-b1",
+		Name:          "Learn a language A1→B1",
+		Category:      "Education",
+		Description:   "Reach an intermediate B1 level in a new language within six months.",
+		DueOffsetDays: 180,
+		Steps: []models.TemplateStep{
+			{Name: "Learn the alphabet and pronunciation", DueOffsetDays: 7},
+			{Name: "Master the 500 most common words", DueOffsetDays: 30},
+			{Name: "Complete an A1 course", DueOffsetDays: 60},
+			{Name: "Hold a 10 minute conversation", DueOffsetDays: 90},
+			{Name: "Complete an A2 course", DueOffsetDays: 120},
+			{Name: "Read a graded reader", DueOffsetDays: 150},
+			{Name: "Pass a B1 practice exam", DueOffsetDays: 180},
+		},
+	},
+	{
+		Slug:          "emergency-fund",
+		Name:          "Build an emergency fund",
+		Category:      "Finance",
+		Description:   "Save three months of living expenses.",
+		DueOffsetDays: 365,
+		Steps: []models.TemplateStep{
+			{Name: "Calculate monthly expenses", DueOffsetDays: 7},
+			{Name: "Open a separate savings account", DueOffsetDays: 14},
+			{Name: "Set up an automatic monthly transfer", DueOffsetDays: 21},
+			{Name: "Save one month of expenses", DueOffsetDays: 120},
+			{Name: "Save two months of expenses", DueOffsetDays: 240},
+			{Name: "Save three months of expenses", DueOffsetDays: 365},
+		},
+	},
+	{
+		Slug:          "read-12-books",
+		Name:          "Read 12 books this year",
+		Category:      "Personal",
+		Description:   "Read one book a month for a year.",
+		DueOffsetDays: 365,
+		Steps: []models.TemplateStep{
+			{Name: "Pick a reading list", DueOffsetDays: 7},
+			{Name: "Finish 3 books", DueOffsetDays: 91},
+			{Name: "Finish 6 books", DueOffsetDays: 182},
+			{Name: "Finish 9 books", DueOffsetDays: 273},
+			{Name: "Finish 12 books", DueOffsetDays: 365},
+		},
+	},
+}
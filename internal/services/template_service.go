@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TemplateService encapsulates the business logic for goal templates.
+type TemplateService struct {
+	repo  *repository.TemplateRepository
+	goals *GoalService
+}
+
+// NewTemplateService creates a new instance of TemplateService.
+func NewTemplateService(repo *repository.TemplateRepository, goals *GoalService) *TemplateService {
+	return &TemplateService{
+		repo:  repo,
+		goals: goals,
+	}
+}
+
+// SeedSystemTemplates creates or refreshes the built-in templates.
+func (s *TemplateService) SeedSystemTemplates(ctx context.Context) error {
+	for i := range systemTemplates {
+		template := systemTemplates[i]
+		if err := s.repo.UpsertSystemTemplate(ctx, &template); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateTemplate stores a new private template.
+func (s *TemplateService) CreateTemplate(ctx context.Context, template *models.GoalTemplate) (*models.GoalTemplate, error) {
+	template.System = false
+	template.Slug = ""
+	createdTemplate, err := s.repo.CreateTemplate(ctx, template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template: %v", err)
+	}
+	return createdTemplate, nil
+}
+
+// GetTemplate retrieves a template by its ID.
+func (s *TemplateService) GetTemplate(ctx context.Context, id string) (*models.GoalTemplate, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template ID: %v", err)
+	}
+	template, err := s.repo.GetTemplateByID(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %v", err)
+	}
+	return template, nil
+}
+
+// GetTemplates retrieves the system templates and the user's private templates.
+func (s *TemplateService) GetTemplates(ctx context.Context, userID primitive.ObjectID, category string) ([]models.GoalTemplate, error) {
+	return s.repo.GetTemplates(ctx, userID, category)
+}
+
+// UpdateTemplate updates a private template.
+func (s *TemplateService) UpdateTemplate(ctx context.Context, id string, template *models.GoalTemplate) (*models.GoalTemplate, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template ID: %v", err)
+	}
+	updatedTemplate, err := s.repo.UpdateTemplate(ctx, objID, template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update template: %v", err)
+	}
+	return updatedTemplate, nil
+}
+
+// DeleteTemplate removes a private template.
+func (s *TemplateService) DeleteTemplate(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid template ID: %v", err)
+	}
+	return s.repo.DeleteTemplate(ctx, objID)
+}
+
+// SaveGoalAsTemplate creates a private template from an existing goal. Due dates are
+// turned into offsets relative to the day the goal was created.
+func (s *TemplateService) SaveGoalAsTemplate(ctx context.Context, goal *models.Goal, name string) (*models.GoalTemplate, error) {
+	if name == "" {
+		name = goal.Name
+	}
+	template := &models.GoalTemplate{
+		UserID:      goal.UserID,
+		Name:        name,
+		Category:    goal.Category,
+		Description: goal.Description,
+		Steps:       []models.TemplateStep{},
+	}
+	if !goal.DueDate.IsZero() {
+		template.DueOffsetDays = offsetDays(goal.CreatedAt, goal.DueDate)
+	}
+	for _, step := range goal.Steps {
+		templateStep := models.TemplateStep{Name: step}
+		if meta, exists := goal.StepMeta[step]; exists && meta.DueDate != nil {
+			templateStep.DueOffsetDays = offsetDays(goal.CreatedAt, *meta.DueDate)
+		}
+		template.Steps = append(template.Steps, templateStep)
+	}
+	return s.CreateTemplate(ctx, template)
+}
+
+// CreateGoalFromTemplate instantiates a goal for userID, computing due dates from start.
+// When the template has no overall due offset the goal is due with its last step.
+func (s *TemplateService) CreateGoalFromTemplate(ctx context.Context, template *models.GoalTemplate, userID primitive.ObjectID, orgID *primitive.ObjectID, start time.Time, name string) (*models.Goal, error) {
+	goal := BuildGoalFromTemplate(template, start)
+	goal.UserID = userID
+	goal.OrgID = orgID
+	if name != "" {
+		goal.Name = name
+	}
+	return s.goals.CreateGoal(ctx, goal)
+}
+
+// BuildGoalFromTemplate returns the goal a template describes when started at start.
+func BuildGoalFromTemplate(template *models.GoalTemplate, start time.Time) *models.Goal {
+	goal := &models.Goal{
+		Name:        template.Name,
+		Description: template.Description,
+		Category:    template.Category,
+		Steps:       []string{},
+		Progress:    make(map[string]bool),
+		Status:      "pending",
+	}
+
+	lastOffset := 0
+	for _, step := range template.Steps {
+		goal.Steps = append(goal.Steps, step.Name)
+		goal.Progress[step.Name] = false
+		if step.DueOffsetDays > 0 {
+			if goal.StepMeta == nil {
+				goal.StepMeta = make(map[string]models.StepMeta)
+			}
+			dueDate := start.AddDate(0, 0, step.DueOffsetDays)
+			goal.StepMeta[step.Name] = models.StepMeta{DueDate: &dueDate}
+			if step.DueOffsetDays > lastOffset {
+				lastOffset = step.DueOffsetDays
+			}
+		}
+	}
+
+	if template.DueOffsetDays > 0 {
+		goal.DueDate = start.AddDate(0, 0, template.DueOffsetDays)
+	} else if lastOffset > 0 {
+		goal.DueDate = start.AddDate(0, 0, lastOffset)
+	}
+	return goal
+}
+
+// ValidateTemplate checks the name and steps of a template. Whether the category is
+// available depends on the owner; see GoalService.ValidCategory.
+func ValidateTemplate(template *models.GoalTemplate) error {
+	if template.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	if template.DueOffsetDays < 0 {
+		return fmt.Errorf("due offset cannot be negative")
+	}
+	seen := make(map[string]bool)
+	for _, step := range template.Steps {
+		if step.Name == "" {
+			return fmt.Errorf("step name is required")
+		}
+		if seen[step.Name] {
+			return fmt.Errorf("duplicate step: %s", step.Name)
+		}
+		if step.DueOffsetDays < 0 {
+			return fmt.Errorf("due offset cannot be negative")
+		}
+		seen[step.Name] = true
+	}
+	return nil
+}
+
+// offsetDays returns the whole number of days from start to t, rounded up and never negative.
+func offsetDays(start, t time.Time) int {
+	days := int(math.Ceil(t.Sub(start).Hours() / 24))
+	if days < 0 {
+		return 0
+	}
+	return days
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSystemTemplatesAreValid ensures the seeded templates pass validation.
+func TestSystemTemplatesAreValid(t *testing.T) {
+	for i := range systemTemplates {
+		assert.NoError(t, ValidateTemplate(&systemTemplates[i]), systemTemplates[i].Slug)
+	}
+}
+
+// TestBuildGoalFromTemplate tests due date computation from the start date.
+func TestBuildGoalFromTemplate(t *testing.T) {
+	template := &models.GoalTemplate{
+		Name: "Learn Go",
+		Steps: []models.TemplateStep{
+			{Name: "Tour of Go", DueOffsetDays: 7},
+			{Name: "Build a CLI", DueOffsetDays: 30},
+			{Name: "Celebrate"},
+		},
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	goal := BuildGoalFromTemplate(template, start)
+	assert.Equal(t, []string{"Tour of Go", "Build a CLI", "Celebrate"}, goal.Steps)
+	assert.Equal(t, map[string]bool{"Tour of Go": false, "Build a CLI": false, "Celebrate": false}, goal.Progress)
+	assert.Equal(t, time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC), *goal.StepMeta["Tour of Go"].DueDate)
+	assert.NotContains(t, goal.StepMeta, "Celebrate")
+	// Without an overall offset the goal is due with its last step
+	assert.Equal(t, time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), goal.DueDate)
+}
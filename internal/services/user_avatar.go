@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/storage"
+	"github.com/czeful/diplom_back/pkg/imaging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxAvatarSize is the largest accepted avatar upload, in bytes.
+const MaxAvatarSize = 5 << 20
+
+var (
+	// ErrAvatarTooLarge is returned when an avatar upload exceeds MaxAvatarSize
+	ErrAvatarTooLarge = errors.New("avatar is too large")
+	// ErrInvalidAvatar is returned when an avatar upload is not a JPEG, PNG or GIF image
+	ErrInvalidAvatar = errors.New("avatar must be a JPEG, PNG or GIF image")
+)
+
+// avatarImage is an avatar rendered in one of models.AvatarSizes.
+type avatarImage struct {
+	size models.AvatarSize
+	data []byte
+}
+
+// UploadAvatar crops the image to a square, stores it in every avatar size and makes it
+// the user's avatar. The images of the previous avatar are removed.
+func (s *UserService) UploadAvatar(ctx context.Context, user *models.User, r io.Reader) (*models.User, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxAvatarSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %v", err)
+	}
+	if len(data) > MaxAvatarSize {
+		return nil, ErrAvatarTooLarge
+	}
+	images, err := renderAvatar(data)
+	if err != nil {
+		return nil, err
+	}
+
+	avatar := &models.Avatar{ID: primitive.NewObjectID(), UpdatedAt: time.Now()}
+	for _, image := range images {
+		if _, err := s.store.Put(ctx, avatarKey(avatar.ID, image.size.Name), bytes.NewReader(image.data)); err != nil {
+			s.deleteAvatarBlobs(ctx, avatar)
+			return nil, err
+		}
+	}
+
+	updatedUser, err := s.repo.UpdateUserFields(ctx, user.ID, bson.M{"avatar": avatar})
+	if err != nil {
+		s.deleteAvatarBlobs(ctx, avatar)
+		return nil, fmt.Errorf("failed to update user: %v", err)
+	}
+	if user.Avatar != nil {
+		s.deleteAvatarBlobs(ctx, user.Avatar)
+	}
+	return updatedUser, nil
+}
+
+// DeleteAvatar removes the user's avatar.
+func (s *UserService) DeleteAvatar(ctx context.Context, user *models.User) (*models.User, error) {
+	if user.Avatar == nil {
+		return user, nil
+	}
+	updatedUser, err := s.repo.UpdateUserFields(ctx, user.ID, bson.M{"avatar": nil})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %v", err)
+	}
+	s.deleteAvatarBlobs(ctx, user.Avatar)
+	return updatedUser, nil
+}
+
+// OpenAvatar opens the image of an avatar in the size with the given name.
+func (s *UserService) OpenAvatar(ctx context.Context, avatarID, sizeName string) (storage.Blob, error) {
+	objID, err := primitive.ObjectIDFromHex(avatarID)
+	if err != nil {
+		return nil, storage.ErrNotFound
+	}
+	for _, size := range models.AvatarSizes {
+		if size.Name == sizeName {
+			return s.store.Open(ctx, avatarKey(objID, size.Name))
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (s *UserService) deleteAvatarBlobs(ctx context.Context, avatar *models.Avatar) {
+	for _, size := range models.AvatarSizes {
+		key := avatarKey(avatar.ID, size.Name)
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// avatarKey returns the blob key of an avatar image.
+func avatarKey(avatarID primitive.ObjectID, sizeName string) string {
+	return "avatars/" + avatarID.Hex() + "/" + sizeName
+}
+
+// renderAvatar crops an uploaded image to its centered square and scales it to every
+// avatar size. Photos stay JPEG, other images become PNG.
+func renderAvatar(data []byte) ([]avatarImage, error) {
+	img, format, err := imaging.Decode(data)
+	if err != nil {
+		return nil, ErrInvalidAvatar
+	}
+	square := imaging.Square(img)
+
+	images := make([]avatarImage, 0, len(models.AvatarSizes))
+	for _, size := range models.AvatarSizes {
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, imaging.Fit(square, size.Pixels), format); err != nil {
+			return nil, fmt.Errorf("failed to encode avatar: %v", err)
+		}
+		images = append(images, avatarImage{size: size, data: buf.Bytes()})
+	}
+	return images, nil
+}
@@ -2,25 +2,38 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/czeful/diplom_back/internal/models"
 	"github.com/czeful/diplom_back/internal/repository"
+	"github.com/czeful/diplom_back/internal/storage"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrEmailInUse is returned when an email address is already registered to another user.
+var ErrEmailInUse = errors.New("email already in use")
+
 // UserService encapsulates the business logic for user operations.
 type UserService struct {
-	repo *repository.UserRepository
+	repo  *repository.UserRepository
+	store storage.BlobStore
 }
 
-// NewUserService creates a new instance of UserService.
-func NewUserService(repo *repository.UserRepository) *UserService {
+// NewUserService creates a new instance of UserService, keeping avatars in store.
+func NewUserService(repo *repository.UserRepository, store storage.BlobStore) *UserService {
 	return &UserService{
-		repo: repo,
+		repo:  repo,
+		store: store,
 	}
 }
 
@@ -33,7 +46,7 @@ func (s *UserService) RegisterUser(ctx context.Context, user *models.User) (*mod
 	// Check if the email is already registered
 	existingUser, _ := s.repo.GetUserByEmail(ctx, user.Email)
 	if existingUser != nil {
-		return nil, fmt.Errorf("email already in use")
+		return nil, ErrEmailInUse
 	}
 
 	// Hash the user's password.
@@ -80,6 +93,15 @@ func (s *UserService) GetUser(ctx context.Context, id string) (*models.User, err
 	return user, nil
 }
 
+// FindUserByEmail returns the user registered with an email address, or nil if there is none.
+func (s *UserService) FindUserByEmail(ctx context.Context, email string) *models.User {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
 // UpdateUser updates an existing user's details.
 func (s *UserService) UpdateUser(ctx context.Context, id string, updatedUser *models.User) (*models.User, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -94,6 +116,133 @@ func (s *UserService) UpdateUser(ctx context.Context, id string, updatedUser *mo
 	return user, nil
 }
 
+// Location returns the timezone of a user, UTC when the user hasn't chosen one.
+func (s *UserService) Location(ctx context.Context, userID primitive.ObjectID) (*time.Location, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(user.Timezone)
+}
+
+// Profile field limits, in characters.
+const (
+	MaxUsernameLength    = 50
+	MaxDisplayNameLength = 50
+	MaxBioLength         = 500
+)
+
+// localePattern matches BCP 47 language tags such as "en", "ru-RU" or "zh-Hant-TW".
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// UserPatch holds the user fields that can be changed by the user.
+type UserPatch struct {
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	Locale      string `json:"locale"`
+	Timezone    string `json:"timezone"`
+}
+
+// NewUserPatch returns the editable fields of a user.
+func NewUserPatch(user *models.User) UserPatch {
+	return UserPatch{
+		Username:    user.Username,
+		Email:       user.Email,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Locale:      user.Locale,
+		Timezone:    user.Timezone,
+	}
+}
+
+// ValidateUserPatch trims the fields of patch and checks that they make a valid profile.
+// The locale must be a BCP 47 language tag and the timezone an IANA zone name; both
+// may be left empty.
+func ValidateUserPatch(patch *UserPatch) error {
+	patch.Username = strings.TrimSpace(patch.Username)
+	patch.Email = strings.TrimSpace(patch.Email)
+	patch.DisplayName = strings.TrimSpace(patch.DisplayName)
+	patch.Bio = strings.TrimSpace(stripControl(patch.Bio))
+	patch.Locale = strings.TrimSpace(patch.Locale)
+	patch.Timezone = strings.TrimSpace(patch.Timezone)
+
+	if patch.Username == "" {
+		return fmt.Errorf("username is required")
+	}
+	if utf8.RuneCountInString(patch.Username) > MaxUsernameLength {
+		return fmt.Errorf("username must be at most %d characters", MaxUsernameLength)
+	}
+	if address, err := mail.ParseAddress(patch.Email); err != nil || address.Address != patch.Email {
+		return fmt.Errorf("invalid email")
+	}
+	if utf8.RuneCountInString(patch.DisplayName) > MaxDisplayNameLength {
+		return fmt.Errorf("display name must be at most %d characters", MaxDisplayNameLength)
+	}
+	if strings.IndexFunc(patch.DisplayName, unicode.IsControl) >= 0 {
+		return fmt.Errorf("display name must not contain control characters")
+	}
+	if utf8.RuneCountInString(patch.Bio) > MaxBioLength {
+		return fmt.Errorf("bio must be at most %d characters", MaxBioLength)
+	}
+	if patch.Locale != "" && !localePattern.MatchString(patch.Locale) {
+		return fmt.Errorf("invalid locale %q", patch.Locale)
+	}
+	if patch.Timezone != "" {
+		// "Local" would mean the server's zone, which is exactly what users shouldn't get
+		if _, err := time.LoadLocation(patch.Timezone); err != nil || patch.Timezone == "Local" {
+			return fmt.Errorf("unknown timezone %q", patch.Timezone)
+		}
+	}
+	return nil
+}
+
+// PatchUser writes only the fields of patch that differ from the stored user. The patch
+// must have been validated with ValidateUserPatch.
+func (s *UserService) PatchUser(ctx context.Context, id string, patch UserPatch) (*models.User, error) {
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := bson.M{}
+	if patch.Username != user.Username {
+		fields["username"] = patch.Username
+	}
+	if patch.Email != user.Email {
+		// The new email must not belong to another account
+		if existingUser, _ := s.repo.GetUserByEmail(ctx, patch.Email); existingUser != nil {
+			return nil, ErrEmailInUse
+		}
+		fields["email"] = patch.Email
+	}
+	if patch.DisplayName != user.DisplayName {
+		fields["display_name"] = patch.DisplayName
+	}
+	if patch.Bio != user.Bio {
+		fields["bio"] = patch.Bio
+	}
+	if patch.Locale != user.Locale {
+		fields["locale"] = patch.Locale
+	}
+	if patch.Timezone != user.Timezone {
+		fields["timezone"] = patch.Timezone
+	}
+	if len(fields) == 0 {
+		return user, nil
+	}
+
+	updatedUser, err := s.repo.UpdateUserFields(ctx, user.ID, fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %v", err)
+	}
+	return updatedUser, nil
+}
+
 // DeleteUser deletes a user by their ID.
 func (s *UserService) DeleteUser(ctx context.Context, id string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestValidateUserPatch tests that profile fields are trimmed and validated.
+func TestValidateUserPatch(t *testing.T) {
+	patch := UserPatch{
+		Username:    " alice ",
+		Email:       "alice@example.com",
+		DisplayName: "Alice",
+		Bio:         "Runner\r\nReader ",
+		Locale:      "ru-RU",
+		Timezone:    "Asia/Almaty",
+	}
+	assert.NoError(t, ValidateUserPatch(&patch))
+	assert.Equal(t, "alice", patch.Username)
+	assert.Equal(t, "Runner\nReader", patch.Bio)
+
+	invalid := []UserPatch{
+		{Email: "alice@example.com"},
+		{Username: "alice", Email: "not an email"},
+		{Username: "alice", Email: "Alice <alice@example.com>"},
+		{Username: "alice", Email: "alice@example.com", DisplayName: strings.Repeat("x", MaxDisplayNameLength+1)},
+		{Username: "alice", Email: "alice@example.com", Bio: strings.Repeat("x", MaxBioLength+1)},
+		{Username: "alice", Email: "alice@example.com", Locale: "english!"},
+		{Username: "alice", Email: "alice@example.com", Timezone: "Mars/Olympus"},
+		{Username: "alice", Email: "alice@example.com", Timezone: "Local"},
+	}
+	for _, patch := range invalid {
+		assert.Error(t, ValidateUserPatch(&patch), "%+v", patch)
+	}
+}
+
+// TestUserProfile tests that profiles never include the password hash and that the
+// public profile leaves out contact details.
+func TestUserProfile(t *testing.T) {
+	user := &models.User{
+		ID:             primitive.NewObjectID(),
+		Username:       "alice",
+		Email:          "alice@example.com",
+		HashedPassword: "$2a$10$secret",
+		Avatar:         &models.Avatar{ID: primitive.NewObjectID()},
+	}
+
+	profile, err := json.Marshal(user.Profile())
+	assert.NoError(t, err)
+	assert.NotContains(t, string(profile), "secret")
+	assert.Contains(t, string(profile), "alice@example.com")
+	assert.Contains(t, string(profile), "/avatars/"+user.Avatar.ID.Hex()+"/small")
+
+	public, err := json.Marshal(user.PublicProfile())
+	assert.NoError(t, err)
+	assert.NotContains(t, string(public), "secret")
+	assert.NotContains(t, string(public), "alice@example.com")
+
+	raw, err := json.Marshal(user)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(raw), "secret")
+}
+
+// TestRenderAvatar tests that avatars are cropped square and rendered in every size.
+func TestRenderAvatar(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 600, 400))))
+
+	images, err := renderAvatar(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Len(t, images, len(models.AvatarSizes))
+	for _, img := range images {
+		config, format, err := image.DecodeConfig(bytes.NewReader(img.data))
+		assert.NoError(t, err)
+		assert.Equal(t, "png", format)
+		assert.Equal(t, img.size.Pixels, config.Width)
+		assert.Equal(t, img.size.Pixels, config.Height)
+	}
+
+	_, err = renderAvatar([]byte("%PDF-1.4"))
+	assert.ErrorIs(t, err, ErrInvalidAvatar)
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/czeful/diplom_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook delivery settings.
+const (
+	webhookMaxAttempts     = 5
+	webhookInitialBackoff  = 2 * time.Second
+	webhookRequestTimeout  = 10 * time.Second
+	webhookMaxResponseBody = 1024
+	webhookTestEvent       = "webhook.test"
+	webhookQueueSize       = 256
+)
+
+// ErrWebhookAddress is returned when a webhook URL points at a loopback, link-local,
+// private or otherwise internal address.
+var ErrWebhookAddress = errors.New("webhook URL must point to a public address")
+
+// SignatureHeader carries the HMAC-SHA256 signature of the request body.
+const SignatureHeader = "X-Webhook-Signature"
+
+// WebhookPayload is the JSON body posted to webhook endpoints.
+type WebhookPayload struct {
+	ID         primitive.ObjectID `json:"id"`
+	Type       string             `json:"type"`
+	OccurredAt time.Time          `json:"occurred_at"`
+	Goal       *models.Goal       `json:"goal,omitempty"`
+	Step       string             `json:"step,omitempty"`
+}
+
+// webhookJob is a delivery waiting in the queue of a WebhookService.
+type webhookJob struct {
+	webhook models.Webhook
+	payload WebhookPayload
+	body    []byte
+}
+
+// WebhookService encapsulates the business logic for webhooks and event delivery.
+type WebhookService struct {
+	repo    *repository.WebhookRepository
+	client  *http.Client
+	backoff time.Duration
+	lookup  func(ctx context.Context, host string) ([]net.IPAddr, error)
+	queue   chan webhookJob
+}
+
+// NewWebhookService creates a new instance of WebhookService. Deliveries are queued
+// until RunDeliveries starts the workers that send them.
+func NewWebhookService(repo *repository.WebhookRepository) *WebhookService {
+	// Check every address the client connects to, so that a host resolving to a public
+	// address at registration cannot be rebound to an internal one later
+	dialer := &net.Dialer{Timeout: webhookRequestTimeout, Control: webhookDialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &WebhookService{
+		repo:    repo,
+		client:  &http.Client{Timeout: webhookRequestTimeout, Transport: transport},
+		backoff: webhookInitialBackoff,
+		lookup:  net.DefaultResolver.LookupIPAddr,
+		queue:   make(chan webhookJob, webhookQueueSize),
+	}
+}
+
+// CheckEndpoint resolves the host of a webhook URL and rejects it when any of its
+// addresses is internal.
+func (s *WebhookService) CheckEndpoint(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL")
+	}
+	addrs, err := s.lookup(ctx, parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("webhook host cannot be resolved")
+	}
+	for _, addr := range addrs {
+		if blockedWebhookIP(addr.IP) {
+			return ErrWebhookAddress
+		}
+	}
+	return nil
+}
+
+// CreateWebhook stores a new webhook, generating a signing secret if none was given.
+func (s *WebhookService) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	if webhook.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %v", err)
+		}
+		webhook.Secret = secret
+	}
+	createdWebhook, err := s.repo.CreateWebhook(ctx, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %v", err)
+	}
+	return createdWebhook, nil
+}
+
+// GetWebhook retrieves a webhook by its ID.
+func (s *WebhookService) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook ID: %v", err)
+	}
+	webhook, err := s.repo.GetWebhookByID(ctx, objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %v", err)
+	}
+	return webhook, nil
+}
+
+// GetWebhooks retrieves all webhooks registered by a user.
+func (s *WebhookService) GetWebhooks(ctx context.Context, userID primitive.ObjectID) ([]models.Webhook, error) {
+	return s.repo.GetWebhooks(ctx, userID)
+}
+
+// UpdateWebhook updates an existing webhook.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id string, webhook *models.Webhook) (*models.Webhook, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook ID: %v", err)
+	}
+	updatedWebhook, err := s.repo.UpdateWebhook(ctx, objID, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %v", err)
+	}
+	return updatedWebhook, nil
+}
+
+// DeleteWebhook removes a webhook and its delivery log.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid webhook ID: %v", err)
+	}
+	if err := s.repo.DeleteWebhook(ctx, objID); err != nil {
+		return fmt.Errorf("failed to delete webhook: %v", err)
+	}
+	return nil
+}
+
+// GetDeliveries retrieves the most recent delivery attempts of a webhook.
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookID primitive.ObjectID, limit int64) ([]models.WebhookDelivery, error) {
+	return s.repo.GetDeliveries(ctx, webhookID, limit)
+}
+
+// SendTestEvent delivers a single test event to the webhook and returns the logged attempt.
+func (s *WebhookService) SendTestEvent(ctx context.Context, webhook *models.Webhook) (*models.WebhookDelivery, error) {
+	payload := WebhookPayload{
+		ID:         primitive.NewObjectID(),
+		Type:       webhookTestEvent,
+		OccurredAt: time.Now(),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+	return s.attempt(ctx, webhook, payload, body, 1)
+}
+
+// HandleGoalEvent is a GoalEventListener that queues the event for every subscribed webhook.
+// Deliveries run in the background so that the originating request is never blocked; when
+// the queue is full, the delivery is dropped.
+func (s *WebhookService) HandleGoalEvent(ctx context.Context, event models.GoalEvent) {
+	webhooks, err := s.repo.GetSubscribedWebhooks(ctx, event.UserID, event.Type)
+	if err != nil {
+		log.Printf("Failed to load webhooks for event %s: %v", event.Type, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload := WebhookPayload{
+		ID:         event.ID,
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		Goal:       event.Goal,
+		Step:       event.Step,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Failed to encode webhook payload: %v", err)
+		return
+	}
+
+	for i := range webhooks {
+		select {
+		case s.queue <- webhookJob{webhook: webhooks[i], payload: payload, body: body}:
+		default:
+			log.Printf("Webhook queue is full, dropping event %s for webhook %s", event.Type, webhooks[i].ID.Hex())
+		}
+	}
+}
+
+// RunDeliveries sends queued deliveries with the given number of workers until ctx
+// is cancelled, and returns once every worker has stopped. At least one worker runs.
+func (s *WebhookService) RunDeliveries(ctx context.Context, workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-s.queue:
+					s.deliver(ctx, job.webhook, job.payload, job.body)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver posts the payload, retrying with exponential backoff until it succeeds,
+// the maximum number of attempts is reached or ctx is cancelled.
+func (s *WebhookService) deliver(ctx context.Context, webhook models.Webhook, payload WebhookPayload, body []byte) {
+	backoff := s.backoff
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		delivery, err := s.attempt(ctx, &webhook, payload, body, attempt)
+		if err != nil {
+			log.Printf("Failed to log webhook delivery: %v", err)
+		}
+		if delivery != nil && delivery.Success {
+			return
+		}
+		if attempt < webhookMaxAttempts {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+	log.Printf("Webhook %s gave up on event %s after %d attempts", webhook.ID.Hex(), payload.Type, webhookMaxAttempts)
+}
+
+// attempt performs a single signed POST and records the outcome in the delivery log.
+func (s *WebhookService) attempt(ctx context.Context, webhook *models.Webhook, payload WebhookPayload, body []byte, attempt int) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		UserID:    webhook.UserID,
+		EventID:   payload.ID,
+		Event:     payload.Type,
+		Payload:   string(body),
+		Attempt:   attempt,
+	}
+
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return s.repo.CreateDelivery(ctx, delivery)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Achievement-Manager-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", payload.Type)
+	req.Header.Set("X-Webhook-Delivery", payload.ID.Hex())
+	req.Header.Set(SignatureHeader, "sha256="+SignPayload(webhook.Secret, body))
+
+	resp, err := s.client.Do(req)
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return s.repo.CreateDelivery(ctx, delivery)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxResponseBody))
+	delivery.StatusCode = resp.StatusCode
+	delivery.ResponseBody = string(respBody)
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+
+	return s.repo.CreateDelivery(ctx, delivery)
+}
+
+// SignPayload returns the hex-encoded HMAC-SHA256 of body keyed with secret.
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateWebhook checks the endpoint URL and the subscribed event types. Hosts given
+// as internal IP addresses or as localhost are rejected; CheckEndpoint resolves host names.
+func ValidateWebhook(webhook *models.Webhook) error {
+	parsed, err := url.ParseRequestURI(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("invalid webhook URL")
+	}
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && blockedWebhookIP(ip) {
+		return ErrWebhookAddress
+	}
+	if len(webhook.Events) == 0 {
+		return fmt.Errorf("at least one event type is required")
+	}
+	for _, event := range webhook.Events {
+		if !models.GoalEventTypes[event] {
+			return fmt.Errorf("unknown event type: %s", event)
+		}
+	}
+	return nil
+}
+
+// blockedWebhookIP reports whether ip is an address webhooks may not be delivered to.
+func blockedWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+// webhookDialControl refuses connections to internal addresses once the host name of a
+// webhook has been resolved.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || blockedWebhookIP(ip) {
+		return ErrWebhookAddress
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
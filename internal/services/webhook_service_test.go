@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/czeful/diplom_back/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSignPayload checks the signature against a known HMAC-SHA256 vector.
+func TestSignPayload(t *testing.T) {
+	signature := SignPayload("key", []byte("The quick brown fox jumps over the lazy dog"))
+	assert.Equal(t, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", signature)
+}
+
+// TestValidateWebhook tests URL and event type validation.
+func TestValidateWebhook(t *testing.T) {
+	valid := &models.Webhook{URL: "https://example.com/hook", Events: []string{models.EventGoalCreated}}
+	assert.NoError(t, ValidateWebhook(valid))
+
+	badURL := &models.Webhook{URL: "ftp://example.com", Events: []string{models.EventGoalCreated}}
+	assert.Error(t, ValidateWebhook(badURL))
+
+	noEvents := &models.Webhook{URL: "https://example.com/hook"}
+	assert.Error(t, ValidateWebhook(noEvents))
+
+	unknownEvent := &models.Webhook{URL: "https://example.com/hook", Events: []string{"goal.exploded"}}
+	assert.Error(t, ValidateWebhook(unknownEvent))
+}
+
+// TestValidateWebhookInternalHosts tests that URLs naming internal hosts are rejected.
+func TestValidateWebhookInternalHosts(t *testing.T) {
+	for _, rawURL := range []string{
+		"http://localhost:8080/hook",
+		"http://127.0.0.1/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0/hook",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+	} {
+		webhook := &models.Webhook{URL: rawURL, Events: []string{models.EventGoalCreated}}
+		assert.ErrorIs(t, ValidateWebhook(webhook), ErrWebhookAddress, rawURL)
+	}
+}
+
+// TestCheckEndpoint tests that host names resolving to internal addresses are rejected.
+func TestCheckEndpoint(t *testing.T) {
+	s := NewWebhookService(nil)
+	addrs := map[string][]net.IPAddr{
+		"public.example":   {{IP: net.ParseIP("93.184.216.34")}},
+		"internal.example": {{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.1.2.3")}},
+	}
+	s.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if found, ok := addrs[host]; ok {
+			return found, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	assert.NoError(t, s.CheckEndpoint(context.Background(), "https://public.example/hook"))
+	assert.ErrorIs(t, s.CheckEndpoint(context.Background(), "https://internal.example/hook"), ErrWebhookAddress)
+	assert.Error(t, s.CheckEndpoint(context.Background(), "https://missing.example/hook"))
+}
+
+// TestWebhookClientRefusesInternalAddresses tests that the delivery client never connects
+// to an internal address, even when the URL passed validation earlier.
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := NewWebhookService(nil)
+	_, err := s.client.Post(server.URL, "application/json", nil)
+	assert.ErrorIs(t, err, ErrWebhookAddress)
+}
+
+// TestRunDeliveriesStops tests that the delivery workers stop once the context is cancelled.
+func TestRunDeliveriesStops(t *testing.T) {
+	s := NewWebhookService(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.RunDeliveries(ctx, 2)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunDeliveries did not stop after cancellation")
+	}
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no blob is stored under a key
+var ErrNotFound = errors.New("blob not found")
+
+// Blob is a stored object opened for reading. It can seek, so it can serve range requests.
+type Blob interface {
+	io.ReadSeekCloser
+	Size() int64
+}
+
+// BlobStore stores binary objects, such as attachments and avatars, under string keys
+type BlobStore interface {
+	// Put stores the content of r under key, replacing any existing blob, and returns its size
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open opens the blob stored under key, or returns ErrNotFound
+	Open(ctx context.Context, key string) (Blob, error)
+	// Delete removes the blob stored under key; deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSStore is a BlobStore keeping blobs in a MongoDB GridFS bucket, using the key as file ID
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+// NewGridFSStore creates a GridFSStore using the bucket with the given name
+func NewGridFSStore(db *mongo.Database, name string) (*GridFSStore, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GridFS bucket: %v", err)
+	}
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+// Put uploads the blob, replacing an existing blob with the same key
+func (s *GridFSStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	if err := s.Delete(ctx, key); err != nil {
+		return 0, err
+	}
+
+	stream, err := s.bucket.OpenUploadStreamWithID(key, key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open upload stream: %v", err)
+	}
+	size, err := io.Copy(stream, r)
+	if err != nil {
+		stream.Abort()
+		return 0, fmt.Errorf("failed to upload blob: %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		return 0, fmt.Errorf("failed to upload blob: %v", err)
+	}
+	return size, nil
+}
+
+// Open opens a download stream for the blob
+func (s *GridFSStore) Open(ctx context.Context, key string) (Blob, error) {
+	stream, err := s.bucket.OpenDownloadStream(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %v", err)
+	}
+	return &gridFSBlob{bucket: s.bucket, key: key, stream: stream, size: stream.GetFile().Length}, nil
+}
+
+// Delete removes the blob and its chunks
+func (s *GridFSStore) Delete(ctx context.Context, key string) error {
+	err := s.bucket.DeleteContext(ctx, key)
+	if err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		return fmt.Errorf("failed to delete blob: %v", err)
+	}
+	return nil
+}
+
+// gridFSBlob makes a GridFS download stream seekable. GridFS streams only read forward,
+// so seeking records the new position and the next read reopens the stream there.
+type gridFSBlob struct {
+	bucket    *gridfs.Bucket
+	key       string
+	stream    *gridfs.DownloadStream
+	streamPos int64
+	pos       int64
+	size      int64
+}
+
+func (b *gridFSBlob) Read(p []byte) (int, error) {
+	if b.pos >= b.size {
+		return 0, io.EOF
+	}
+	if b.stream == nil || b.streamPos != b.pos {
+		if err := b.reopen(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := b.stream.Read(p)
+	b.pos += int64(n)
+	b.streamPos = b.pos
+	return n, err
+}
+
+func (b *gridFSBlob) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = b.pos + offset
+	case io.SeekEnd:
+		pos = b.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if pos < 0 {
+		return 0, fmt.Errorf("negative position: %d", pos)
+	}
+	b.pos = pos
+	return pos, nil
+}
+
+func (b *gridFSBlob) Close() error {
+	if b.stream == nil {
+		return nil
+	}
+	return b.stream.Close()
+}
+
+func (b *gridFSBlob) Size() int64 {
+	return b.size
+}
+
+func (b *gridFSBlob) reopen() error {
+	if b.stream != nil {
+		b.stream.Close()
+	}
+	stream, err := b.bucket.OpenDownloadStream(b.key)
+	if err != nil {
+		b.stream = nil
+		return fmt.Errorf("failed to reopen blob: %v", err)
+	}
+	if _, err := stream.Skip(b.pos); err != nil {
+		stream.Close()
+		b.stream = nil
+		return fmt.Errorf("failed to seek blob: %v", err)
+	}
+	b.stream, b.streamPos = stream, b.pos
+	return nil
+}
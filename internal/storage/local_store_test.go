@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLocalStore tests storing, seeking, replacing and deleting blobs on disk.
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+
+	size, err := store.Put(ctx, "attachments/u1/a1", strings.NewReader("hello world"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), size)
+
+	blob, err := store.Open(ctx, "attachments/u1/a1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), blob.Size())
+	_, err = blob.Seek(6, io.SeekStart)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(blob)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", string(data))
+	blob.Close()
+
+	_, err = store.Put(ctx, "attachments/u1/a1", strings.NewReader("bye"))
+	assert.NoError(t, err)
+	blob, err = store.Open(ctx, "attachments/u1/a1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), blob.Size())
+	blob.Close()
+
+	assert.NoError(t, store.Delete(ctx, "attachments/u1/a1"))
+	assert.NoError(t, store.Delete(ctx, "attachments/u1/a1"))
+	_, err = store.Open(ctx, "attachments/u1/a1")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = store.Put(ctx, "../escape", strings.NewReader("x"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFit tests that images are scaled down with their aspect ratio kept.
+func TestFit(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for x := 0; x < 200; x++ {
+		for y := 0; y < 200; y++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	thumbnail := Fit(img, 100)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), thumbnail.Bounds())
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, thumbnail.RGBAAt(10, 10))
+	assert.Equal(t, color.RGBA{}, thumbnail.RGBAAt(90, 10))
+
+	small := Fit(image.NewRGBA(image.Rect(0, 0, 20, 30)), 100)
+	assert.Equal(t, image.Rect(0, 0, 20, 30), small.Bounds())
+}
+
+// TestSquare tests that the centered square of an image is cropped out.
+func TestSquare(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	for x := 100; x < 200; x++ {
+		for y := 0; y < 100; y++ {
+			img.Set(x, y, color.RGBA{G: 255, A: 255})
+		}
+	}
+
+	square := Square(img)
+	assert.Equal(t, image.Rect(0, 0, 100, 100), square.Bounds())
+	assert.Equal(t, color.RGBA{G: 255, A: 255}, square.At(0, 0))
+	assert.Equal(t, color.RGBA{G: 255, A: 255}, square.At(99, 99))
+}
+
+// TestDecode tests decoding an encoded image and rejecting garbage.
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+
+	img, format, err := Decode(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, 4, img.Bounds().Dx())
+
+	_, _, err = Decode([]byte("not an image"))
+	assert.Error(t, err)
+}
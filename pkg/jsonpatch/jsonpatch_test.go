@@ -0,0 +1,55 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMergePatch tests the examples from RFC 7396 appendix A.
+func TestMergePatch(t *testing.T) {
+	cases := []struct {
+		document, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+	}
+
+	for _, c := range cases {
+		result, err := MergePatch([]byte(c.document), []byte(c.patch))
+		assert.NoError(t, err)
+		assert.JSONEq(t, c.expected, string(result))
+	}
+}
+
+// TestApply tests every JSON Patch operation and atomic failure.
+func TestApply(t *testing.T) {
+	document := `{"name":"Run","steps":["a","c"],"progress":{"a":true,"x/y":false}}`
+	patch := `[
+		{"op":"test","path":"/name","value":"Run"},
+		{"op":"add","path":"/steps/1","value":"b"},
+		{"op":"add","path":"/steps/-","value":"d"},
+		{"op":"replace","path":"/progress/a","value":false},
+		{"op":"remove","path":"/progress/x~1y"},
+		{"op":"copy","from":"/name","path":"/description"},
+		{"op":"move","from":"/description","path":"/category"}
+	]`
+
+	result, err := Apply([]byte(document), []byte(patch))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Run","category":"Run","steps":["a","b","c","d"],"progress":{"a":false}}`, string(result))
+
+	_, err = Apply([]byte(document), []byte(`[{"op":"test","path":"/name","value":"Walk"}]`))
+	assert.ErrorIs(t, err, ErrTestFailed)
+
+	_, err = Apply([]byte(document), []byte(`[{"op":"replace","path":"/missing","value":1}]`))
+	assert.Error(t, err)
+
+	_, err = Apply([]byte(document), []byte(`[{"op":"remove","path":"/steps/5"}]`))
+	assert.Error(t, err)
+}
@@ -10,14 +10,23 @@ import (
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	// OrgID is the organization the user is acting within, empty for personal use
+	OrgID string `json:"org_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateToken creates a new JWT token for the given user.
 func GenerateToken(userID, email, secret string, expiry time.Duration) (string, error) {
+	return GenerateOrgToken(userID, email, "", secret, expiry)
+}
+
+// GenerateOrgToken creates a new JWT token for the given user acting within an
+// organization, or for personal use when orgID is empty.
+func GenerateOrgToken(userID, email, orgID, secret string, expiry time.Duration) (string, error) {
 	claims := Claims{
 		UserID: userID,
 		Email:  email,
+		OrgID:  orgID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -53,3 +53,13 @@ func GetUserFromContext(ctx context.Context) *jwtutil.Claims {
 	}
 	return claims
 }
+
+// GetActiveOrgFromContext returns the organization the user is acting within, or ""
+// for personal use
+func GetActiveOrgFromContext(ctx context.Context) string {
+	claims := GetUserFromContext(ctx)
+	if claims == nil {
+		return ""
+	}
+	return claims.OrgID
+}
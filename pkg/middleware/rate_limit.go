@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter counts the requests of each client over fixed windows.
+type RateLimiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*rateWindow
+	swept   time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewRateLimiter creates a limiter allowing limit requests per client every window.
+// A limit below 1 would reject every request and is raised to 1.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		now:     time.Now,
+		clients: make(map[string]*rateWindow),
+	}
+}
+
+// Allow counts a request of a client. When the client is over the limit, it returns false
+// along with how long until its window resets.
+func (l *RateLimiter) Allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	// Forget the clients whose window is over, so the map doesn't grow without bound
+	if now.Sub(l.swept) >= l.window {
+		for key, w := range l.clients {
+			if now.Sub(w.start) >= l.window {
+				delete(l.clients, key)
+			}
+		}
+		l.swept = now
+	}
+
+	w, ok := l.clients[client]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.clients[client] = w
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// RateLimitMiddleware rejects the requests of a client, identified by its IP address,
+// beyond limit every window with 429 Too Many Requests.
+//
+// The address is taken from the connection, not from headers such as X-Forwarded-For that
+// clients can forge. Behind a reverse proxy every request comes from the proxy, so all
+// clients share a single limit.
+func RateLimitMiddleware(limit int, window time.Duration) func(http.Handler) http.Handler {
+	limiter := NewRateLimiter(limit, window)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				client = r.RemoteAddr
+			}
+
+			if ok, retryAfter := limiter.Allow(client); !ok {
+				seconds := int((retryAfter + time.Second - 1) / time.Second)
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRateLimiter tests that each client gets its own window of requests.
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	ok, _ := limiter.Allow("a")
+	assert.True(t, ok)
+	ok, _ = limiter.Allow("a")
+	assert.True(t, ok)
+	ok, retryAfter := limiter.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	ok, _ = limiter.Allow("b")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	ok, _ = limiter.Allow("a")
+	assert.True(t, ok)
+}
+
+// TestRateLimitMiddleware tests that requests over the limit are rejected with Retry-After.
+func TestRateLimitMiddleware(t *testing.T) {
+	handler := RateLimitMiddleware(1, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/public/goals/token", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:5000").Code)
+	rec := request("10.0.0.1:5001")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, request("10.0.0.2:5000").Code)
+}
+
+// TestRateLimiterMinimumLimit tests that a limit below 1 still lets a request through.
+func TestRateLimiterMinimumLimit(t *testing.T) {
+	limiter := NewRateLimiter(0, time.Minute)
+	ok, _ := limiter.Allow("a")
+	assert.True(t, ok)
+	ok, _ = limiter.Allow("a")
+	assert.False(t, ok)
+}